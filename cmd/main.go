@@ -1,16 +1,28 @@
 package main
 
 import (
-	"fmt"
-	"strconv"
+	"context"
 
+	"github.com/gostream-official/tracks/impl/config"
+	"github.com/gostream-official/tracks/impl/funcs/batchtracks"
 	"github.com/gostream-official/tracks/impl/funcs/createtrack"
 	"github.com/gostream-official/tracks/impl/funcs/deletetrack"
+	"github.com/gostream-official/tracks/impl/funcs/getliveness"
+	"github.com/gostream-official/tracks/impl/funcs/getmetrics"
+	"github.com/gostream-official/tracks/impl/funcs/getreadiness"
 	"github.com/gostream-official/tracks/impl/funcs/gettrack"
+	"github.com/gostream-official/tracks/impl/funcs/gettrackrevision"
+	"github.com/gostream-official/tracks/impl/funcs/gettrackrevisions"
 	"github.com/gostream-official/tracks/impl/funcs/gettracks"
+	"github.com/gostream-official/tracks/impl/funcs/gettrackstats"
+	"github.com/gostream-official/tracks/impl/funcs/patchtrack"
+	"github.com/gostream-official/tracks/impl/funcs/purgetracks"
+	"github.com/gostream-official/tracks/impl/funcs/restoretrack"
+	"github.com/gostream-official/tracks/impl/funcs/reverttrack"
+	"github.com/gostream-official/tracks/impl/funcs/searchtracks"
 	"github.com/gostream-official/tracks/impl/funcs/updatetrack"
 	"github.com/gostream-official/tracks/impl/inject"
-	"github.com/gostream-official/tracks/pkg/env"
+	"github.com/gostream-official/tracks/impl/middleware"
 	"github.com/gostream-official/tracks/pkg/router"
 	"github.com/gostream-official/tracks/pkg/store"
 
@@ -32,54 +44,65 @@ func init() {
 func main() {
 	log.Infof("booting service instance ...")
 
-	executionPortEnvVar := env.GetEnvironmentVariableWithFallback("PORT", "9871")
-	executionPort, err := strconv.Atoi(executionPortEnvVar)
-
+	config, err := config.Load()
 	if err != nil {
-		log.Fatalf("Received invalid execution port")
+		log.Fatalf("failed to load service configuration: %s", err)
 	}
 
-	if executionPort < 0 || executionPort > 65535 {
-		log.Fatalf("Received invalid execution port")
-	}
+	log.Infof("establishing database connection ...")
 
-	mongoUsername, err := env.GetEnvironmentVariable("MONGO_USERNAME")
+	instance, err := store.NewMongoInstance(config.Mongo.ConnectionURI())
 	if err != nil {
-		log.Fatalf("Cannot retrieve mongo username via environment variable")
+		log.Fatalf("failed to connect to mongo instance: %s", err)
 	}
 
-	mongoPassword, err := env.GetEnvironmentVariable("MONGO_PASSWORD")
-	if err != nil {
-		log.Fatalf("Cannot retrieve mongo password via environment variable")
-	}
+	log.Infof("successfully established database connection")
 
-	mongoHost := env.GetEnvironmentVariableWithFallback("MONGO_HOST", "127.0.0.1:27017")
+	injector := inject.NewMongoInjector(instance, config.Mongo)
+	injector.TrashRetention = config.TrashRetention
+	injector.AdminPrincipals = config.AdminPrincipals
+	injector.Health.SetTimeout(config.HealthCheckTimeout)
 
-	connectionURI := fmt.Sprintf("mongodb://%s:%s@%s", mongoUsername, mongoPassword, mongoHost)
-	instance, err := store.NewMongoInstance(connectionURI)
+	log.Infof("ensuring database indexes ...")
 
-	log.Infof("establishing database connection ...")
+	err = injector.EnsureIndexes(context.Background())
 	if err != nil {
-		log.Fatalf("failed to connect to mongo instance: %s", err)
-	}
-
-	log.Infof("successfully established database connection")
-
-	injector := inject.Injector{
-		MongoInstance: instance,
+		log.Fatalf("failed to ensure database indexes: %s", err)
 	}
 
 	log.Infof("launching router engine ...")
 	engine := router.Default()
-
-	engine.HandleWith("GET", "/tracks", gettracks.Handler).Inject(injector)
-	engine.HandleWith("GET", "/tracks/:id", gettrack.Handler).Inject(injector)
-	engine.HandleWith("POST", "/tracks", createtrack.Handler).Inject(injector)
-	engine.HandleWith("PUT", "/tracks/:id", updatetrack.Handler).Inject(injector)
-	engine.HandleWith("DELETE", "/tracks/:id", deletetrack.Handler).Inject(injector)
-
-	err = engine.Run(uint16(executionPort))
+	engine.OnDraining(injector.Health.MarkShuttingDown)
+	engine.OnShutdown(instance.Disconnect)
+
+	// Probes and scrapes are registered before the middlewares, so frequent polling does not flood the logs and metrics.
+	engine.HandleWith("GET", "/healthz", getliveness.Handler)
+	engine.HandleWith("GET", "/readyz", middleware.Handle(getreadiness.Handler)).Inject(injector)
+	engine.HandleWith("GET", "/metrics", middleware.Handle(getmetrics.Handler)).Inject(injector)
+
+	engine.Use(middleware.Metrics(injector.Metrics))
+	engine.Use(middleware.Logging)
+	engine.Use(middleware.Principal)
+
+	engine.HandleWith("GET", "/tracks", middleware.Handle(gettracks.Handler)).Inject(injector).WithTimeout(config.RequestTimeout)
+	engine.HandleWith("GET", "/tracks/stats", middleware.Handle(gettrackstats.Handler)).Inject(injector).WithTimeout(config.RequestTimeout)
+	engine.HandleWith("POST", "/tracks/search", middleware.Handle(searchtracks.Handler)).Inject(injector).WithTimeout(config.RequestTimeout)
+	engine.HandleWith("GET", "/tracks/:id", middleware.Handle(gettrack.Handler)).Inject(injector).WithTimeout(config.RequestTimeout)
+	engine.HandleWith("POST", "/tracks", middleware.Handle(createtrack.Handler)).Inject(injector).WithTimeout(config.RequestTimeout)
+	engine.HandleWith("POST", "/tracks:batch", middleware.Handle(batchtracks.Handler)).Inject(injector).WithTimeout(config.RequestTimeout)
+	engine.HandleWith("PUT", "/tracks/:id", middleware.Handle(updatetrack.Handler)).Inject(injector).WithTimeout(config.RequestTimeout)
+	engine.HandleWith("PATCH", "/tracks/:id", middleware.Handle(patchtrack.Handler)).Inject(injector).WithTimeout(config.RequestTimeout)
+	engine.HandleWith("DELETE", "/tracks/:id", middleware.Handle(deletetrack.Handler)).Inject(injector).WithTimeout(config.RequestTimeout)
+	engine.HandleWith("POST", "/tracks/:id/restore", middleware.Handle(restoretrack.Handler)).Inject(injector).WithTimeout(config.RequestTimeout)
+	engine.HandleWith("POST", "/tracks:purge", middleware.Handle(purgetracks.Handler)).Inject(injector).WithTimeout(config.RequestTimeout)
+	engine.HandleWith("GET", "/tracks/:id/revisions", middleware.Handle(gettrackrevisions.Handler)).Inject(injector).WithTimeout(config.RequestTimeout)
+	engine.HandleWith("GET", "/tracks/:id/revisions/:rev", middleware.Handle(gettrackrevision.Handler)).Inject(injector).WithTimeout(config.RequestTimeout)
+	engine.HandleWith("POST", "/tracks/:id/revisions/:rev/revert", middleware.Handle(reverttrack.Handler)).Inject(injector).WithTimeout(config.RequestTimeout)
+
+	err = engine.Run(config.Port, config.ShutdownDelay, config.ShutdownTimeout)
 	if err != nil {
-		log.Fatalf("failed to launch router engine: %s", err)
+		log.Fatalf("router engine stopped with error: %s", err)
 	}
+
+	log.Infof("service instance stopped")
 }
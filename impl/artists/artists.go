@@ -0,0 +1,94 @@
+package artists
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gostream-official/tracks/impl/models"
+	"github.com/gostream-official/tracks/pkg/store"
+	"github.com/gostream-official/tracks/pkg/store/query"
+)
+
+var (
+
+	// Returned if the referenced artist does not exist.
+	ErrArtistNotFound = errors.New("artists: artist not found")
+)
+
+// Description:
+//
+//	Checks whether an artist referenced by a write exists, e.g. the artist of a track.
+//	Should run within the transaction of the write, so the check and the write observe the same snapshot.
+//
+//	The artist collection is owned by the artist service, so the artist is only read, never written.
+//	A snapshot read does not conflict with a concurrent delete of the artist: the artist service
+//	is responsible for checking for referencing tracks before deleting an artist.
+//
+// Parameters:
+//
+//	ctx 		The transaction context.
+//	artistStore The artist store.
+//	artistID 	The id of the referenced artist.
+//
+// Returns:
+//
+//	ErrArtistNotFound if the artist does not exist.
+//	An error if the database request fails.
+func Exists(ctx context.Context, artistStore store.Store[models.ArtistInfo], artistID string) error {
+	existing, err := FindExisting(ctx, artistStore, []string{artistID})
+	if err != nil {
+		return err
+	}
+
+	if !existing[artistID] {
+		return ErrArtistNotFound
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Checks which of multiple referenced artists exist, like Exists, using a single query.
+//
+// Parameters:
+//
+//	ctx 		The transaction context.
+//	artistStore The artist store.
+//	artistIDs 	The distinct ids of the referenced artists.
+//
+// Returns:
+//
+//	The set of existing artist ids.
+//	An error if the database request fails.
+func FindExisting(ctx context.Context, artistStore store.Store[models.ArtistInfo], artistIDs []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+
+	if len(artistIDs) == 0 {
+		return existing, nil
+	}
+
+	values := make([]interface{}, len(artistIDs))
+	for index, artistID := range artistIDs {
+		values[index] = artistID
+	}
+
+	filter := query.Filter{
+		Root: query.FilterOperatorIn{
+			Key:    "_id",
+			Values: values,
+		},
+		Projection: []string{"_id"},
+	}
+
+	artists, err := artistStore.FindItems(ctx, &filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, artist := range artists {
+		existing[artist.ID] = true
+	}
+
+	return existing, nil
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gostream-official/tracks/pkg/env"
+)
+
+// Description:
+//
+//	The service configuration.
+//	Loaded from environment variables.
+type Config struct {
+
+	// The port the service listens on.
+	Port uint16
+
+	// The maximum duration of a single request.
+	RequestTimeout time.Duration
+
+	// The duration the service keeps accepting requests after reporting unready on shutdown.
+	ShutdownDelay time.Duration
+
+	// The maximum duration of draining in-flight requests on shutdown.
+	ShutdownTimeout time.Duration
+
+	// The maximum duration of a single readiness check.
+	HealthCheckTimeout time.Duration
+
+	// The duration deleted tracks are kept in the trash before they can be purged.
+	TrashRetention time.Duration
+
+	// The principals allowed to use administrative endpoints, e.g. purging the trash.
+	AdminPrincipals []string
+
+	// The database configuration.
+	Mongo MongoConfig
+}
+
+// Description:
+//
+//	The MongoDB configuration.
+type MongoConfig struct {
+
+	// The MongoDB username.
+	Username string
+
+	// The MongoDB password.
+	Password string
+
+	// The MongoDB host, including the port.
+	Host string
+
+	// The name of the database.
+	Database string
+
+	// The name of the tracks collection.
+	TracksCollection string
+
+	// The name of the artists collection.
+	ArtistsCollection string
+
+	// The name of the track revisions collection.
+	RevisionsCollection string
+}
+
+// Description:
+//
+//	Loads the service configuration from the environment.
+//
+//	Environment variables:
+//	  - PORT (default: 9871)
+//	  - REQUEST_TIMEOUT (default: 10s)
+//	  - SHUTDOWN_DELAY (default: 5s)
+//	  - SHUTDOWN_TIMEOUT (default: 20s)
+//	  - HEALTH_CHECK_TIMEOUT (default: 2s)
+//	  - TRASH_RETENTION (default: 720h)
+//	  - ADMIN_PRINCIPALS (default: none, comma-separated)
+//	  - MONGO_USERNAME (required)
+//	  - MONGO_PASSWORD (required)
+//	  - MONGO_HOST (default: 127.0.0.1:27017)
+//	  - MONGO_DATABASE (default: gostream)
+//	  - MONGO_TRACKS_COLLECTION (default: tracks)
+//	  - MONGO_ARTISTS_COLLECTION (default: artists)
+//	  - MONGO_REVISIONS_COLLECTION (default: track_revisions)
+//
+// Returns:
+//
+//	The loaded configuration, or an error if a variable is missing or invalid.
+func Load() (*Config, error) {
+	portEnvVar := env.GetEnvironmentVariableWithFallback("PORT", "9871")
+
+	port, err := strconv.ParseUint(portEnvVar, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("config: received invalid execution port: %s", portEnvVar)
+	}
+
+	requestTimeoutEnvVar := env.GetEnvironmentVariableWithFallback("REQUEST_TIMEOUT", "10s")
+
+	requestTimeout, err := time.ParseDuration(requestTimeoutEnvVar)
+	if err != nil || requestTimeout < 0 {
+		return nil, fmt.Errorf("config: received invalid request timeout: %s", requestTimeoutEnvVar)
+	}
+
+	shutdownDelayEnvVar := env.GetEnvironmentVariableWithFallback("SHUTDOWN_DELAY", "5s")
+
+	shutdownDelay, err := time.ParseDuration(shutdownDelayEnvVar)
+	if err != nil || shutdownDelay < 0 {
+		return nil, fmt.Errorf("config: received invalid shutdown delay: %s", shutdownDelayEnvVar)
+	}
+
+	shutdownTimeoutEnvVar := env.GetEnvironmentVariableWithFallback("SHUTDOWN_TIMEOUT", "20s")
+
+	shutdownTimeout, err := time.ParseDuration(shutdownTimeoutEnvVar)
+	if err != nil || shutdownTimeout < 0 {
+		return nil, fmt.Errorf("config: received invalid shutdown timeout: %s", shutdownTimeoutEnvVar)
+	}
+
+	healthCheckTimeoutEnvVar := env.GetEnvironmentVariableWithFallback("HEALTH_CHECK_TIMEOUT", "2s")
+
+	healthCheckTimeout, err := time.ParseDuration(healthCheckTimeoutEnvVar)
+	if err != nil || healthCheckTimeout < 0 {
+		return nil, fmt.Errorf("config: received invalid health check timeout: %s", healthCheckTimeoutEnvVar)
+	}
+
+	trashRetentionEnvVar := env.GetEnvironmentVariableWithFallback("TRASH_RETENTION", "720h")
+
+	trashRetention, err := time.ParseDuration(trashRetentionEnvVar)
+	if err != nil || trashRetention < 0 {
+		return nil, fmt.Errorf("config: received invalid trash retention: %s", trashRetentionEnvVar)
+	}
+
+	adminPrincipals := make([]string, 0)
+
+	for _, principal := range strings.Split(env.GetEnvironmentVariableWithFallback("ADMIN_PRINCIPALS", ""), ",") {
+		principal = strings.TrimSpace(principal)
+		if len(principal) > 0 {
+			adminPrincipals = append(adminPrincipals, principal)
+		}
+	}
+
+	mongoUsername, err := env.GetEnvironmentVariable("MONGO_USERNAME")
+	if err != nil {
+		return nil, fmt.Errorf("config: cannot retrieve mongo username: %w", err)
+	}
+
+	mongoPassword, err := env.GetEnvironmentVariable("MONGO_PASSWORD")
+	if err != nil {
+		return nil, fmt.Errorf("config: cannot retrieve mongo password: %w", err)
+	}
+
+	return &Config{
+		Port:               uint16(port),
+		RequestTimeout:     requestTimeout,
+		ShutdownDelay:      shutdownDelay,
+		ShutdownTimeout:    shutdownTimeout,
+		HealthCheckTimeout: healthCheckTimeout,
+		TrashRetention:     trashRetention,
+		AdminPrincipals:    adminPrincipals,
+		Mongo: MongoConfig{
+			Username:            mongoUsername,
+			Password:            mongoPassword,
+			Host:                env.GetEnvironmentVariableWithFallback("MONGO_HOST", "127.0.0.1:27017"),
+			Database:            env.GetEnvironmentVariableWithFallback("MONGO_DATABASE", "gostream"),
+			TracksCollection:    env.GetEnvironmentVariableWithFallback("MONGO_TRACKS_COLLECTION", "tracks"),
+			ArtistsCollection:   env.GetEnvironmentVariableWithFallback("MONGO_ARTISTS_COLLECTION", "artists"),
+			RevisionsCollection: env.GetEnvironmentVariableWithFallback("MONGO_REVISIONS_COLLECTION", "track_revisions"),
+		},
+	}, nil
+}
+
+// Description:
+//
+//	Builds the MongoDB connection URI.
+//
+// Returns:
+//
+//	The MongoDB connection URI.
+func (config MongoConfig) ConnectionURI() string {
+	return fmt.Sprintf("mongodb://%s:%s@%s", config.Username, config.Password, config.Host)
+}
@@ -0,0 +1,439 @@
+package batchtracks
+
+import (
+	"bytes"
+	stdcontext "context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gostream-official/tracks/impl/artists"
+	"github.com/gostream-official/tracks/impl/funcs/createtrack"
+	"github.com/gostream-official/tracks/impl/inject"
+	"github.com/gostream-official/tracks/impl/models"
+	"github.com/gostream-official/tracks/impl/revisions"
+	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/clock"
+	"github.com/gostream-official/tracks/pkg/parallel"
+	"github.com/revx-official/output/log"
+)
+
+const (
+
+	// The maximum size of the request body in bytes.
+	MaxBodySize = 1024 * 1024
+
+	// The maximum number of items of a single batch.
+	MaxBatchSize = 500
+)
+
+// Description:
+//
+//	The request body for the batch tracks endpoint.
+type BatchTracksRequestBody struct {
+
+	// The tracks to create, each following the request body of the create track endpoint.
+	Items []json.RawMessage `json:"items"`
+
+	// Whether the items are processed in order, stopping at the first failed item.
+	// Otherwise, all valid items are created, regardless of failed items.
+	Ordered bool `json:"ordered"`
+}
+
+// Description:
+//
+//	The response body for the batch tracks endpoint.
+type BatchTracksResponseBody struct {
+
+	// The number of created tracks.
+	InsertedCount int64 `json:"insertedCount"`
+
+	// The results of the items, in the order of the request items.
+	Results []BatchTracksItemResult `json:"results"`
+}
+
+// Description:
+//
+//	The result of a single batch item.
+type BatchTracksItemResult struct {
+
+	// The index of the item within the request items.
+	Index int `json:"index"`
+
+	// The HTTP status code of the item: ok if the track was created, bad request if the item failed validation
+	// and unprocessable entity if a referenced artist does not exist.
+	// Items skipped in ordered mode receive a failed dependency status.
+	Status int `json:"status"`
+
+	// The ID of the created track. Empty if the item failed.
+	ID string `json:"id,omitempty"`
+
+	// The errors of the item. Empty if the item succeeded.
+	Errors []createtrack.CreateTrackValidationError `json:"errors,omitempty"`
+}
+
+// Description:
+//
+//	The error response body for the batch tracks endpoint.
+type BatchTracksErrorResponseBody struct {
+
+	// The error message.
+	Message string `json:"message"`
+}
+
+// Description:
+//
+//	Unmarshals the request body for this endpoint.
+//	Unknown fields are rejected. Items are unmarshalled separately, see ValidateItem.
+//
+// Parameters:
+//
+//	request The original request.
+//
+// Returns:
+//
+//	The unmarshalled request body, or an error when unmarshalling fails.
+func ExtractRequestBody(request *api.APIRequest) (*BatchTracksRequestBody, error) {
+	body := &BatchTracksRequestBody{}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(request.Body)))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// Description:
+//
+//	Validates the request body for this endpoint.
+//	The items themselves are validated separately.
+//
+// Parameters:
+//
+//	request The request body.
+//
+// Returns:
+//
+//	An error if the validation fails.
+func ValidateRequestBody(request *BatchTracksRequestBody) *createtrack.CreateTrackValidationError {
+	if len(request.Items) == 0 {
+		return &createtrack.CreateTrackValidationError{
+			FieldRef:     "items",
+			ErrorMessage: "array must not be empty",
+		}
+	}
+
+	if len(request.Items) > MaxBatchSize {
+		return &createtrack.CreateTrackValidationError{
+			FieldRef:     "items",
+			ErrorMessage: fmt.Sprintf("array must not contain more than %d items", MaxBatchSize),
+		}
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Unmarshals and validates a single batch item,
+//	using the decoding and the rules of the create track endpoint,
+//	so a batch item is accepted exactly if the create track endpoint accepts it.
+//
+// Parameters:
+//
+//	item The JSON encoded item.
+//
+// Returns:
+//
+//	The unmarshalled item, or an error if unmarshalling or validation fails.
+func ValidateItem(item json.RawMessage) (*createtrack.CreateTrackRequestBody, *createtrack.CreateTrackValidationError) {
+	body, err := createtrack.ExtractRequestBody(&api.APIRequest{Body: string(item)})
+	if err != nil {
+		return nil, &createtrack.CreateTrackValidationError{
+			ErrorMessage: "invalid item",
+		}
+	}
+
+	validationError := createtrack.ValidateRequestBody(body)
+	if validationError != nil {
+		return nil, validationError
+	}
+
+	return body, nil
+}
+
+// Description:
+//
+//	Collects the distinct artist ids referenced by the given items.
+//
+// Parameters:
+//
+//	items The validated items. Nil entries are ignored.
+//
+// Returns:
+//
+//	The distinct artist ids, including featured artists.
+func CollectArtistIDs(items []*createtrack.CreateTrackRequestBody) []string {
+	seen := make(map[string]bool)
+	artistIDs := make([]string, 0)
+
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+
+		for _, artistID := range append([]string{item.ArtistID}, item.FeaturedArtistIDs...) {
+			if seen[artistID] {
+				continue
+			}
+
+			seen[artistID] = true
+			artistIDs = append(artistIDs, artistID)
+		}
+	}
+
+	return artistIDs
+}
+
+// Description:
+//
+//	Checks the artist references of a validated item.
+//
+// Parameters:
+//
+//	item 		The validated item.
+//	existing 	The set of existing artist ids.
+//
+// Returns:
+//
+//	The errors for missing artists, empty if all artists exist.
+func CheckArtists(item *createtrack.CreateTrackRequestBody, existing map[string]bool) []createtrack.CreateTrackValidationError {
+	errors := make([]createtrack.CreateTrackValidationError, 0)
+
+	if !existing[item.ArtistID] {
+		errors = append(errors, createtrack.CreateTrackValidationError{
+			FieldRef:     "artistId",
+			ErrorMessage: "artist does not exist",
+		})
+	}
+
+	for _, featuredArtist := range item.FeaturedArtistIDs {
+		if !existing[featuredArtist] {
+			errors = append(errors, createtrack.CreateTrackValidationError{
+				FieldRef:     "featuredArtistIds",
+				ErrorMessage: "featured artist does not exist",
+			})
+
+			break
+		}
+	}
+
+	return errors
+}
+
+// Description:
+//
+//	Creates the tracks of the validated items, together with their revisions.
+//	Must run within a transaction, so tracks are never created without their revisions
+//	and the artist check observes the same snapshot as the writes, see artists.Exists.
+//	MongoDB aborts a transaction on any write error, so a failed write fails the whole batch.
+//
+// Parameters:
+//
+//	ctx 		The transaction context.
+//	injector 	The injector.
+//	items 		The validated items. Nil entries failed validation.
+//	validated 	The item results after validation. Left unmodified, so the transaction can be retried.
+//	ordered 	Whether the items are processed in order, skipping all items after the first failed item.
+//	now 		The creation date of the tracks.
+//	principal 	The principal creating the tracks.
+//
+// Returns:
+//
+//	The response body, holding the results of all items.
+//	An error if a database request fails.
+func CreateTracks(ctx stdcontext.Context, injector *inject.Injector, items []*createtrack.CreateTrackRequestBody, validated []BatchTracksItemResult, ordered bool, now time.Time, principal string) (*BatchTracksResponseBody, error) {
+	results := append([]BatchTracksItemResult{}, validated...)
+
+	existingArtists, err := artists.FindExisting(ctx, injector.ArtistStore, CollectArtistIDs(items))
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := make([]models.TrackInfo, 0, len(items))
+	positions := make([]int, 0, len(items))
+
+	for index, item := range items {
+		if results[index].Status != 0 {
+			if ordered && results[index].Status != http.StatusFailedDependency {
+				skipResults(results, index+1)
+			}
+
+			continue
+		}
+
+		results[index].Errors = CheckArtists(item, existingArtists)
+
+		if len(results[index].Errors) > 0 {
+			results[index].Status = http.StatusUnprocessableEntity
+
+			if ordered {
+				skipResults(results, index+1)
+			}
+
+			continue
+		}
+
+		track := createtrack.CreateTrackFromRequestBody(injector.IDGenerator.NewID(), now, principal, item)
+
+		tracks = append(tracks, track)
+		positions = append(positions, index)
+	}
+
+	bulkResult, err := injector.TrackStore.CreateItems(ctx, tracks, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bulkResult.Errors) > 0 {
+		return nil, fmt.Errorf("batchtracks: failed to create track at index %d: %s", positions[bulkResult.Errors[0].Index], bulkResult.Errors[0].Message)
+	}
+
+	trackRevisions := make([]models.TrackRevision, len(tracks))
+
+	for index, track := range tracks {
+		trackRevisions[index], err = revisions.NewRevision(models.RevisionOperationCreate, nil, track)
+		if err != nil {
+			return nil, err
+		}
+
+		position := positions[index]
+		results[position].Status = http.StatusOK
+		results[position].ID = track.ID
+	}
+
+	revisionResult, err := injector.RevisionStore.CreateItems(ctx, trackRevisions, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(revisionResult.Errors) > 0 {
+		return nil, fmt.Errorf("batchtracks: failed to record revision of track %s: %s", trackRevisions[revisionResult.Errors[0].Index].TrackID, revisionResult.Errors[0].Message)
+	}
+
+	return &BatchTracksResponseBody{
+		InsertedCount: bulkResult.InsertedCount,
+		Results:       results,
+	}, nil
+}
+
+// Description:
+//
+//	Marks all pending results from the given index on as skipped.
+//
+// Parameters:
+//
+//	results The item results.
+//	from 	The index of the first result to skip.
+func skipResults(results []BatchTracksItemResult, from int) {
+	for index := from; index < len(results); index++ {
+		if results[index].Status != 0 {
+			continue
+		}
+
+		results[index].Status = http.StatusFailedDependency
+		results[index].Errors = []createtrack.CreateTrackValidationError{
+			{
+				ErrorMessage: "skipped due to a preceding failed item",
+			},
+		}
+	}
+}
+
+// Description:
+//
+//	The router handler for: Batch Tracks
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//
+// Returns:
+//
+//	An API response object, or an error which is answered with an error status.
+func Handler(request *api.APIRequest, injector *inject.Injector) (*api.APIResponse, error) {
+	context := parallel.FromContext(request.Context)
+
+	if len(request.Body) > MaxBodySize {
+		log.Warnf("[%s] request body exceeds %d bytes", context.ID, MaxBodySize)
+		return &api.APIResponse{
+			StatusCode: http.StatusRequestEntityTooLarge,
+			Body: BatchTracksErrorResponseBody{
+				Message: "request body too large",
+			},
+		}, nil
+	}
+
+	requestBody, err := ExtractRequestBody(request)
+	if err != nil {
+		log.Warnf("[%s] failed to extract request body: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body: BatchTracksErrorResponseBody{
+				Message: "invalid request body",
+			},
+		}, nil
+	}
+
+	validationError := ValidateRequestBody(requestBody)
+	if validationError != nil {
+		log.Warnf("[%s] failed request body validation: %s", context.ID, validationError.ErrorMessage)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body:       validationError,
+		}, nil
+	}
+
+	results := make([]BatchTracksItemResult, len(requestBody.Items))
+	items := make([]*createtrack.CreateTrackRequestBody, len(requestBody.Items))
+
+	for index, rawItem := range requestBody.Items {
+		results[index].Index = index
+
+		item, validationError := ValidateItem(rawItem)
+		if validationError != nil {
+			results[index].Status = http.StatusBadRequest
+			results[index].Errors = []createtrack.CreateTrackValidationError{*validationError}
+			continue
+		}
+
+		items[index] = item
+	}
+
+	now := clock.Timestamp(injector.Clock)
+	principal := api.GetPrincipal(request)
+
+	var responseBody *BatchTracksResponseBody
+
+	log.Tracef("[%s] attempting to create database items ...", context.ID)
+	err = injector.Transactor.WithTransaction(request.Context, func(tx stdcontext.Context) error {
+		responseBody, err = CreateTracks(tx, injector, items, results, requestBody.Ordered, now, principal)
+		return err
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database items: %w", err)
+	}
+
+	log.Tracef("[%s] successfully completed request", context.ID)
+	return &api.APIResponse{
+		StatusCode: http.StatusOK,
+		Body:       *responseBody,
+	}, nil
+}
@@ -1,25 +1,37 @@
 package createtrack
 
 import (
+	stdcontext "context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/gostream-official/tracks/impl/artists"
 	"github.com/gostream-official/tracks/impl/inject"
 	"github.com/gostream-official/tracks/impl/models"
+	"github.com/gostream-official/tracks/impl/revisions"
 	"github.com/gostream-official/tracks/pkg/api"
 	"github.com/gostream-official/tracks/pkg/arrays"
-	"github.com/gostream-official/tracks/pkg/marshal"
+	"github.com/gostream-official/tracks/pkg/clock"
 	"github.com/gostream-official/tracks/pkg/parallel"
 	"github.com/gostream-official/tracks/pkg/store"
-	"github.com/gostream-official/tracks/pkg/store/query"
 	"github.com/revx-official/output/log"
 
 	"github.com/google/uuid"
 )
 
+var (
+
+	// Returned if a referenced artist does not exist.
+	ErrArtistNotFound = errors.New("createtrack: artist not found")
+
+	// Returned if a referenced featured artist does not exist.
+	ErrFeaturedArtistNotFound = errors.New("createtrack: featured artist not found")
+)
+
 // Description:
 //
 //	The request body for the create track endpoint.
@@ -115,28 +127,6 @@ type CreateTrackValidationError struct {
 	ErrorMessage string `json:"error"`
 }
 
-// Description:
-//
-//	Attempts to cast the input object to the endpoint injector.
-//	If this cast fails, we cannot proceed to process this request.
-//
-// Parameters:
-//
-//	object 	The injector object.
-//
-// Returns:
-//
-//	The injector if the cast is successful, an error otherwise.
-func GetSafeInjector(object interface{}) (*inject.Injector, error) {
-	injector, ok := object.(inject.Injector)
-
-	if !ok {
-		return nil, fmt.Errorf("createtrack: failed to deduce injector")
-	}
-
-	return &injector, nil
-}
-
 // Description:
 //
 //	Unmarshals the request body for this endpoint.
@@ -254,33 +244,58 @@ func ValidateAudioFeaturesRequestBody(request *CreateTrackAudioFeaturesRequestBo
 
 // Description:
 //
-//	Checks whether the given artist id exists in the mongo store.
+//	Checks whether the given artist id exists in the artist store.
+//	Reads the artist within the transaction, see artists.Exists.
 //
 // Parameters:
 //
-//	store 		The mongo store to search.
+//	ctx 		The transaction context.
+//	store 		The artist store to search.
 //	artistID 	The artist id to search.
 //
 // Returns:
 //
 //	An error, if the artist could not be found or an error,
 //	if the database request failed, nothing if successful.
-func CheckIfArtistExists(store *store.MongoStore[models.ArtistInfo], artistID string) error {
-	filter := query.Filter{
-		Root: query.FilterOperatorEq{
-			Key:   "_id",
-			Value: artistID,
-		},
-		Limit: 1,
+func CheckIfArtistExists(ctx stdcontext.Context, store store.Store[models.ArtistInfo], artistID string) error {
+	err := artists.Exists(ctx, store, artistID)
+	if errors.Is(err, artists.ErrArtistNotFound) {
+		return ErrArtistNotFound
 	}
 
-	items, err := store.FindItems(&filter)
+	return err
+}
+
+// Description:
+//
+//	Checks whether the artist and all featured artists exist in the artist store.
+//
+// Parameters:
+//
+//	ctx 				The transaction context.
+//	store 				The artist store to search.
+//	artistID 			The artist id to search.
+//	featuredArtistIDs 	The featured artist ids to search.
+//
+// Returns:
+//
+//	ErrArtistNotFound or ErrFeaturedArtistNotFound, if an artist could not be found,
+//	an error, if the database request failed, nothing if successful.
+func CheckArtistReferences(ctx stdcontext.Context, store store.Store[models.ArtistInfo], artistID string, featuredArtistIDs []string) error {
+	err := CheckIfArtistExists(ctx, store, artistID)
 	if err != nil {
 		return err
 	}
 
-	if len(items) == 0 {
-		return fmt.Errorf("artist not found")
+	for _, featuredArtist := range featuredArtistIDs {
+		err = CheckIfArtistExists(ctx, store, featuredArtist)
+		if errors.Is(err, ErrArtistNotFound) {
+			return ErrFeaturedArtistNotFound
+		}
+
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -288,29 +303,66 @@ func CheckIfArtistExists(store *store.MongoStore[models.ArtistInfo], artistID st
 
 // Description:
 //
-//	The router handler for track creation.
+//	Creates the track from a validated request body.
 //
 // Parameters:
 //
-//	request The incoming request.
-//	object 	The injector. Contains injected dependencies.
+//	id 			The ID of the track.
+//	now 		The creation date of the track.
+//	principal 	The principal creating the track.
+//	request 	The validated request body.
 //
 // Returns:
 //
-//	An API response object.
-func Handler(request *api.APIRequest, object interface{}) *api.APIResponse {
-	context := parallel.NewContext()
+//	The created track.
+func CreateTrackFromRequestBody(id string, now time.Time, principal string, request *CreateTrackRequestBody) models.TrackInfo {
+	releaseDate, _ := time.Parse("2006-01-02", request.ReleaseDate)
 
-	log.Infof("[%s] %s: %s", context.ID, request.Method, request.Path)
-	log.Tracef("[%s] request: %s", context.ID, marshal.Quick(request))
-
-	injector, err := GetSafeInjector(object)
-	if err != nil {
-		log.Warnf("[%s] failed to get endpoint injector: %s", context.ID, err)
-		return &api.APIResponse{
-			StatusCode: http.StatusInternalServerError,
-		}
+	return models.TrackInfo{
+		ID:                id,
+		ArtistID:          request.ArtistID,
+		FeaturedArtistIDs: request.FeaturedArtistIDs,
+		Title:             request.Title,
+		Label:             request.Label,
+		ReleaseDate:       releaseDate,
+		TrackStats: models.TrackStats{
+			Streams: request.TrackStats.Streams,
+			Likes:   request.TrackStats.Likes,
+		},
+		AudioFeatures: models.AudioFeatures{
+			Key:              request.AudioFeatures.Key,
+			Tempo:            request.AudioFeatures.Tempo,
+			Duration:         request.AudioFeatures.Duration,
+			Energy:           request.AudioFeatures.Energy,
+			Danceability:     request.AudioFeatures.Danceability,
+			Accousticness:    request.AudioFeatures.Accousticness,
+			Instrumentalness: request.AudioFeatures.Instrumentalness,
+			Liveness:         request.AudioFeatures.Liveness,
+			Loudness:         request.AudioFeatures.Loudness,
+			TimeSignature:    request.AudioFeatures.TimeSignature,
+		},
+		Version:   1,
+		CreatedAt: now,
+		CreatedBy: principal,
+		UpdatedAt: now,
+		UpdatedBy: principal,
 	}
+}
+
+// Description:
+//
+//	The router handler for track creation.
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//
+// Returns:
+//
+//	An API response object, or an error which is answered with an error status.
+func Handler(request *api.APIRequest, injector *inject.Injector) (*api.APIResponse, error) {
+	context := parallel.FromContext(request.Context)
 
 	requestBody, err := ExtractRequestBody(request)
 	if err != nil {
@@ -320,7 +372,7 @@ func Handler(request *api.APIRequest, object interface{}) *api.APIResponse {
 			Body: CreateTrackErrorResponseBody{
 				Message: "invalid request body",
 			},
-		}
+		}, nil
 	}
 
 	validationError := ValidateRequestBody(requestBody)
@@ -329,75 +381,66 @@ func Handler(request *api.APIRequest, object interface{}) *api.APIResponse {
 		return &api.APIResponse{
 			StatusCode: http.StatusBadRequest,
 			Body:       validationError,
-		}
+		}, nil
 	}
 
-	trackStore := store.NewMongoStore[models.TrackInfo](injector.MongoInstance, "gostream", "tracks")
-	artistStore := store.NewMongoStore[models.ArtistInfo](injector.MongoInstance, "gostream", "artists")
+	trackStore := injector.TrackStore
+	artistStore := injector.ArtistStore
+	revisionStore := injector.RevisionStore
 
-	err = CheckIfArtistExists(artistStore, requestBody.ArtistID)
-	if err != nil {
+	track := CreateTrackFromRequestBody(injector.IDGenerator.NewID(), clock.Timestamp(injector.Clock), api.GetPrincipal(request), requestBody)
+
+	log.Tracef("[%s] attempting to create database item ...", context.ID)
+	err = injector.Transactor.WithTransaction(request.Context, func(tx stdcontext.Context) error {
+		err := CheckArtistReferences(tx, artistStore, track.ArtistID, track.FeaturedArtistIDs)
+		if err != nil {
+			return err
+		}
+
+		err = trackStore.CreateItem(tx, track)
+		if err != nil {
+			return err
+		}
+
+		return revisions.Record(tx, revisionStore, models.RevisionOperationCreate, nil, track)
+	})
+
+	if errors.Is(err, ErrArtistNotFound) {
 		log.Warnf("[%s] artist does not exist: %s", context.ID, err)
 		return &api.APIResponse{
 			StatusCode: http.StatusBadRequest,
 			Body: CreateTrackErrorResponseBody{
 				Message: "artist does not exist",
 			},
-		}
+		}, nil
 	}
 
-	for _, featuredArtist := range requestBody.FeaturedArtistIDs {
-		err = CheckIfArtistExists(artistStore, featuredArtist)
-		if err != nil {
-			log.Warnf("[%s] featured artist does not exist: %s", context.ID, err)
-			return &api.APIResponse{
-				StatusCode: http.StatusBadRequest,
-				Body: CreateTrackErrorResponseBody{
-					Message: "featured artist does not exist",
-				},
-			}
-		}
+	if errors.Is(err, ErrFeaturedArtistNotFound) {
+		log.Warnf("[%s] featured artist does not exist: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body: CreateTrackErrorResponseBody{
+				Message: "featured artist does not exist",
+			},
+		}, nil
 	}
 
-	releaseDate, _ := time.Parse("2006-01-02", requestBody.ReleaseDate)
-	track := models.TrackInfo{
-		ID:                uuid.New().String(),
-		ArtistID:          requestBody.ArtistID,
-		FeaturedArtistIDs: requestBody.FeaturedArtistIDs,
-		Title:             requestBody.Title,
-		Label:             requestBody.Label,
-		ReleaseDate:       releaseDate,
-		TrackStats: models.TrackStats{
-			Streams: requestBody.TrackStats.Streams,
-			Likes:   requestBody.TrackStats.Likes,
-		},
-		AudioFeatures: models.AudioFeatures{
-			Key:              requestBody.AudioFeatures.Key,
-			Tempo:            requestBody.AudioFeatures.Tempo,
-			Duration:         requestBody.AudioFeatures.Duration,
-			Energy:           requestBody.AudioFeatures.Energy,
-			Danceability:     requestBody.AudioFeatures.Danceability,
-			Accousticness:    requestBody.AudioFeatures.Accousticness,
-			Instrumentalness: requestBody.AudioFeatures.Instrumentalness,
-			Liveness:         requestBody.AudioFeatures.Liveness,
-			Loudness:         requestBody.AudioFeatures.Loudness,
-			TimeSignature:    requestBody.AudioFeatures.TimeSignature,
-		},
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database item: %w", err)
 	}
 
-	log.Tracef("[%s] attempting to create database item ...", context.ID)
-	err = trackStore.CreateItem(track)
-
+	etag, err := api.ComputeETag(track)
 	if err != nil {
-		log.Errorf("[%s] failed to create database item: %s", context.ID, err)
-		return &api.APIResponse{
-			StatusCode: http.StatusInternalServerError,
-		}
+		return nil, fmt.Errorf("failed to compute entity tag: %w", err)
 	}
 
 	log.Tracef("[%s] successfully completed request", context.ID)
 	return &api.APIResponse{
 		StatusCode: http.StatusOK,
-		Body:       track,
-	}
+		Headers: map[string]string{
+			api.ETagHeader:         etag,
+			api.LastModifiedHeader: track.UpdatedAt.Format(http.TimeFormat),
+		},
+		Body: track,
+	}, nil
 }
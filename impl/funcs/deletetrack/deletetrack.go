@@ -1,85 +1,187 @@
 package deletetrack
 
 import (
+	stdcontext "context"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/gostream-official/tracks/impl/funcs/gettracks"
+	"github.com/gostream-official/tracks/impl/funcs/updatetrack"
 	"github.com/gostream-official/tracks/impl/inject"
 	"github.com/gostream-official/tracks/impl/models"
+	"github.com/gostream-official/tracks/impl/revisions"
 	"github.com/gostream-official/tracks/pkg/api"
-	"github.com/gostream-official/tracks/pkg/marshal"
+	"github.com/gostream-official/tracks/pkg/clock"
 	"github.com/gostream-official/tracks/pkg/parallel"
-	"github.com/gostream-official/tracks/pkg/store"
+	"github.com/gostream-official/tracks/pkg/store/query"
 	"github.com/revx-official/output/log"
 )
 
+var (
+
+	// Returned if the track to delete does not satisfy the request precondition.
+	ErrPreconditionFailed = errors.New("deletetrack: precondition failed")
+)
+
 // Description:
 //
-//	Attempts to cast the input object to the endpoint injector.
-//	If this cast fails, we cannot proceed to process this request.
+//	The error response body for the delete track endpoint.
+type DeleteTrackErrorResponseBody struct {
+
+	// The error message.
+	Message string `json:"message"`
+}
+
+// Description:
+//
+//	Creates the update moving a track to the trash.
 //
 // Parameters:
 //
-//	object 	The injector object.
+//	now 		The deletion date.
+//	principal 	The deleting principal.
 //
 // Returns:
 //
-//	The injector if the cast is successful, an error otherwise.
-func GetSafeInjector(object interface{}) (*inject.Injector, error) {
-	injector, ok := object.(inject.Injector)
-
-	if !ok {
-		return nil, fmt.Errorf("deletetrack: failed to deduce injector")
+//	The created update.
+func CreateDeleteUpdate(now time.Time, principal string) query.Update {
+	update := query.Update{
+		Root: query.UpdateOperatorSet{
+			Set: map[string]interface{}{
+				"deletedAt": now,
+			},
+		},
 	}
 
-	return &injector, nil
+	return updatetrack.StampUpdate(update, now, principal)
 }
 
 // Description:
 //
-//	The router handler for: Get Track By ID
+//	Moves a track to the trash, if it satisfies the precondition, and records its revision.
+//	Deleted tracks are hidden from reads until they are restored or purged.
+//	The track is looked up, compared and deleted in its current version, within a single transaction.
 //
 // Parameters:
 //
-//	request The incoming request.
-//	object 	The injector. Contains injected dependencies.
+//	ctx 			The context of the operation.
+//	injector 		The injector, providing the stores and the transactor.
+//	id 				The id of the track to delete.
+//	update 			The update moving the track to the trash.
+//	precondition 	The precondition of the request, nil if the request is not conditional.
 //
 // Returns:
 //
-//	An API response object.
-func Handler(request *api.APIRequest, object interface{}) *api.APIResponse {
-	context := parallel.NewContext()
+//	The number of deleted tracks.
+//	ErrPreconditionFailed, if the request is conditional and no track was deleted,
+//	an error, if the database request failed.
+func DeleteTrack(ctx stdcontext.Context, injector *inject.Injector, id string, update query.Update, precondition *api.Precondition) (int64, error) {
+	trackStore := injector.TrackStore
+	count := int64(0)
+
+	err := injector.Transactor.WithTransaction(ctx, func(tx stdcontext.Context) error {
+		track, err := updatetrack.FindTrackByID(tx, trackStore, id)
+		if errors.Is(err, updatetrack.ErrTrackNotFound) && precondition == nil {
+			return nil
+		}
+
+		if errors.Is(err, updatetrack.ErrTrackNotFound) {
+			return ErrPreconditionFailed
+		}
+
+		if err != nil {
+			return err
+		}
 
-	log.Infof("[%s] %s: %s", context.ID, request.Method, request.Path)
-	log.Tracef("[%s] request: %s", context.ID, marshal.Quick(request))
+		etag, err := api.ComputeETag(track)
+		if err != nil {
+			return err
+		}
 
-	injector, err := GetSafeInjector(object)
+		if precondition != nil && !precondition.Matches(etag) {
+			return ErrPreconditionFailed
+		}
+
+		filter := query.Filter{
+			Root: gettracks.ExcludeDeleted(updatetrack.CreateVersionFilter(id, track.Version)),
+		}
+
+		count, err = trackStore.UpdateItem(tx, &filter, &update)
+		if err != nil {
+			return err
+		}
+
+		if count == 0 && precondition != nil {
+			return ErrPreconditionFailed
+		}
+
+		if count == 0 {
+			return nil
+		}
+
+		deletedTrack, err := updatetrack.FindTrack(tx, trackStore, id, true)
+		if err != nil {
+			return err
+		}
+
+		return revisions.Record(tx, injector.RevisionStore, models.RevisionOperationDelete, track, *deletedTrack)
+	})
+
+	return count, err
+}
+
+// Description:
+//
+//	The router handler for: Get Track By ID
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//
+// Returns:
+//
+//	An API response object, or an error which is answered with an error status.
+func Handler(request *api.APIRequest, injector *inject.Injector) (*api.APIResponse, error) {
+	context := parallel.FromContext(request.Context)
+
+	precondition, err := api.ParseIfMatch(request)
 	if err != nil {
-		log.Errorf("[%s] failed to get endpoint injector: %s", context.ID, err)
+		log.Warnf("[%s] failed to parse precondition: %s", context.ID, err)
 		return &api.APIResponse{
-			StatusCode: http.StatusInternalServerError,
-		}
+			StatusCode: http.StatusBadRequest,
+			Body: DeleteTrackErrorResponseBody{
+				Message: "invalid If-Match header",
+			},
+		}, nil
 	}
 
 	idToDelete := request.PathParameters["id"]
 
-	store := store.NewMongoStore[models.TrackInfo](injector.MongoInstance, "gostream", "tracks")
-	count, err := store.DeleteItem(idToDelete)
+	update := CreateDeleteUpdate(clock.Timestamp(injector.Clock), api.GetPrincipal(request))
 
-	if err != nil {
-		log.Errorf("[%s] failed to delete database items: %s", context.ID, err)
+	count, err := DeleteTrack(request.Context, injector, idToDelete, update, precondition)
+
+	if errors.Is(err, ErrPreconditionFailed) {
+		log.Warnf("[%s] track does not match the precondition: %s", context.ID, err)
 		return &api.APIResponse{
-			StatusCode: http.StatusInternalServerError,
-		}
+			StatusCode: http.StatusPreconditionFailed,
+		}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete database items: %w", err)
 	}
 
 	if count == 0 {
 		return &api.APIResponse{
 			StatusCode: http.StatusNoContent,
-		}
+		}, nil
 	}
 
 	return &api.APIResponse{
 		StatusCode: http.StatusAccepted,
-	}
+	}, nil
 }
@@ -0,0 +1,39 @@
+package getliveness
+
+import (
+	"net/http"
+
+	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/health"
+)
+
+// Description:
+//
+//	The liveness response body.
+type GetLivenessResponseBody struct {
+
+	// The status of the service process.
+	Status string `json:"status"`
+}
+
+// Description:
+//
+//	The router handler for: Get Liveness
+//	Reports the service process as alive, without checking any dependencies.
+//
+// Parameters:
+//
+//	request The incoming request.
+//	object 	The injector. Contains injected dependencies.
+//
+// Returns:
+//
+//	An API response object.
+func Handler(request *api.APIRequest, object interface{}) *api.APIResponse {
+	return &api.APIResponse{
+		StatusCode: http.StatusOK,
+		Body: GetLivenessResponseBody{
+			Status: health.StatusUp,
+		},
+	}
+}
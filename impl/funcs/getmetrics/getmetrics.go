@@ -0,0 +1,41 @@
+package getmetrics
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/gostream-official/tracks/impl/inject"
+	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/metrics"
+)
+
+// Description:
+//
+//	The router handler for: Get Metrics
+//	Reports the metrics of the service in the Prometheus text exposition format.
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//
+// Returns:
+//
+//	An API response object, or an error which is answered with an error status.
+func Handler(request *api.APIRequest, injector *inject.Injector) (*api.APIResponse, error) {
+	var body bytes.Buffer
+
+	err := injector.Metrics.WriteText(&body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write metrics: %w", err)
+	}
+
+	return &api.APIResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type": metrics.TextContentType,
+		},
+		RawBody: body.Bytes(),
+	}, nil
+}
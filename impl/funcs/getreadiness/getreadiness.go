@@ -0,0 +1,43 @@
+package getreadiness
+
+import (
+	"net/http"
+
+	"github.com/gostream-official/tracks/impl/inject"
+	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/health"
+	"github.com/gostream-official/tracks/pkg/parallel"
+	"github.com/revx-official/output/log"
+)
+
+// Description:
+//
+//	The router handler for: Get Readiness
+//	Checks all registered dependencies and reports whether the service can accept traffic.
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//
+// Returns:
+//
+//	An API response object, or an error which is answered with an error status.
+func Handler(request *api.APIRequest, injector *inject.Injector) (*api.APIResponse, error) {
+	context := parallel.FromContext(request.Context)
+
+	report := injector.Health.Check(request.Context)
+
+	if report.Status != health.StatusUp {
+		log.Warnf("[%s] service is not ready: %s", context.ID, report.Status)
+		return &api.APIResponse{
+			StatusCode: http.StatusServiceUnavailable,
+			Body:       report,
+		}, nil
+	}
+
+	return &api.APIResponse{
+		StatusCode: http.StatusOK,
+		Body:       report,
+	}, nil
+}
@@ -4,92 +4,86 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/gostream-official/tracks/impl/funcs/gettracks"
 	"github.com/gostream-official/tracks/impl/inject"
-	"github.com/gostream-official/tracks/impl/models"
 	"github.com/gostream-official/tracks/pkg/api"
-	"github.com/gostream-official/tracks/pkg/marshal"
 	"github.com/gostream-official/tracks/pkg/parallel"
-	"github.com/gostream-official/tracks/pkg/store"
 	"github.com/gostream-official/tracks/pkg/store/query"
 	"github.com/revx-official/output/log"
 )
 
-// Description:
-//
-//	Attempts to cast the input object to the endpoint injector.
-//	If this cast fails, we cannot proceed to process this request.
-//
-// Parameters:
-//
-//	object 	The injector object.
-//
-// Returns:
-//
-//	The injector if the cast is successful, an error otherwise.
-func GetSafeInjector(object interface{}) (*inject.Injector, error) {
-	injector, ok := object.(inject.Injector)
-
-	if !ok {
-		return nil, fmt.Errorf("gettrack: failed to deduce injector")
-	}
-
-	return &injector, nil
-}
-
 // Description:
 //
 //	The router handler for: Get Track By ID
 //
 // Parameters:
 //
-//	request The incoming request.
-//	object 	The injector. Contains injected dependencies.
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
 //
 // Returns:
 //
-//	An API response object.
-func Handler(request *api.APIRequest, object interface{}) *api.APIResponse {
-	context := parallel.NewContext()
+//	An API response object, or an error which is answered with an error status.
+func Handler(request *api.APIRequest, injector *inject.Injector) (*api.APIResponse, error) {
+	context := parallel.FromContext(request.Context)
 
-	log.Infof("[%s] %s: %s", context.ID, request.Method, request.Path)
-	log.Tracef("[%s] request: %s", context.ID, marshal.Quick(request))
+	fields, validationErr := gettracks.GetAndValidateFields(request)
+	if validationErr != nil {
+		log.Warnf("[%s] failed query parameter validation: %s", context.ID, validationErr.ErrorMessage)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body:       validationErr,
+		}, nil
+	}
 
-	injector, err := GetSafeInjector(object)
-	if err != nil {
-		log.Errorf("[%s] failed to get endpoint injector: %s", context.ID, err)
+	includeDeleted, validationErr := gettracks.GetAndValidateIncludeDeleted(request)
+	if validationErr != nil {
+		log.Warnf("[%s] failed query parameter validation: %s", context.ID, validationErr.ErrorMessage)
 		return &api.APIResponse{
-			StatusCode: http.StatusInternalServerError,
-		}
+			StatusCode: http.StatusBadRequest,
+			Body:       validationErr,
+		}, nil
 	}
 
-	store := store.NewMongoStore[models.TrackInfo](injector.MongoInstance, "gostream", "tracks")
+	store := injector.TrackStore
 
 	filter := query.Filter{
 		Root: query.FilterOperatorEq{
 			Key:   "_id",
 			Value: request.PathParameters["id"],
 		},
-		Limit: 10,
+		Limit:      1,
+		Projection: query.ExtendProjection(gettracks.ProjectionKeys(fields), "updatedAt"),
 	}
 
-	items, err := store.FindItems(&filter)
+	if !includeDeleted {
+		filter.Root = gettracks.ExcludeDeleted(filter.Root)
+	}
+
+	items, err := store.FindItems(request.Context, &filter)
 
 	if err != nil {
-		log.Errorf("[%s] failed to retrieve database items: %s", context.ID, err)
-		return &api.APIResponse{
-			StatusCode: http.StatusInternalServerError,
-		}
+		return nil, fmt.Errorf("failed to retrieve database items: %w", err)
 	}
 
 	if len(items) == 0 {
 		return &api.APIResponse{
 			StatusCode: http.StatusNotFound,
-		}
+		}, nil
 	}
 
-	resultItem := items[0]
-	return &api.APIResponse{
-		StatusCode: http.StatusOK,
-		Body:       resultItem,
+	resultItem, err := gettracks.ProjectItem(items[0], fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to project item: %w", err)
+	}
+
+	response, err := api.NewConditionalResponse(request, resultItem, items[0].UpdatedAt)
+	if err != nil {
+		log.Warnf("[%s] failed to evaluate conditional request: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+		}, nil
 	}
+
+	return response, nil
 }
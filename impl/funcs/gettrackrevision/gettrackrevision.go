@@ -0,0 +1,138 @@
+package gettrackrevision
+
+import (
+	stdcontext "context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gostream-official/tracks/impl/inject"
+	"github.com/gostream-official/tracks/impl/models"
+	"github.com/gostream-official/tracks/impl/revisions"
+	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/parallel"
+	"github.com/gostream-official/tracks/pkg/store"
+	"github.com/gostream-official/tracks/pkg/store/query"
+	"github.com/revx-official/output/log"
+)
+
+var (
+
+	// Returned if the requested revision does not exist.
+	ErrRevisionNotFound = errors.New("gettrackrevision: revision not found")
+)
+
+// Description:
+//
+//	Describes a path parameter validation error.
+type GetTrackRevisionPathValidationError struct {
+
+	// The path parameter which is referenced by the error message.
+	PathRef string `json:"pathRef"`
+
+	// The error message.
+	ErrorMessage string `json:"error"`
+}
+
+// Description:
+//
+//	Gets and validates the rev path parameter.
+//
+// Parameters:
+//
+//	request The http request.
+//
+// Returns:
+//
+//	The revision number.
+//	A validation error if the revision is not a positive integer.
+func GetAndValidateRevision(request *api.APIRequest) (int64, *GetTrackRevisionPathValidationError) {
+	revision, err := strconv.ParseInt(request.PathParameters["rev"], 10, 64)
+	if err != nil || revision < 1 {
+		return 0, &GetTrackRevisionPathValidationError{
+			PathRef:      ":rev",
+			ErrorMessage: "value must be a positive integer",
+		}
+	}
+
+	return revision, nil
+}
+
+// Description:
+//
+//	Searches a revision of a track in the database.
+//
+// Parameters:
+//
+//	ctx 		The context of the operation.
+//	store 		The revision store to search through.
+//	trackID 	The id of the track.
+//	revision 	The revision number.
+//
+// Returns:
+//
+//	The revision.
+//	ErrRevisionNotFound, if the revision does not exist, an error if the query fails.
+func FindRevision(ctx stdcontext.Context, store store.Store[models.TrackRevision], trackID string, revision int64) (*models.TrackRevision, error) {
+	filter := query.Filter{
+		Root: query.FilterOperatorEq{
+			Key:   "_id",
+			Value: revisions.CreateRevisionID(trackID, revision),
+		},
+		Limit: 1,
+	}
+
+	items, err := store.FindItems(ctx, &filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, ErrRevisionNotFound
+	}
+
+	return &items[0], nil
+}
+
+// Description:
+//
+//	The router handler for: Get Track Revision
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//
+// Returns:
+//
+//	An API response object, or an error which is answered with an error status.
+func Handler(request *api.APIRequest, injector *inject.Injector) (*api.APIResponse, error) {
+	context := parallel.FromContext(request.Context)
+
+	revisionNumber, validationErr := GetAndValidateRevision(request)
+	if validationErr != nil {
+		log.Warnf("[%s] failed path parameter validation: %s", context.ID, validationErr.ErrorMessage)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body:       validationErr,
+		}, nil
+	}
+
+	revision, err := FindRevision(request.Context, injector.RevisionStore, request.PathParameters["id"], revisionNumber)
+
+	if errors.Is(err, ErrRevisionNotFound) {
+		return &api.APIResponse{
+			StatusCode: http.StatusNotFound,
+		}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve database items: %w", err)
+	}
+
+	return &api.APIResponse{
+		StatusCode: http.StatusOK,
+		Body:       revision,
+	}, nil
+}
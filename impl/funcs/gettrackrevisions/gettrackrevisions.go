@@ -0,0 +1,130 @@
+package gettrackrevisions
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gostream-official/tracks/impl/funcs/gettracks"
+	"github.com/gostream-official/tracks/impl/funcs/updatetrack"
+	"github.com/gostream-official/tracks/impl/inject"
+	"github.com/gostream-official/tracks/impl/models"
+	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/parallel"
+	"github.com/gostream-official/tracks/pkg/store"
+	"github.com/gostream-official/tracks/pkg/store/query"
+	"github.com/revx-official/output/log"
+)
+
+// Description:
+//
+//	The response body for the get track revisions endpoint.
+type GetTrackRevisionsResponseBody struct {
+
+	// The revisions of the requested page, newest first.
+	Items []models.TrackRevision `json:"items"`
+
+	// The page metadata.
+	Page gettracks.GetTracksPageInfo `json:"page"`
+}
+
+// Description:
+//
+//	Creates the query filter for the revisions of a track.
+//
+// Parameters:
+//
+//	trackID The id of the track.
+//	limit 	The page size.
+//
+// Returns:
+//
+//	The query filter, sorting the revisions newest first.
+func CreateFilter(trackID string, limit uint32) query.Filter {
+	return query.Filter{
+		Root: query.FilterOperatorEq{
+			Key:   "trackId",
+			Value: trackID,
+		},
+		Limit: limit,
+		Sort: []query.SortKey{
+			{Key: "revision", Order: query.SortDescending},
+		},
+	}
+}
+
+// Description:
+//
+//	The router handler for: Get Track Revisions
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//
+// Returns:
+//
+//	An API response object, or an error which is answered with an error status.
+func Handler(request *api.APIRequest, injector *inject.Injector) (*api.APIResponse, error) {
+	context := parallel.FromContext(request.Context)
+
+	limit, validationErr := gettracks.GetAndValidateLimit(request)
+	if validationErr != nil {
+		log.Warnf("[%s] failed query parameter validation: %s", context.ID, validationErr.ErrorMessage)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body:       validationErr,
+		}, nil
+	}
+
+	trackID := request.PathParameters["id"]
+	filter := CreateFilter(trackID, limit)
+	cursor := request.QueryParameters["cursor"]
+
+	page, err := store.FindPage(request.Context, injector.RevisionStore, &filter, cursor)
+
+	if errors.Is(err, store.ErrInvalidCursor) {
+		log.Warnf("[%s] received invalid cursor: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body: gettracks.GetTracksQueryValidationError{
+				QueryRef:     "cursor",
+				ErrorMessage: "value is not a valid cursor for this query",
+			},
+		}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve database items: %w", err)
+	}
+
+	if len(page.Items) == 0 && cursor == "" {
+		_, err := updatetrack.FindTrack(request.Context, injector.TrackStore, trackID, true)
+
+		if errors.Is(err, updatetrack.ErrTrackNotFound) {
+			return &api.APIResponse{
+				StatusCode: http.StatusNotFound,
+			}, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve database items: %w", err)
+		}
+	}
+
+	return &api.APIResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Link": gettracks.CreateLinkHeader(request, page.NextCursor),
+		},
+		Body: GetTrackRevisionsResponseBody{
+			Items: page.Items,
+			Page: gettracks.GetTracksPageInfo{
+				Limit:      filter.Limit,
+				Count:      len(page.Items),
+				HasMore:    page.HasMore,
+				NextCursor: page.NextCursor,
+			},
+		},
+	}, nil
+}
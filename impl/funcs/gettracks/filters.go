@@ -0,0 +1,488 @@
+package gettracks
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gostream-official/tracks/impl/models"
+	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/store/query"
+)
+
+// Description:
+//
+//	The type of a filter parameter value.
+type FilterParameterType int
+
+const (
+
+	// A string value.
+	FilterParameterTypeString FilterParameterType = iota
+
+	// A date value, formatted as yyyy-MM-dd or RFC 3339.
+	FilterParameterTypeDate
+
+	// A floating point value.
+	FilterParameterTypeFloat
+
+	// A signed integer value.
+	FilterParameterTypeInt
+
+	// An unsigned 32 bit integer value.
+	FilterParameterTypeUint
+)
+
+// Description:
+//
+//	The comparison operator of a filter parameter.
+//	Given in brackets after the parameter name, e.g. 'tempo[gte]=120'.
+//	A parameter without brackets compares for equality.
+type FilterParameterOperator string
+
+const (
+
+	// Matches values equal to the given value.
+	FilterParameterOperatorEq FilterParameterOperator = "eq"
+
+	// Matches values not equal to the given value.
+	FilterParameterOperatorNe FilterParameterOperator = "ne"
+
+	// Matches values less than the given value.
+	FilterParameterOperatorLt FilterParameterOperator = "lt"
+
+	// Matches values less than or equal the given value.
+	FilterParameterOperatorLte FilterParameterOperator = "lte"
+
+	// Matches values greater than the given value.
+	FilterParameterOperatorGt FilterParameterOperator = "gt"
+
+	// Matches values greater than or equal the given value.
+	FilterParameterOperatorGte FilterParameterOperator = "gte"
+)
+
+// Description:
+//
+//	Declares a query parameter which filters tracks by a single field.
+type FilterParameter struct {
+
+	// The name of the query parameter.
+	Name string
+
+	// The document key the parameter filters by.
+	Key string
+
+	// The type of the parameter value.
+	Type FilterParameterType
+
+	// The operators supported by the parameter.
+	Operators []FilterParameterOperator
+}
+
+var (
+
+	// Operators for fields which can only be compared for equality.
+	equalityOperators = []FilterParameterOperator{
+		FilterParameterOperatorEq,
+		FilterParameterOperatorNe,
+	}
+
+	// Operators for fields which can be compared for equality and ordered.
+	rangeOperators = []FilterParameterOperator{
+		FilterParameterOperatorEq,
+		FilterParameterOperatorNe,
+		FilterParameterOperatorLt,
+		FilterParameterOperatorLte,
+		FilterParameterOperatorGt,
+		FilterParameterOperatorGte,
+	}
+)
+
+// Description:
+//
+//	All query parameters tracks can be filtered by.
+var FilterParameters = []FilterParameter{
+	{Name: "artist", Key: "artistId", Type: FilterParameterTypeString, Operators: equalityOperators},
+	{Name: "featuredArtist", Key: "featuredArtistIds", Type: FilterParameterTypeString, Operators: equalityOperators},
+	{Name: "title", Key: "title", Type: FilterParameterTypeString, Operators: equalityOperators},
+	{Name: "label", Key: "label", Type: FilterParameterTypeString, Operators: equalityOperators},
+	{Name: "releaseDate", Key: "releaseDate", Type: FilterParameterTypeDate, Operators: rangeOperators},
+	{Name: "streams", Key: "trackStats.streams", Type: FilterParameterTypeUint, Operators: rangeOperators},
+	{Name: "likes", Key: "trackStats.likes", Type: FilterParameterTypeUint, Operators: rangeOperators},
+	{Name: "key", Key: "audioFeatures.key", Type: FilterParameterTypeString, Operators: equalityOperators},
+	{Name: "tempo", Key: "audioFeatures.tempo", Type: FilterParameterTypeFloat, Operators: rangeOperators},
+	{Name: "duration", Key: "audioFeatures.duration", Type: FilterParameterTypeFloat, Operators: rangeOperators},
+	{Name: "energy", Key: "audioFeatures.energy", Type: FilterParameterTypeFloat, Operators: rangeOperators},
+	{Name: "danceability", Key: "audioFeatures.danceability", Type: FilterParameterTypeFloat, Operators: rangeOperators},
+	{Name: "accousticness", Key: "audioFeatures.accousticness", Type: FilterParameterTypeFloat, Operators: rangeOperators},
+	{Name: "instrumentalness", Key: "audioFeatures.instrumentalness", Type: FilterParameterTypeFloat, Operators: rangeOperators},
+	{Name: "liveness", Key: "audioFeatures.liveness", Type: FilterParameterTypeFloat, Operators: rangeOperators},
+	{Name: "loudness", Key: "audioFeatures.loudness", Type: FilterParameterTypeFloat, Operators: rangeOperators},
+	{Name: "timeSignature", Key: "audioFeatures.timeSignature", Type: FilterParameterTypeInt, Operators: rangeOperators},
+	{Name: "createdAt", Key: "createdAt", Type: FilterParameterTypeDate, Operators: rangeOperators},
+	{Name: "createdBy", Key: "createdBy", Type: FilterParameterTypeString, Operators: equalityOperators},
+	{Name: "updatedAt", Key: "updatedAt", Type: FilterParameterTypeDate, Operators: rangeOperators},
+	{Name: "updatedBy", Key: "updatedBy", Type: FilterParameterTypeString, Operators: equalityOperators},
+	{Name: "deletedAt", Key: "deletedAt", Type: FilterParameterTypeDate, Operators: rangeOperators},
+}
+
+// Description:
+//
+//	Query parameters which do not filter tracks.
+var reservedParameters = map[string]bool{
+	"limit":          true,
+	"cursor":         true,
+	"sort":           true,
+	"q":              true,
+	"fields":         true,
+	"includeDeleted": true,
+}
+
+// Description:
+//
+//	The schema of the textual query parameter 'q'.
+//	Allows all fields of the track data model.
+var QuerySchema = query.NewSchema(models.TrackInfo{})
+
+// Description:
+//
+//	Gets and validates the includeDeleted query parameter.
+//	Deleted tracks are hidden from reads, unless the parameter is 'true'.
+//
+// Parameters:
+//
+//	request The http request.
+//
+// Returns:
+//
+//	Whether deleted tracks are included.
+//	A validation error if the parameter is not a boolean.
+func GetAndValidateIncludeDeleted(request *api.APIRequest) (bool, *GetTracksQueryValidationError) {
+	value, ok := request.QueryParameters["includeDeleted"]
+	if !ok {
+		return false, nil
+	}
+
+	includeDeleted, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, &GetTracksQueryValidationError{
+			QueryRef:     "includeDeleted",
+			ErrorMessage: "value must be either true or false",
+		}
+	}
+
+	return includeDeleted, nil
+}
+
+// Description:
+//
+//	Restricts a filter to tracks which are not deleted.
+//
+// Parameters:
+//
+//	root The filter to restrict. Nil matches all tracks.
+//
+// Returns:
+//
+//	The restricted filter.
+func ExcludeDeleted(root query.IQuery) query.IQuery {
+	notDeleted := query.FilterOperatorExists{
+		Key:    "deletedAt",
+		Exists: false,
+	}
+
+	if root == nil {
+		return notDeleted
+	}
+
+	return query.FilterOperatorAnd{
+		And: []query.IQuery{root, notDeleted},
+	}
+}
+
+// Description:
+//
+//	Creates the field filters from the request's query parameters.
+//	Parameters are matched against the declared filter parameters,
+//	e.g. 'tempo[gte]=120&releaseDate[lt]=2020-01-01'.
+//	Repeated parameters are combined with AND, like repeated textual queries,
+//	e.g. 'featuredArtist=a&featuredArtist=b' matches tracks featuring both artists.
+//
+// Parameters:
+//
+//	request The http request.
+//
+// Returns:
+//
+//	The field filters, in the order of the query parameter names and values.
+//	A validation error if a parameter is unknown, uses an unsupported operator or has a malformed value.
+func CreateFieldFilters(request *api.APIRequest) ([]query.IQuery, *GetTracksQueryValidationError) {
+	names := make([]string, 0, len(request.QueryParameters))
+
+	for name := range request.QueryParameters {
+		if !reservedParameters[name] {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	filters := make([]query.IQuery, 0, len(names))
+
+	for _, name := range names {
+		values, ok := request.QueryParameterValues[name]
+		if !ok {
+			values = []string{request.QueryParameters[name]}
+		}
+
+		for _, value := range values {
+			filter, validationErr := createFieldFilter(name, value)
+			if validationErr != nil {
+				return nil, validationErr
+			}
+
+			filters = append(filters, filter)
+		}
+	}
+
+	return filters, nil
+}
+
+// Description:
+//
+//	Creates the field filter for a single query parameter.
+//
+// Parameters:
+//
+//	name 	The query parameter name, including the optional operator.
+//	value 	The query parameter value.
+//
+// Returns:
+//
+//	The field filter.
+//	A validation error if the parameter is invalid.
+func createFieldFilter(name string, value string) (query.IQuery, *GetTracksQueryValidationError) {
+	parameterName, operator, ok := splitParameterName(name)
+	if !ok {
+		return nil, &GetTracksQueryValidationError{
+			QueryRef:     name,
+			ErrorMessage: "malformed parameter name, expected: name or name[operator]",
+		}
+	}
+
+	parameter, ok := findFilterParameter(parameterName)
+	if !ok {
+		return nil, &GetTracksQueryValidationError{
+			QueryRef:     name,
+			ErrorMessage: "unknown query parameter",
+		}
+	}
+
+	if !parameter.supports(operator) {
+		return nil, &GetTracksQueryValidationError{
+			QueryRef:     name,
+			ErrorMessage: fmt.Sprintf("unsupported operator: %s", operator),
+		}
+	}
+
+	typedValue, err := parameter.parseValue(value)
+	if err != nil {
+		return nil, &GetTracksQueryValidationError{
+			QueryRef:     name,
+			ErrorMessage: err.Error(),
+		}
+	}
+
+	switch operator {
+	case FilterParameterOperatorNe:
+		return query.FilterOperatorNeq{Key: parameter.Key, Value: typedValue}, nil
+	case FilterParameterOperatorLt:
+		return query.FilterOperatorLt{Key: parameter.Key, Value: typedValue}, nil
+	case FilterParameterOperatorLte:
+		return query.FilterOperatorLte{Key: parameter.Key, Value: typedValue}, nil
+	case FilterParameterOperatorGt:
+		return query.FilterOperatorGt{Key: parameter.Key, Value: typedValue}, nil
+	case FilterParameterOperatorGte:
+		return query.FilterOperatorGte{Key: parameter.Key, Value: typedValue}, nil
+	}
+
+	return query.FilterOperatorEq{Key: parameter.Key, Value: typedValue}, nil
+}
+
+// Description:
+//
+//	Splits a query parameter name into the parameter name and the operator.
+//	E.g. 'tempo[gte]' is split into 'tempo' and 'gte'.
+//
+// Parameters:
+//
+//	name The query parameter name.
+//
+// Returns:
+//
+//	The parameter name, the operator and whether the name is well-formed.
+func splitParameterName(name string) (string, FilterParameterOperator, bool) {
+	open := strings.Index(name, "[")
+	if open < 0 {
+		return name, FilterParameterOperatorEq, !strings.Contains(name, "]")
+	}
+
+	if !strings.HasSuffix(name, "]") || open == 0 {
+		return "", "", false
+	}
+
+	operator := name[open+1 : len(name)-1]
+	if len(operator) == 0 || strings.ContainsAny(operator, "[]") {
+		return "", "", false
+	}
+
+	return name[:open], FilterParameterOperator(operator), true
+}
+
+// Description:
+//
+//	Finds the declared filter parameter with the given name.
+//
+// Parameters:
+//
+//	name The parameter name.
+//
+// Returns:
+//
+//	The filter parameter and whether it exists.
+func findFilterParameter(name string) (FilterParameter, bool) {
+	for _, parameter := range FilterParameters {
+		if parameter.Name == name {
+			return parameter, true
+		}
+	}
+
+	return FilterParameter{}, false
+}
+
+// Description:
+//
+//	Checks whether the filter parameter supports the given operator.
+//
+// Parameters:
+//
+//	operator The operator to check.
+//
+// Returns:
+//
+//	True, if the operator is supported.
+func (parameter FilterParameter) supports(operator FilterParameterOperator) bool {
+	for _, supported := range parameter.Operators {
+		if supported == operator {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Parses a raw query parameter value into the parameter's type.
+//
+// Parameters:
+//
+//	value The raw value.
+//
+// Returns:
+//
+//	The typed value, or an error describing the expected format.
+func (parameter FilterParameter) parseValue(value string) (interface{}, error) {
+	value = strings.TrimSpace(value)
+
+	switch parameter.Type {
+	case FilterParameterTypeDate:
+		date, err := time.Parse("2006-01-02", value)
+		if err == nil {
+			return date, nil
+		}
+
+		date, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("expected following format: yyyy-MM-dd or RFC 3339")
+		}
+
+		return date, nil
+
+	case FilterParameterTypeFloat:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, fmt.Errorf("value is not a valid number")
+		}
+
+		return number, nil
+
+	case FilterParameterTypeInt:
+		number, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("value is not a valid integer")
+		}
+
+		return number, nil
+
+	case FilterParameterTypeUint:
+		number, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("value is not a valid unsigned integer")
+		}
+
+		return int64(number), nil
+	}
+
+	return value, nil
+}
+
+// Description:
+//
+//	Creates the filter from the textual query parameter 'q',
+//	e.g. 'q=audioFeatures.tempo=ge=120;(label==Spinnin,label==Armada)'.
+//	Repeated textual queries are combined with AND, e.g. 'q=a==1&q=b==2'.
+//
+// Parameters:
+//
+//	request The http request.
+//
+// Returns:
+//
+//	The filter, nil if the request has no textual query.
+//	A validation error if the textual query is malformed or references unknown fields.
+func CreateTextFilter(request *api.APIRequest) (query.IQuery, *GetTracksQueryValidationError) {
+	texts, ok := request.QueryParameterValues["q"]
+	if !ok {
+		text, ok := request.QueryParameters["q"]
+		if !ok {
+			return nil, nil
+		}
+
+		texts = []string{text}
+	}
+
+	filters := make([]query.IQuery, 0, len(texts))
+
+	for _, text := range texts {
+		filter, err := query.Parse(text, QuerySchema)
+		if err != nil {
+			return nil, &GetTracksQueryValidationError{
+				QueryRef:     "q",
+				ErrorMessage: err.Error(),
+			}
+		}
+
+		filters = append(filters, filter)
+	}
+
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+
+	return query.FilterOperatorAnd{
+		And: filters,
+	}, nil
+}
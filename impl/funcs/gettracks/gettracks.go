@@ -1,9 +1,13 @@
 package gettracks
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gostream-official/tracks/impl/inject"
 	"github.com/gostream-official/tracks/impl/models"
@@ -15,102 +19,495 @@ import (
 	"github.com/revx-official/output/log"
 )
 
+const (
+
+	// The page size used if no limit is requested.
+	DefaultPageSize = 50
+
+	// The maximum page size which can be requested.
+	MaxPageSize = 500
+)
+
+// Description:
+//
+//	The fields tracks can be sorted by.
+//	Maps the JSON field path to the document key.
+var SortableFields = map[string]string{
+	"id":                             "_id",
+	"artistId":                       "artistId",
+	"title":                          "title",
+	"label":                          "label",
+	"releaseDate":                    "releaseDate",
+	"trackStats.streams":             "trackStats.streams",
+	"trackStats.likes":               "trackStats.likes",
+	"audioFeatures.key":              "audioFeatures.key",
+	"audioFeatures.tempo":            "audioFeatures.tempo",
+	"audioFeatures.duration":         "audioFeatures.duration",
+	"audioFeatures.energy":           "audioFeatures.energy",
+	"audioFeatures.danceability":     "audioFeatures.danceability",
+	"audioFeatures.accousticness":    "audioFeatures.accousticness",
+	"audioFeatures.instrumentalness": "audioFeatures.instrumentalness",
+	"audioFeatures.liveness":         "audioFeatures.liveness",
+	"audioFeatures.loudness":         "audioFeatures.loudness",
+	"audioFeatures.timeSignature":    "audioFeatures.timeSignature",
+	"createdAt":                      "createdAt",
+	"updatedAt":                      "updatedAt",
+}
+
+// Description:
+//
+//	The response body for the get tracks endpoint.
+type GetTracksResponseBody struct {
+
+	// The tracks of the requested page.
+	// Partial tracks if the request projects fields.
+	Items interface{} `json:"items"`
+
+	// The page metadata.
+	Page GetTracksPageInfo `json:"page"`
+}
+
 // Description:
 //
-//	Attempts to cast the input object to the endpoint injector.
-//	If this cast fails, we cannot proceed to process this request.
+//	The page metadata of the get tracks response.
+type GetTracksPageInfo struct {
+
+	// The requested page size.
+	Limit uint32 `json:"limit"`
+
+	// The number of items in this page.
+	Count int `json:"count"`
+
+	// Whether there are more items after this page.
+	HasMore bool `json:"hasMore"`
+
+	// The cursor for requesting the next page. Empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// Description:
+//
+//	Describes a query parameter validation error.
+type GetTracksQueryValidationError struct {
+
+	// The query parameter which is referenced by the error message.
+	QueryRef string `json:"queryRef"`
+
+	// The error message.
+	ErrorMessage string `json:"error"`
+}
+
+// Description:
+//
+//	The router handler for: Get Tracks
 //
 // Parameters:
 //
-//	object 	The injector object.
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
 //
 // Returns:
 //
-//	The injector if the cast is successful, an error otherwise.
-func GetSafeInjector(object interface{}) (*inject.Injector, error) {
-	injector, ok := object.(inject.Injector)
+//	An API response object, or an error which is answered with an error status.
+func Handler(request *api.APIRequest, injector *inject.Injector) (*api.APIResponse, error) {
+	context := parallel.FromContext(request.Context)
 
-	if !ok {
-		return nil, fmt.Errorf("gettracks: failed to deduce injector")
+	filter, validationErr := CreateFilterFromQueryParameters(request)
+	if validationErr != nil {
+		log.Warnf("[%s] failed query parameter validation: %s", context.ID, validationErr.ErrorMessage)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body:       validationErr,
+		}, nil
+	}
+
+	fields, validationErr := GetAndValidateFields(request)
+	if validationErr != nil {
+		log.Warnf("[%s] failed query parameter validation: %s", context.ID, validationErr.ErrorMessage)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body:       validationErr,
+		}, nil
+	}
+
+	filter.Projection = query.ExtendProjection(ProjectionKeys(fields), "updatedAt")
+
+	if filter.Root != nil {
+		log.Debugf("[%s] filter: %s", context.ID, filter.Root)
+	}
+
+	cursor := request.QueryParameters["cursor"]
+	page, err := store.FindPage(request.Context, injector.TrackStore, &filter, cursor)
+
+	if errors.Is(err, store.ErrInvalidCursor) {
+		log.Warnf("[%s] received invalid cursor: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body: GetTracksQueryValidationError{
+				QueryRef:     "cursor",
+				ErrorMessage: "value is not a valid cursor for this query",
+			},
+		}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve database items: %w", err)
+	}
+
+	items, err := ProjectItems(page.Items, fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to project items: %w", err)
+	}
+
+	lastModified := time.Time{}
+
+	for _, item := range page.Items {
+		if item.UpdatedAt.After(lastModified) {
+			lastModified = item.UpdatedAt
+		}
+	}
+
+	responseBody := GetTracksResponseBody{
+		Items: items,
+		Page: GetTracksPageInfo{
+			Limit:      filter.Limit,
+			Count:      len(page.Items),
+			HasMore:    page.HasMore,
+			NextCursor: page.NextCursor,
+		},
+	}
+
+	// The page is modified at the latest modification of its items. Tracks leaving the page, e.g. when
+	// deleted, do not advance it, so clients should prefer If-None-Match, which takes precedence.
+	response, err := api.NewConditionalResponse(request, responseBody, lastModified)
+	if err != nil {
+		log.Warnf("[%s] failed to evaluate conditional request: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+		}, nil
+	}
+
+	response.Headers["Link"] = CreateLinkHeader(request, page.NextCursor)
+	return response, nil
+}
+
+// Description:
+//
+//	Creates the query filter from the request's query parameters.
+//
+// Parameters:
+//
+//	request The http request.
+//
+// Returns:
+//
+//	The query filter.
+//	A validation error if a query parameter is invalid.
+func CreateFilterFromQueryParameters(request *api.APIRequest) (query.Filter, *GetTracksQueryValidationError) {
+	andFilter := query.FilterOperatorAnd{
+		And: make([]query.IQuery, 0),
+	}
+
+	limit, validationErr := GetAndValidateLimit(request)
+	if validationErr != nil {
+		return query.Filter{}, validationErr
+	}
+
+	sort, validationErr := GetAndValidateSort(request)
+	if validationErr != nil {
+		return query.Filter{}, validationErr
+	}
+
+	fieldFilters, validationErr := CreateFieldFilters(request)
+	if validationErr != nil {
+		return query.Filter{}, validationErr
+	}
+
+	andFilter.And = append(andFilter.And, fieldFilters...)
+
+	textFilter, validationErr := CreateTextFilter(request)
+	if validationErr != nil {
+		return query.Filter{}, validationErr
+	}
+
+	if textFilter != nil {
+		andFilter.And = append(andFilter.And, textFilter)
+	}
+
+	includeDeleted, validationErr := GetAndValidateIncludeDeleted(request)
+	if validationErr != nil {
+		return query.Filter{}, validationErr
+	}
+
+	if !includeDeleted {
+		andFilter.And = append(andFilter.And, ExcludeDeleted(nil))
+	}
+
+	resultFilter := query.Filter{
+		Limit: limit,
+		Sort:  sort,
+	}
+
+	if len(andFilter.And) > 0 {
+		resultFilter.Root = andFilter
+	}
+
+	return resultFilter, nil
+}
+
+// Description:
+//
+//	Gets and validates the limit query parameter.
+//	Falls back to the default page size if no limit is given.
+//
+// Parameters:
+//
+//	request The http request.
+//
+// Returns:
+//
+//	The page size.
+//	A validation error if the limit is not a number between 1 and the maximum page size.
+func GetAndValidateLimit(request *api.APIRequest) (uint32, *GetTracksQueryValidationError) {
+	limit, limitOk := request.QueryParameters["limit"]
+	if !limitOk {
+		return DefaultPageSize, nil
 	}
 
-	return &injector, nil
+	realLimit, err := strconv.Atoi(limit)
+	if err != nil || realLimit < 1 || realLimit > MaxPageSize {
+		return 0, &GetTracksQueryValidationError{
+			QueryRef:     "limit",
+			ErrorMessage: fmt.Sprintf("value must be a number between 1 and %d", MaxPageSize),
+		}
+	}
+
+	return uint32(realLimit), nil
 }
 
 // Description:
 //
-//	The router handler for: Get Track By ID
+//	Gets and validates the sort query parameter, e.g. 'sort=-trackStats.streams,title'.
+//	Keys prefixed with a minus sign are sorted in descending order.
 //
 // Parameters:
 //
-//	request The incoming request.
-//	object 	The injector. Contains injected dependencies.
+//	request The http request.
 //
 // Returns:
 //
-//	An API response object.
-func Handler(request *api.APIRequest, object interface{}) *api.APIResponse {
-	context := parallel.NewContext()
+//	The sort specification, empty if no sort order is requested.
+//	A validation error if the sort specification is malformed or contains a field which is not sortable.
+func GetAndValidateSort(request *api.APIRequest) ([]query.SortKey, *GetTracksQueryValidationError) {
+	sort, sortOk := request.QueryParameters["sort"]
+	if !sortOk {
+		return nil, nil
+	}
 
-	log.Infof("[%s] %s: %s", context.ID, request.Method, request.Path)
-	log.Tracef("[%s] request: %s", context.ID, marshal.Quick(request))
+	return ParseAndValidateSort(sort)
+}
 
-	injector, err := GetSafeInjector(object)
+// Description:
+//
+//	Parses and validates a textual sort specification against the sortable fields.
+//
+// Parameters:
+//
+//	sort The sort specification, e.g. '-trackStats.streams,title'.
+//
+// Returns:
+//
+//	The sort specification, mapped to document keys.
+//	A validation error if the sort specification is malformed or contains a field which is not sortable.
+func ParseAndValidateSort(sort string) ([]query.SortKey, *GetTracksQueryValidationError) {
+	keys, err := query.ParseSort(sort)
 	if err != nil {
-		log.Errorf("[%s] failed to get endpoint injector: %s", context.ID, err)
-		return &api.APIResponse{
-			StatusCode: http.StatusInternalServerError,
+		return nil, &GetTracksQueryValidationError{
+			QueryRef:     "sort",
+			ErrorMessage: "value is not a valid sort specification",
 		}
 	}
 
-	store := store.NewMongoStore[models.TrackInfo](injector.MongoInstance, "gostream", "tracks")
-	filter := CreateFilterFromQueryParameters(request)
+	for index, key := range keys {
+		documentKey, ok := SortableFields[key.Key]
+		if !ok {
+			return nil, &GetTracksQueryValidationError{
+				QueryRef:     "sort",
+				ErrorMessage: fmt.Sprintf("field is not sortable: %s", key.Key),
+			}
+		}
 
-	items, err := store.FindItems(&filter)
+		keys[index].Key = documentKey
+	}
 
+	return keys, nil
+}
+
+// Description:
+//
+//	Gets and validates the fields query parameter, e.g. 'fields=id,title,audioFeatures.tempo'.
+//
+// Parameters:
+//
+//	request The http request.
+//
+// Returns:
+//
+//	The projected fields, empty if all fields are requested.
+//	A validation error if a field is unknown or fields collide.
+func GetAndValidateFields(request *api.APIRequest) ([]query.SchemaField, *GetTracksQueryValidationError) {
+	fields, ok := request.QueryParameters["fields"]
+	if !ok {
+		return nil, nil
+	}
+
+	projection, err := query.ParseProjection(fields, QuerySchema)
 	if err != nil {
-		log.Errorf("[%s] failed to retrieve database items: %s", context.ID, err)
-		return &api.APIResponse{
-			StatusCode: http.StatusInternalServerError,
+		return nil, &GetTracksQueryValidationError{
+			QueryRef:     "fields",
+			ErrorMessage: err.Error(),
 		}
 	}
 
-	return &api.APIResponse{
-		StatusCode: http.StatusOK,
-		Body:       items,
+	return projection, nil
+}
+
+// Description:
+//
+//	Gets the document keys of projected fields.
+//
+// Parameters:
+//
+//	fields The projected fields.
+//
+// Returns:
+//
+//	The document keys, empty if all fields are requested.
+func ProjectionKeys(fields []query.SchemaField) []string {
+	keys := make([]string, len(fields))
+
+	for index, field := range fields {
+		keys[index] = field.Key
 	}
+
+	return keys
 }
 
-func CreateFilterFromQueryParameters(request *api.APIRequest) query.Filter {
-	andFilter := query.FilterOperatorAnd{
-		And: make([]query.IQuery, 0),
+// Description:
+//
+//	Reduces tracks to the projected fields.
+//
+// Parameters:
+//
+//	items 	The tracks.
+//	fields 	The projected fields. All fields are kept if empty.
+//
+// Returns:
+//
+//	The tracks, or partial tracks if fields are projected.
+//	An error if a track cannot be projected.
+func ProjectItems(items []models.TrackInfo, fields []query.SchemaField) (interface{}, error) {
+	if len(fields) == 0 {
+		return items, nil
 	}
 
-	var realLimit int
-	var realLimitErr error
+	result := make([]interface{}, len(items))
 
-	limit, limitOk := request.QueryParameters["limit"]
-	if limitOk {
-		realLimit, realLimitErr = strconv.Atoi(limit)
+	for index, item := range items {
+		projected, err := ProjectItem(item, fields)
+		if err != nil {
+			return nil, err
+		}
+
+		result[index] = projected
 	}
 
-	artist, artistOk := request.QueryParameters["artist"]
-	if artistOk {
-		andFilter.And = append(andFilter.And, query.FilterOperatorEq{
-			Key:   "artistId",
-			Value: artist,
-		})
+	return result, nil
+}
+
+// Description:
+//
+//	Reduces a track to the projected fields.
+//
+// Parameters:
+//
+//	item 	The track.
+//	fields 	The projected fields. All fields are kept if empty.
+//
+// Returns:
+//
+//	The track, or a partial track if fields are projected.
+//	An error if the track cannot be projected.
+func ProjectItem(item models.TrackInfo, fields []query.SchemaField) (interface{}, error) {
+	if len(fields) == 0 {
+		return item, nil
 	}
 
-	resultFilter := query.Filter{}
+	paths := make([]string, len(fields))
 
-	if limitOk && realLimitErr == nil {
-		resultFilter.Limit = uint32(realLimit)
+	for index, field := range fields {
+		paths[index] = field.Name
 	}
 
-	if len(andFilter.And) > 0 {
-		resultFilter.Root = andFilter
+	return marshal.Project(item, paths)
+}
+
+// Description:
+//
+//	Creates the RFC 8288 link header for a page.
+//	Always links the first page and links the next page if there is one.
+//
+// Parameters:
+//
+//	request 	The http request.
+//	nextCursor 	The cursor of the next page. Empty on the last page.
+//
+// Returns:
+//
+//	The link header value.
+func CreateLinkHeader(request *api.APIRequest, nextCursor string) string {
+	parameters := url.Values{}
+
+	for key, value := range request.QueryParameters {
+		if key != "cursor" {
+			parameters.Set(key, value)
+		}
+	}
+
+	for key, values := range request.QueryParameterValues {
+		if key != "cursor" {
+			parameters[key] = values
+		}
+	}
+
+	links := []string{
+		fmt.Sprintf("<%s>; rel=\"first\"", createPageURL(request.Path, parameters)),
+	}
+
+	if nextCursor != "" {
+		parameters.Set("cursor", nextCursor)
+		links = append(links, fmt.Sprintf("<%s>; rel=\"next\"", createPageURL(request.Path, parameters)))
+	}
+
+	return strings.Join(links, ", ")
+}
+
+// Description:
+//
+//	Creates the URL of a page.
+//
+// Parameters:
+//
+//	path 		The request path.
+//	parameters 	The query parameters of the page.
+//
+// Returns:
+//
+//	The page URL.
+func createPageURL(path string, parameters url.Values) string {
+	if len(parameters) == 0 {
+		return path
 	}
 
-	return resultFilter
+	return path + "?" + parameters.Encode()
 }
@@ -0,0 +1,270 @@
+package gettrackstats
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gostream-official/tracks/impl/funcs/gettracks"
+	"github.com/gostream-official/tracks/impl/inject"
+	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/parallel"
+	"github.com/gostream-official/tracks/pkg/store"
+	"github.com/gostream-official/tracks/pkg/store/query"
+	"github.com/revx-official/output/log"
+)
+
+// Description:
+//
+//	The expressions tracks can be grouped by.
+//	Maps the groupBy query parameter value to the group key expression.
+var GroupByExpressions = map[string]store.Expression{
+	"artistId":    store.FieldExpression{Key: "artistId"},
+	"label":       store.FieldExpression{Key: "label"},
+	"releaseYear": store.YearExpression{Key: "releaseDate"},
+	"key":         store.FieldExpression{Key: "audioFeatures.key"},
+}
+
+// Description:
+//
+//	The accumulators computed for every group.
+var GroupAccumulators = []store.Accumulator{
+	{Field: "count", Operator: store.AccumulatorCount},
+	{Field: "totalStreams", Operator: store.AccumulatorSum, Key: "trackStats.streams"},
+	{Field: "averageStreams", Operator: store.AccumulatorAvg, Key: "trackStats.streams"},
+	{Field: "totalLikes", Operator: store.AccumulatorSum, Key: "trackStats.likes"},
+	{Field: "averageTempo", Operator: store.AccumulatorAvg, Key: "audioFeatures.tempo"},
+	{Field: "minTempo", Operator: store.AccumulatorMin, Key: "audioFeatures.tempo"},
+	{Field: "maxTempo", Operator: store.AccumulatorMax, Key: "audioFeatures.tempo"},
+	{Field: "averageDuration", Operator: store.AccumulatorAvg, Key: "audioFeatures.duration"},
+	{Field: "firstReleaseDate", Operator: store.AccumulatorMin, Key: "releaseDate"},
+	{Field: "lastReleaseDate", Operator: store.AccumulatorMax, Key: "releaseDate"},
+}
+
+// Description:
+//
+//	The response body for the get track stats endpoint.
+type GetTrackStatsResponseBody struct {
+
+	// The expression the tracks are grouped by.
+	GroupBy string `json:"groupBy"`
+
+	// The number of tracks matching the filters.
+	Total int64 `json:"total"`
+
+	// The groups, ordered by descending track count.
+	Groups []GetTrackStatsGroup `json:"groups"`
+}
+
+// Description:
+//
+//	The statistics of a single group of tracks.
+type GetTrackStatsGroup struct {
+
+	// The group key, e.g. the label or the release year.
+	Key interface{} `json:"key" bson:"_id"`
+
+	// The number of tracks in the group.
+	Count int64 `json:"count" bson:"count"`
+
+	// The total stream count of the tracks.
+	TotalStreams int64 `json:"totalStreams" bson:"totalStreams"`
+
+	// The average stream count of the tracks.
+	AverageStreams float64 `json:"averageStreams" bson:"averageStreams"`
+
+	// The total amount of likes of the tracks.
+	TotalLikes int64 `json:"totalLikes" bson:"totalLikes"`
+
+	// The average tempo of the tracks.
+	AverageTempo float64 `json:"averageTempo" bson:"averageTempo"`
+
+	// The lowest tempo of the tracks.
+	MinTempo float64 `json:"minTempo" bson:"minTempo"`
+
+	// The highest tempo of the tracks.
+	MaxTempo float64 `json:"maxTempo" bson:"maxTempo"`
+
+	// The average duration of the tracks.
+	AverageDuration float64 `json:"averageDuration" bson:"averageDuration"`
+
+	// The release date of the earliest track.
+	FirstReleaseDate time.Time `json:"firstReleaseDate" bson:"firstReleaseDate"`
+
+	// The release date of the latest track.
+	LastReleaseDate time.Time `json:"lastReleaseDate" bson:"lastReleaseDate"`
+}
+
+// Description:
+//
+//	The router handler for: Get Track Stats
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//
+// Returns:
+//
+//	An API response object, or an error which is answered with an error status.
+func Handler(request *api.APIRequest, injector *inject.Injector) (*api.APIResponse, error) {
+	context := parallel.FromContext(request.Context)
+
+	groupBy, expression, validationErr := GetAndValidateGroupBy(request)
+	if validationErr != nil {
+		log.Warnf("[%s] failed query parameter validation: %s", context.ID, validationErr.ErrorMessage)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body:       validationErr,
+		}, nil
+	}
+
+	limit, validationErr := gettracks.GetAndValidateLimit(request)
+	if validationErr != nil {
+		log.Warnf("[%s] failed query parameter validation: %s", context.ID, validationErr.ErrorMessage)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body:       validationErr,
+		}, nil
+	}
+
+	filter, validationErr := CreateFilterFromQueryParameters(request)
+	if validationErr != nil {
+		log.Warnf("[%s] failed query parameter validation: %s", context.ID, validationErr.ErrorMessage)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body:       validationErr,
+		}, nil
+	}
+
+	trackStore := injector.TrackStore
+
+	total, err := trackStore.CountItems(request.Context, &query.Filter{Root: filter})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count database items: %w", err)
+	}
+
+	aggregation := store.NewAggregation().
+		Match(filter).
+		Group(expression, GroupAccumulators...).
+		Sort(
+			query.SortKey{Key: "count", Order: query.SortDescending},
+			query.SortKey{Key: "_id", Order: query.SortAscending},
+		).
+		Limit(int64(limit))
+
+	groups, err := store.AggregateInto[GetTrackStatsGroup](request.Context, trackStore, aggregation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate database items: %w", err)
+	}
+
+	return &api.APIResponse{
+		StatusCode: http.StatusOK,
+		Body: GetTrackStatsResponseBody{
+			GroupBy: groupBy,
+			Total:   total,
+			Groups:  groups,
+		},
+	}, nil
+}
+
+// Description:
+//
+//	Gets and validates the groupBy query parameter.
+//
+// Parameters:
+//
+//	request The http request.
+//
+// Returns:
+//
+//	The groupBy value and the group key expression.
+//	A validation error if the parameter is missing or not supported.
+func GetAndValidateGroupBy(request *api.APIRequest) (string, store.Expression, *gettracks.GetTracksQueryValidationError) {
+	groupBy := request.QueryParameters["groupBy"]
+
+	expression, ok := GroupByExpressions[groupBy]
+	if !ok {
+		supported := make([]string, 0, len(GroupByExpressions))
+		for name := range GroupByExpressions {
+			supported = append(supported, name)
+		}
+
+		sort.Strings(supported)
+
+		return "", nil, &gettracks.GetTracksQueryValidationError{
+			QueryRef:     "groupBy",
+			ErrorMessage: fmt.Sprintf("value must be one of: %s", strings.Join(supported, ", ")),
+		}
+	}
+
+	return groupBy, expression, nil
+}
+
+// Description:
+//
+//	Creates the filter from the request's query parameters.
+//	Supports the same field filters, textual query and deleted track handling as the get tracks endpoint.
+//
+// Parameters:
+//
+//	request The http request.
+//
+// Returns:
+//
+//	The filter, nil if all tracks are matched.
+//	A validation error if a query parameter is invalid.
+func CreateFilterFromQueryParameters(request *api.APIRequest) (query.IQuery, *gettracks.GetTracksQueryValidationError) {
+	filterRequest := *request
+	filterRequest.QueryParameters = make(map[string]string)
+
+	for name, value := range request.QueryParameters {
+		if name != "groupBy" && name != "limit" {
+			filterRequest.QueryParameters[name] = value
+		}
+	}
+
+	for _, name := range []string{"cursor", "sort", "fields"} {
+		_, ok := filterRequest.QueryParameters[name]
+		if ok {
+			return nil, &gettracks.GetTracksQueryValidationError{
+				QueryRef:     name,
+				ErrorMessage: "unknown query parameter",
+			}
+		}
+	}
+
+	filters, validationErr := gettracks.CreateFieldFilters(&filterRequest)
+	if validationErr != nil {
+		return nil, validationErr
+	}
+
+	textFilter, validationErr := gettracks.CreateTextFilter(&filterRequest)
+	if validationErr != nil {
+		return nil, validationErr
+	}
+
+	if textFilter != nil {
+		filters = append(filters, textFilter)
+	}
+
+	includeDeleted, validationErr := gettracks.GetAndValidateIncludeDeleted(&filterRequest)
+	if validationErr != nil {
+		return nil, validationErr
+	}
+
+	if !includeDeleted {
+		filters = append(filters, gettracks.ExcludeDeleted(nil))
+	}
+
+	switch len(filters) {
+	case 0:
+		return nil, nil
+	case 1:
+		return filters[0], nil
+	}
+
+	return query.FilterOperatorAnd{And: filters}, nil
+}
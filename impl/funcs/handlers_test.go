@@ -0,0 +1,643 @@
+package funcs_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gostream-official/tracks/impl/config"
+	"github.com/gostream-official/tracks/impl/funcs/batchtracks"
+	"github.com/gostream-official/tracks/impl/funcs/createtrack"
+	"github.com/gostream-official/tracks/impl/funcs/deletetrack"
+	"github.com/gostream-official/tracks/impl/funcs/gettrack"
+	"github.com/gostream-official/tracks/impl/funcs/gettracks"
+	"github.com/gostream-official/tracks/impl/funcs/gettrackstats"
+	"github.com/gostream-official/tracks/impl/funcs/patchtrack"
+	"github.com/gostream-official/tracks/impl/funcs/purgetracks"
+	"github.com/gostream-official/tracks/impl/funcs/restoretrack"
+	"github.com/gostream-official/tracks/impl/funcs/searchtracks"
+	"github.com/gostream-official/tracks/impl/funcs/updatetrack"
+	"github.com/gostream-official/tracks/impl/inject"
+	"github.com/gostream-official/tracks/impl/middleware"
+	"github.com/gostream-official/tracks/impl/models"
+	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/clock"
+	"github.com/gostream-official/tracks/pkg/parallel"
+	"github.com/gostream-official/tracks/pkg/patch"
+	"github.com/gostream-official/tracks/pkg/store"
+	"github.com/gostream-official/tracks/pkg/store/query"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+
+	// The id of the artist known to the test injector.
+	artistID = "11111111-1111-1111-1111-111111111111"
+
+	// The id of an artist unknown to the test injector.
+	unknownArtistID = "22222222-2222-2222-2222-222222222222"
+
+	// The id of a track unknown to the test injector.
+	unknownTrackID = "33333333-3333-3333-3333-333333333333"
+)
+
+func TestCreateTrack(t *testing.T) {
+	injector := newInjector(t)
+
+	response := call(t, injector, createtrack.Handler, api.APIRequest{
+		Body: encode(t, map[string]interface{}{"artistId": artistID, "title": "Strobe", "releaseDate": "2009-09-22"}),
+	})
+
+	expectStatus(t, response, http.StatusOK)
+
+	track := response.Body.(models.TrackInfo)
+	if track.Title != "Strobe" || track.Version != 1 || track.CreatedBy != api.AnonymousPrincipal {
+		t.Errorf("unexpected track: %+v", track)
+	}
+
+	if response.Headers[api.ETagHeader] == "" {
+		t.Error("expected an entity tag")
+	}
+
+	response = call(t, injector, createtrack.Handler, api.APIRequest{
+		Body: encode(t, map[string]interface{}{"artistId": unknownArtistID, "title": "Strobe", "releaseDate": "2009-09-22"}),
+	})
+
+	expectStatus(t, response, http.StatusBadRequest)
+}
+
+func TestBatchTracks(t *testing.T) {
+	valid := map[string]interface{}{"artistId": artistID, "title": "Strobe", "releaseDate": "2009-09-22"}
+	invalid := map[string]interface{}{"artistId": artistID, "title": "Strobe", "releaseDate": "22.09.2009"}
+	missingArtist := map[string]interface{}{"artistId": unknownArtistID, "title": "Strobe", "releaseDate": "2009-09-22"}
+	unknownField := map[string]interface{}{"artistId": artistID, "title": "Strobe", "releaseDate": "2009-09-22", "genre": "house"}
+
+	tests := []struct {
+		name     string
+		items    []interface{}
+		ordered  bool
+		inserted int64
+		statuses []int
+	}{
+		{
+			name:     "ordered skips items after a failed validation",
+			items:    []interface{}{valid, invalid, valid},
+			ordered:  true,
+			inserted: 1,
+			statuses: []int{http.StatusOK, http.StatusBadRequest, http.StatusFailedDependency},
+		},
+		{
+			name:     "ordered skips items after a missing artist",
+			items:    []interface{}{valid, missingArtist, valid, invalid},
+			ordered:  true,
+			inserted: 1,
+			statuses: []int{http.StatusOK, http.StatusUnprocessableEntity, http.StatusFailedDependency, http.StatusBadRequest},
+		},
+		{
+			name:     "unordered creates all valid items",
+			items:    []interface{}{valid, missingArtist, invalid, valid},
+			inserted: 2,
+			statuses: []int{http.StatusOK, http.StatusUnprocessableEntity, http.StatusBadRequest, http.StatusOK},
+		},
+		{
+			name:     "items are decoded like created tracks",
+			items:    []interface{}{unknownField, "Strobe"},
+			inserted: 1,
+			statuses: []int{http.StatusOK, http.StatusBadRequest},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			injector := newInjector(t)
+
+			response := call(t, injector, batchtracks.Handler, api.APIRequest{
+				Body: encode(t, map[string]interface{}{"items": test.items, "ordered": test.ordered}),
+			})
+
+			expectStatus(t, response, http.StatusOK)
+
+			body := response.Body.(batchtracks.BatchTracksResponseBody)
+			if body.InsertedCount != test.inserted {
+				t.Errorf("expected %d inserted tracks, received %d", test.inserted, body.InsertedCount)
+			}
+
+			for index, result := range body.Results {
+				if result.Index != index || result.Status != test.statuses[index] {
+					t.Errorf("expected item %d to have status %d, received: %+v", index, test.statuses[index], result)
+				}
+
+				if result.Status == http.StatusOK {
+					getTrack(t, injector, result.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchTracksRejectsInvalidBatches(t *testing.T) {
+	injector := newInjector(t)
+	item := map[string]interface{}{"artistId": artistID, "title": "Strobe", "releaseDate": "2009-09-22"}
+
+	tooMany := make([]interface{}, batchtracks.MaxBatchSize+1)
+	for index := range tooMany {
+		tooMany[index] = item
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{name: "empty batch", body: encode(t, map[string]interface{}{"items": []interface{}{}}), expected: http.StatusBadRequest},
+		{name: "too many items", body: encode(t, map[string]interface{}{"items": tooMany}), expected: http.StatusBadRequest},
+		{name: "unknown envelope field", body: encode(t, map[string]interface{}{"items": []interface{}{item}, "atomic": true}), expected: http.StatusBadRequest},
+		{name: "body too large", body: `{"items":[` + strings.Repeat(" ", batchtracks.MaxBodySize) + `]}`, expected: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := call(t, injector, batchtracks.Handler, api.APIRequest{Body: test.body})
+			expectStatus(t, response, test.expected)
+		})
+	}
+
+	tracks, err := injector.TrackStore.FindItems(context.Background(), &query.Filter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(tracks) != 0 {
+		t.Errorf("expected no tracks to be created, received %d", len(tracks))
+	}
+}
+
+func TestGetTrack(t *testing.T) {
+	injector := newInjector(t)
+	created, _ := createTrack(t, injector, "Strobe")
+
+	response := call(t, injector, gettrack.Handler, api.APIRequest{
+		PathParameters: map[string]string{"id": created.ID},
+	})
+
+	expectStatus(t, response, http.StatusOK)
+
+	response = call(t, injector, gettrack.Handler, api.APIRequest{
+		PathParameters: map[string]string{"id": unknownTrackID},
+	})
+
+	expectStatus(t, response, http.StatusNotFound)
+}
+
+func TestGetTracks(t *testing.T) {
+	injector := newInjector(t)
+	createTrack(t, injector, "Strobe")
+	createTrack(t, injector, "Ghosts 'n' Stuff")
+
+	response := call(t, injector, gettracks.Handler, api.APIRequest{
+		QueryParameters: map[string]string{"title": "Strobe"},
+	})
+
+	expectStatus(t, response, http.StatusOK)
+
+	if count := response.Body.(gettracks.GetTracksResponseBody).Page.Count; count != 1 {
+		t.Errorf("expected 1 track, received %d", count)
+	}
+
+	for expected, titles := range map[int][]string{0: {"Strobe", "Ghosts 'n' Stuff"}, 1: {"Strobe", "Strobe"}} {
+		response = call(t, injector, gettracks.Handler, api.APIRequest{
+			QueryParameters:      map[string]string{"title": titles[0]},
+			QueryParameterValues: map[string][]string{"title": titles},
+		})
+
+		expectStatus(t, response, http.StatusOK)
+
+		if count := response.Body.(gettracks.GetTracksResponseBody).Page.Count; count != expected {
+			t.Errorf("expected %d tracks for titles %v, received %d", expected, titles, count)
+		}
+	}
+
+	response = call(t, injector, gettracks.Handler, api.APIRequest{
+		QueryParameters:      map[string]string{"streams[gte]": "1"},
+		QueryParameterValues: map[string][]string{"streams[gte]": {"1", "x"}},
+	})
+
+	expectStatus(t, response, http.StatusBadRequest)
+
+	cursor, err := query.Cursor{Sort: "_id", Values: bson.A{bson.M{"$gt": ""}}}.Encode()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	response = call(t, injector, gettracks.Handler, api.APIRequest{
+		QueryParameters: map[string]string{"cursor": cursor},
+	})
+
+	expectStatus(t, response, http.StatusBadRequest)
+
+	response = call(t, injector, gettracks.Handler, api.APIRequest{})
+
+	expectStatus(t, response, http.StatusOK)
+
+	if count := response.Body.(gettracks.GetTracksResponseBody).Page.Count; count != 2 {
+		t.Errorf("expected 2 tracks, received %d", count)
+	}
+
+	lastModified := response.Headers[api.LastModifiedHeader]
+	if lastModified != "Tue, 02 Jan 2024 03:04:05 GMT" {
+		t.Errorf("expected the latest modification of the page, received '%s'", lastModified)
+	}
+
+	response = call(t, injector, gettracks.Handler, api.APIRequest{
+		Headers:         map[string]string{api.IfModifiedSinceHeader: lastModified},
+		QueryParameters: map[string]string{"fields": "title"},
+	})
+
+	expectStatus(t, response, http.StatusNotModified)
+
+	response = call(t, injector, gettracks.Handler, api.APIRequest{
+		Headers: map[string]string{api.IfModifiedSinceHeader: "Tue, 02 Jan 2024 03:04:04 GMT"},
+	})
+
+	expectStatus(t, response, http.StatusOK)
+}
+
+func TestGetTrackStats(t *testing.T) {
+	injector := newInjector(t)
+	createTrack(t, injector, "Strobe")
+	createTrack(t, injector, "Ghosts 'n' Stuff")
+
+	response := call(t, injector, gettrackstats.Handler, api.APIRequest{
+		QueryParameters: map[string]string{"groupBy": "releaseYear"},
+	})
+
+	expectStatus(t, response, http.StatusOK)
+
+	body := response.Body.(gettrackstats.GetTrackStatsResponseBody)
+	if body.Total != 2 || len(body.Groups) != 1 || body.Groups[0].Key != int32(2009) || body.Groups[0].Count != 2 {
+		t.Errorf("unexpected stats: %+v", body)
+	}
+
+	response = call(t, injector, gettrackstats.Handler, api.APIRequest{
+		QueryParameters: map[string]string{"groupBy": "tempo"},
+	})
+
+	expectStatus(t, response, http.StatusBadRequest)
+}
+
+func TestSearchTracksFilterLimits(t *testing.T) {
+	injector := newInjector(t)
+	createTrack(t, injector, "Strobe")
+
+	nested := func(depth int) string {
+		return strings.Repeat(`{"op":"not","args":[`, depth-1) + `{"op":"eq","key":"title","value":"Ghosts"}` + strings.Repeat(`]}`, depth-1)
+	}
+
+	wide := func(values int) string {
+		encoded := make([]string, values)
+		for index := range encoded {
+			encoded[index] = fmt.Sprintf(`"%d"`, index)
+		}
+
+		return `{"op":"in","key":"title","values":[` + strings.Join(encoded, ",") + `]}`
+	}
+
+	tests := []struct {
+		name     string
+		filter   string
+		expected int
+	}{
+		{name: "depth at the limit", filter: nested(searchtracks.MaxFilterDepth), expected: http.StatusOK},
+		{name: "depth over the limit", filter: nested(searchtracks.MaxFilterDepth + 1), expected: http.StatusBadRequest},
+		{name: "nodes at the limit", filter: wide(searchtracks.MaxFilterNodes - 1), expected: http.StatusOK},
+		{name: "nodes over the limit", filter: wide(searchtracks.MaxFilterNodes), expected: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := call(t, injector, searchtracks.Handler, api.APIRequest{
+				Body: `{"filter":` + test.filter + `}`,
+			})
+
+			expectStatus(t, response, test.expected)
+		})
+	}
+}
+
+func TestUpdateTrack(t *testing.T) {
+	injector := newInjector(t)
+	created, etag := createTrack(t, injector, "Strobe")
+
+	response := call(t, injector, updatetrack.Handler, api.APIRequest{
+		PathParameters: map[string]string{"id": created.ID},
+		Headers:        map[string]string{api.IfMatchHeader: etag},
+		Body:           encode(t, map[string]interface{}{"artistId": artistID, "title": "Strobe (Club Edit)", "releaseDate": "2009-09-22"}),
+	})
+
+	expectStatus(t, response, http.StatusNoContent)
+
+	if track := getTrack(t, injector, created.ID); track.Title != "Strobe (Club Edit)" || track.Version != 2 {
+		t.Errorf("unexpected track: %+v", track)
+	}
+
+	response = call(t, injector, updatetrack.Handler, api.APIRequest{
+		PathParameters: map[string]string{"id": created.ID},
+		Headers:        map[string]string{api.IfMatchHeader: etag},
+		Body:           encode(t, map[string]interface{}{"artistId": artistID, "title": "Strobe", "releaseDate": "2009-09-22"}),
+	})
+
+	expectStatus(t, response, http.StatusPreconditionFailed)
+}
+
+func TestPatchTrack(t *testing.T) {
+	injector := newInjector(t)
+	created, _ := createTrack(t, injector, "Strobe")
+
+	response := call(t, injector, patchtrack.Handler, api.APIRequest{
+		PathParameters: map[string]string{"id": created.ID},
+		Headers:        map[string]string{patchtrack.ContentTypeHeader: patch.MergePatchMediaType},
+		Body:           encode(t, map[string]interface{}{"label": "mau5trap"}),
+	})
+
+	expectStatus(t, response, http.StatusOK)
+
+	if track := getTrack(t, injector, created.ID); track.Title != "Strobe" || track.Label != "mau5trap" {
+		t.Errorf("unexpected track: %+v", track)
+	}
+
+	fetched := call(t, injector, gettrack.Handler, api.APIRequest{
+		PathParameters: map[string]string{"id": created.ID},
+	})
+
+	expectStatus(t, fetched, http.StatusOK)
+
+	representation := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(encode(t, fetched.Body)), &representation); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	response = call(t, injector, patchtrack.Handler, api.APIRequest{
+		PathParameters: map[string]string{"id": created.ID},
+		Headers:        map[string]string{patchtrack.ContentTypeHeader: patch.JSONPatchMediaType},
+		Body: encode(t, []map[string]interface{}{
+			{"op": "test", "path": "/version", "value": representation["version"]},
+			{"op": "test", "path": "/releaseDate", "value": representation["releaseDate"]},
+			{"op": "replace", "path": "/releaseDate", "value": "2010-01-01T00:00:00Z"},
+		}),
+	})
+
+	expectStatus(t, response, http.StatusOK)
+
+	if track := getTrack(t, injector, created.ID); !track.ReleaseDate.Equal(time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected release date: %s", track.ReleaseDate)
+	}
+
+	for _, body := range []map[string]interface{}{{"version": 9}, {"createdBy": "mallory"}, {"releaseDate": "2010-01-01"}, {"releaseDate": "2010-01-01T12:00:00Z"}} {
+		response = call(t, injector, patchtrack.Handler, api.APIRequest{
+			PathParameters: map[string]string{"id": created.ID},
+			Headers:        map[string]string{patchtrack.ContentTypeHeader: patch.MergePatchMediaType},
+			Body:           encode(t, body),
+		})
+
+		expectStatus(t, response, http.StatusUnprocessableEntity)
+	}
+
+	response = call(t, injector, patchtrack.Handler, api.APIRequest{
+		PathParameters: map[string]string{"id": created.ID},
+		Headers:        map[string]string{patchtrack.ContentTypeHeader: "application/json"},
+		Body:           encode(t, map[string]interface{}{"label": "mau5trap"}),
+	})
+
+	expectStatus(t, response, http.StatusUnsupportedMediaType)
+}
+
+func TestDeleteAndRestoreTrack(t *testing.T) {
+	injector := newInjector(t)
+	created, _ := createTrack(t, injector, "Strobe")
+	path := map[string]string{"id": created.ID}
+
+	response := call(t, injector, deletetrack.Handler, api.APIRequest{PathParameters: path})
+	expectStatus(t, response, http.StatusAccepted)
+
+	response = call(t, injector, deletetrack.Handler, api.APIRequest{PathParameters: path})
+	expectStatus(t, response, http.StatusNoContent)
+
+	response = call(t, injector, gettrack.Handler, api.APIRequest{PathParameters: path})
+	expectStatus(t, response, http.StatusNotFound)
+
+	response = call(t, injector, restoretrack.Handler, api.APIRequest{PathParameters: path})
+	expectStatus(t, response, http.StatusOK)
+
+	response = call(t, injector, restoretrack.Handler, api.APIRequest{PathParameters: path})
+	expectStatus(t, response, http.StatusConflict)
+
+	response = call(t, injector, gettrack.Handler, api.APIRequest{PathParameters: path})
+	expectStatus(t, response, http.StatusOK)
+}
+
+func TestPurgeTracks(t *testing.T) {
+	injector := newInjector(t)
+	injector.TrashRetention = 0
+	injector.AdminPrincipals = []string{"admin"}
+
+	created, _ := createTrack(t, injector, "Strobe")
+	createTrack(t, injector, "Ghosts 'n' Stuff")
+
+	response := call(t, injector, deletetrack.Handler, api.APIRequest{PathParameters: map[string]string{"id": created.ID}})
+	expectStatus(t, response, http.StatusAccepted)
+
+	for _, headers := range []map[string]string{{}, {api.PrincipalHeader: "alice"}} {
+		response = call(t, injector, purgetracks.Handler, api.APIRequest{Headers: headers})
+		expectStatus(t, response, http.StatusForbidden)
+	}
+
+	response = call(t, injector, purgetracks.Handler, api.APIRequest{
+		Headers: map[string]string{api.PrincipalHeader: "admin"},
+	})
+
+	expectStatus(t, response, http.StatusOK)
+
+	if count := response.Body.(purgetracks.PurgeTracksResponseBody).DeletedCount; count != 1 {
+		t.Errorf("expected 1 purged track, received %d", count)
+	}
+
+	tracks, err := injector.TrackStore.FindItems(context.Background(), &query.Filter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(tracks) != 1 || tracks[0].ID == created.ID {
+		t.Errorf("expected only the deleted track to be purged, remaining: %+v", tracks)
+	}
+
+	trackRevisions, err := injector.RevisionStore.FindItems(context.Background(), &query.Filter{
+		Root: query.FilterOperatorEq{Key: "trackId", Value: created.ID},
+		Sort: []query.SortKey{{Key: "revision", Order: query.SortAscending}},
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	last := trackRevisions[len(trackRevisions)-1]
+	if last.Operation != models.RevisionOperationPurge || last.CreatedBy != "admin" || last.Revision != 3 {
+		t.Errorf("unexpected purge revision: %+v", last)
+	}
+}
+
+// Description:
+//
+//	Creates an injector backed by the in-memory store, with a fixed clock and a single artist.
+//
+// Parameters:
+//
+//	t The test.
+//
+// Returns:
+//
+//	The created injector.
+func newInjector(t *testing.T) *inject.Injector {
+	injector := inject.NewMemoryInjector(store.NewMemoryInstance(), config.MongoConfig{
+		Database:            "test",
+		TracksCollection:    "tracks",
+		ArtistsCollection:   "artists",
+		RevisionsCollection: "revisions",
+	})
+
+	injector.Clock = clock.NewFixedClock(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	err := injector.ArtistStore.CreateItem(context.Background(), models.ArtistInfo{ID: artistID})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	return injector
+}
+
+// Description:
+//
+//	Calls a handler through the principal middleware and the handler adapter, like the router does.
+//
+// Parameters:
+//
+//	t 			The test.
+//	injector 	The injector.
+//	handler 	The handler to call.
+//	request 	The request, without a context.
+//
+// Returns:
+//
+//	The response of the handler.
+func call(t *testing.T, injector *inject.Injector, handler middleware.HandlerFunc, request api.APIRequest) *api.APIResponse {
+	request.Context = parallel.WithContext(context.Background(), parallel.NewContext())
+
+	if request.Headers == nil {
+		request.Headers = make(map[string]string)
+	}
+
+	if request.QueryParameters == nil {
+		request.QueryParameters = make(map[string]string)
+	}
+
+	response := middleware.Principal(middleware.Handle(handler))(&request, injector)
+	if response == nil {
+		t.Fatal("expected a response")
+	}
+
+	return response
+}
+
+// Description:
+//
+//	Creates a track through the create handler.
+//
+// Parameters:
+//
+//	t 			The test.
+//	injector 	The injector.
+//	title 		The title of the track.
+//
+// Returns:
+//
+//	The created track and its entity tag.
+func createTrack(t *testing.T, injector *inject.Injector, title string) (models.TrackInfo, string) {
+	response := call(t, injector, createtrack.Handler, api.APIRequest{
+		Body: encode(t, map[string]interface{}{"artistId": artistID, "title": title, "releaseDate": "2009-09-22"}),
+	})
+
+	expectStatus(t, response, http.StatusOK)
+
+	return response.Body.(models.TrackInfo), response.Headers[api.ETagHeader]
+}
+
+// Description:
+//
+//	Reads a track from the track store, failing the test if it does not exist.
+//
+// Parameters:
+//
+//	t 			The test.
+//	injector 	The injector.
+//	id 			The id of the track.
+//
+// Returns:
+//
+//	The stored track.
+func getTrack(t *testing.T, injector *inject.Injector, id string) models.TrackInfo {
+	tracks, err := injector.TrackStore.FindItems(context.Background(), &query.Filter{
+		Root:  query.FilterOperatorEq{Key: "_id", Value: id},
+		Limit: 1,
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(tracks) == 0 {
+		t.Fatalf("expected track '%s' to exist", id)
+	}
+
+	return tracks[0]
+}
+
+// Description:
+//
+//	Fails the test if the response does not have the expected status code.
+//
+// Parameters:
+//
+//	t 			The test.
+//	response 	The response to check.
+//	expected 	The expected status code.
+func expectStatus(t *testing.T, response *api.APIResponse, expected int) {
+	t.Helper()
+
+	if response.StatusCode != expected {
+		t.Fatalf("expected status %d, received %d: %+v", expected, response.StatusCode, response.Body)
+	}
+}
+
+// Description:
+//
+//	Encodes a request body as JSON, failing the test if encoding fails.
+//
+// Parameters:
+//
+//	t 		The test.
+//	body 	The body to encode.
+//
+// Returns:
+//
+//	The JSON encoded body.
+func encode(t *testing.T, body interface{}) string {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	return string(encoded)
+}
@@ -0,0 +1,523 @@
+package patchtrack
+
+import (
+	"bytes"
+	stdcontext "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gostream-official/tracks/impl/funcs/updatetrack"
+	"github.com/gostream-official/tracks/impl/inject"
+	"github.com/gostream-official/tracks/impl/models"
+	"github.com/gostream-official/tracks/impl/revisions"
+	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/clock"
+	"github.com/gostream-official/tracks/pkg/parallel"
+	"github.com/gostream-official/tracks/pkg/patch"
+	"github.com/gostream-official/tracks/pkg/store/query"
+	"github.com/revx-official/output/log"
+)
+
+var (
+
+	// Returned if the content type of the request is not a supported patch format.
+	ErrUnsupportedMediaType = errors.New("patchtrack: unsupported media type")
+
+	// Returned if the patched document is not a track.
+	ErrInvalidDocument = errors.New("patchtrack: patched document is not a track")
+
+	// Returned if the patch changes a field maintained by the service.
+	ErrReadOnlyField = errors.New("patchtrack: patch changes a read-only field")
+
+	// Returned if the patched track fails validation.
+	ErrValidationFailed = errors.New("patchtrack: patched track failed validation")
+
+	// Returned if the track was modified while the patch was applied.
+	ErrConcurrentModification = errors.New("patchtrack: track was modified concurrently")
+)
+
+const (
+
+	// The response header listing the supported patch formats.
+	AcceptPatchHeader = "Accept-Patch"
+
+	// The request header carrying the patch format.
+	ContentTypeHeader = "Content-Type"
+)
+
+// Description:
+//
+//	The error response body for the patch track endpoint.
+type PatchTrackErrorResponseBody struct {
+
+	// The error message.
+	Message string `json:"message"`
+}
+
+// Description:
+//
+//	Applies a patch to the document of a track.
+//	The document is the JSON encoding of the track, as returned by the get track endpoint.
+type Patch func(document interface{}) (interface{}, error)
+
+// Description:
+//
+//	Parses the request body into a patch, according to the content type of the request.
+//	Supports JSON merge patches (RFC 7396) and JSON patches (RFC 6902).
+//
+// Parameters:
+//
+//	request The http request.
+//
+// Returns:
+//
+//	The patch.
+//	ErrUnsupportedMediaType, if the content type is not supported,
+//	patch.ErrInvalidPatch, if the request body is malformed.
+func ParsePatch(request *api.APIRequest) (Patch, error) {
+	mediaType, _, err := mime.ParseMediaType(request.Headers[ContentTypeHeader])
+	if err != nil {
+		return nil, ErrUnsupportedMediaType
+	}
+
+	switch mediaType {
+	case patch.MergePatchMediaType:
+		mergePatch, err := patch.ParseMergePatch([]byte(request.Body))
+		if err != nil {
+			return nil, err
+		}
+
+		return func(document interface{}) (interface{}, error) {
+			return patch.ApplyMergePatch(document, mergePatch), nil
+		}, nil
+	case patch.JSONPatchMediaType:
+		operations, err := patch.ParseJSONPatch([]byte(request.Body))
+		if err != nil {
+			return nil, err
+		}
+
+		return func(document interface{}) (interface{}, error) {
+			return patch.ApplyJSONPatch(document, operations)
+		}, nil
+	}
+
+	return nil, ErrUnsupportedMediaType
+}
+
+// Description:
+//
+//	Applies a patch to a track.
+//	The patch is applied to the representation returned by the get track endpoint,
+//	so test operations may compare any field of it, and the release date is a RFC 3339 timestamp.
+//	Fields set to null or removed by the patch are cleared. The fields maintained by the service
+//	(id, version, creation, modification and deletion) must be left unchanged.
+//
+// Parameters:
+//
+//	track The track to patch.
+//	apply The patch to apply.
+//
+// Returns:
+//
+//	The request body replacing the track with the patched track, to be validated like a replacement.
+//	The error of the patch, ErrInvalidDocument, if the patched document is not a track,
+//	or ErrReadOnlyField, if the patch changes a field maintained by the service.
+func ApplyPatch(track *models.TrackInfo, apply Patch) (*updatetrack.UpdateTrackRequestBody, error) {
+	encoded, err := json.Marshal(track)
+	if err != nil {
+		return nil, err
+	}
+
+	var document interface{}
+
+	err = json.Unmarshal(encoded, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	patched, err := apply(document)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err = json.Marshal(patched)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.DisallowUnknownFields()
+
+	patchedTrack := models.TrackInfo{}
+
+	err = decoder.Decode(&patchedTrack)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDocument, err)
+	}
+
+	if !HasSameReadOnlyFields(track, &patchedTrack) {
+		return nil, ErrReadOnlyField
+	}
+
+	releaseDate := patchedTrack.ReleaseDate.UTC()
+	if releaseDate != releaseDate.Truncate(24*time.Hour) {
+		return nil, fmt.Errorf("%w: release date must not have a time of day", ErrInvalidDocument)
+	}
+
+	body := updatetrack.CreateRequestBodyFromTrack(&patchedTrack)
+	if patchedTrack.ReleaseDate.IsZero() {
+		body.ReleaseDate = ""
+	}
+
+	return body, nil
+}
+
+// Description:
+//
+//	Checks whether a patched track leaves the fields maintained by the service unchanged.
+//
+// Parameters:
+//
+//	track 			The track before the patch.
+//	patchedTrack 	The patched track.
+//
+// Returns:
+//
+//	True, if the id, the version, the creation, modification and deletion are unchanged.
+func HasSameReadOnlyFields(track *models.TrackInfo, patchedTrack *models.TrackInfo) bool {
+	if track.ID != patchedTrack.ID || track.Version != patchedTrack.Version {
+		return false
+	}
+
+	if !track.CreatedAt.Equal(patchedTrack.CreatedAt) || track.CreatedBy != patchedTrack.CreatedBy {
+		return false
+	}
+
+	if !track.UpdatedAt.Equal(patchedTrack.UpdatedAt) || track.UpdatedBy != patchedTrack.UpdatedBy {
+		return false
+	}
+
+	if track.DeletedAt == nil || patchedTrack.DeletedAt == nil {
+		return track.DeletedAt == nil && patchedTrack.DeletedAt == nil
+	}
+
+	return track.DeletedAt.Equal(*patchedTrack.DeletedAt)
+}
+
+// Description:
+//
+//	Collects the artists referenced by a patched track, which were not referenced before.
+//
+// Parameters:
+//
+//	track 	The track before the patch.
+//	body 	The patched track.
+//
+// Returns:
+//
+//	The new artist, empty if the artist is unchanged, and the new featured artists.
+func CollectNewArtists(track *models.TrackInfo, body *updatetrack.UpdateTrackRequestBody) (string, []string) {
+	artistID := strings.TrimSpace(body.ArtistID)
+	if artistID == track.ArtistID {
+		artistID = ""
+	}
+
+	existing := make(map[string]bool)
+	for _, artist := range track.FeaturedArtistIDs {
+		existing[artist] = true
+	}
+
+	featuredArtistIDs := make([]string, 0)
+	for _, artist := range body.FeaturedArtistIDs {
+		artist = strings.TrimSpace(artist)
+		if !existing[artist] {
+			featuredArtistIDs = append(featuredArtistIDs, artist)
+		}
+	}
+
+	return artistID, featuredArtistIDs
+}
+
+// Description:
+//
+//	Patches a track, if it satisfies the precondition, and records its revision.
+//	The track is looked up, patched and updated within a single transaction.
+//	Only newly referenced artists are verified. A patch without effect leaves the track untouched.
+//
+// Parameters:
+//
+//	ctx 			The context of the operation.
+//	injector 		The injector, providing the stores and the transactor.
+//	id 				The id of the track to patch.
+//	apply 			The patch to apply.
+//	now 			The modification date.
+//	principal 		The modifying principal.
+//	precondition 	The precondition of the request, nil if the request is not conditional.
+//
+// Returns:
+//
+//	The patched track.
+//	The validation error of the patched track, if the error is ErrValidationFailed.
+//	updatetrack.ErrTrackNotFound, updatetrack.ErrPreconditionFailed, updatetrack.ErrArtistNotFound,
+//	updatetrack.ErrFeaturedArtistNotFound, ErrConcurrentModification, ErrInvalidDocument, ErrReadOnlyField, ErrValidationFailed
+//	or an error of the patch, if the patch cannot be applied, an error, if the database request failed.
+func PatchTrack(ctx stdcontext.Context, injector *inject.Injector, id string, apply Patch, now time.Time, principal string, precondition *api.Precondition) (*models.TrackInfo, *updatetrack.UpdateTrackValidationError, error) {
+	trackStore := injector.TrackStore
+
+	var patchedTrack *models.TrackInfo
+	var validationError *updatetrack.UpdateTrackValidationError
+
+	err := injector.Transactor.WithTransaction(ctx, func(tx stdcontext.Context) error {
+		track, err := updatetrack.FindTrackByID(tx, trackStore, id)
+		if err != nil {
+			return err
+		}
+
+		etag, err := api.ComputeETag(track)
+		if err != nil {
+			return err
+		}
+
+		if precondition != nil && !precondition.Matches(etag) {
+			return updatetrack.ErrPreconditionFailed
+		}
+
+		body, err := ApplyPatch(track, apply)
+		if err != nil {
+			return err
+		}
+
+		validationError = updatetrack.ValidateRequestBody(body)
+		if validationError != nil {
+			return ErrValidationFailed
+		}
+
+		patchedTrack = track
+		if reflect.DeepEqual(body, updatetrack.CreateRequestBodyFromTrack(track)) {
+			return nil
+		}
+
+		artistID, featuredArtistIDs := CollectNewArtists(track, body)
+
+		err = updatetrack.CheckArtistReferences(tx, injector.ArtistStore, artistID, featuredArtistIDs)
+		if err != nil {
+			return err
+		}
+
+		update := updatetrack.StampUpdate(updatetrack.CreateUpdateFromRequestBody(body), now, principal)
+		updateFilter := query.Filter{
+			Root: updatetrack.CreateVersionFilter(id, track.Version),
+		}
+
+		count, err := trackStore.UpdateItem(tx, &updateFilter, &update)
+		if err != nil {
+			return err
+		}
+
+		if count == 0 && precondition != nil {
+			return updatetrack.ErrPreconditionFailed
+		}
+
+		if count == 0 {
+			return ErrConcurrentModification
+		}
+
+		patchedTrack, err = updatetrack.FindTrackByID(tx, trackStore, id)
+		if err != nil {
+			return err
+		}
+
+		return revisions.Record(tx, injector.RevisionStore, models.RevisionOperationUpdate, track, *patchedTrack)
+	})
+
+	return patchedTrack, validationError, err
+}
+
+// Description:
+//
+//	The router handler for: Patch Track
+//	Patches apply to the track as returned by Get Track, see ApplyPatch.
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//
+// Returns:
+//
+//	An API response object, or an error which is answered with an error status.
+func Handler(request *api.APIRequest, injector *inject.Injector) (*api.APIResponse, error) {
+	context := parallel.FromContext(request.Context)
+
+	id, validationErr := updatetrack.GetAndValidateID(request)
+	if validationErr != nil {
+		log.Warnf("[%s] failed path parameter validation: %s", context.ID, validationErr.ErrorMessage)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body:       validationErr,
+		}, nil
+	}
+
+	precondition, err := api.ParseIfMatch(request)
+	if err != nil {
+		log.Warnf("[%s] failed to parse precondition: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body: PatchTrackErrorResponseBody{
+				Message: "invalid If-Match header",
+			},
+		}, nil
+	}
+
+	apply, err := ParsePatch(request)
+
+	if errors.Is(err, ErrUnsupportedMediaType) {
+		log.Warnf("[%s] received unsupported media type: %s", context.ID, request.Headers[ContentTypeHeader])
+		return &api.APIResponse{
+			StatusCode: http.StatusUnsupportedMediaType,
+			Headers: map[string]string{
+				AcceptPatchHeader: patch.MergePatchMediaType + ", " + patch.JSONPatchMediaType,
+			},
+		}, nil
+	}
+
+	if err != nil {
+		log.Warnf("[%s] failed to parse patch: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body: PatchTrackErrorResponseBody{
+				Message: "invalid patch document",
+			},
+		}, nil
+	}
+
+	log.Tracef("[%s] attempting to patch database item ...", context.ID)
+	track, validationError, err := PatchTrack(request.Context, injector, id, apply, clock.Timestamp(injector.Clock), api.GetPrincipal(request), precondition)
+
+	if errors.Is(err, updatetrack.ErrTrackNotFound) {
+		log.Warnf("[%s] could not find track: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusNotFound,
+		}, nil
+	}
+
+	if errors.Is(err, updatetrack.ErrPreconditionFailed) {
+		log.Warnf("[%s] track does not match the precondition: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusPreconditionFailed,
+		}, nil
+	}
+
+	if errors.Is(err, patch.ErrInvalidPatch) {
+		log.Warnf("[%s] failed to apply patch: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body: PatchTrackErrorResponseBody{
+				Message: "invalid patch document",
+			},
+		}, nil
+	}
+
+	if errors.Is(err, patch.ErrTestFailed) {
+		log.Warnf("[%s] patch test failed: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusConflict,
+			Body: PatchTrackErrorResponseBody{
+				Message: "patch test failed",
+			},
+		}, nil
+	}
+
+	if errors.Is(err, patch.ErrPathNotFound) {
+		log.Warnf("[%s] patch path does not exist: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusConflict,
+			Body: PatchTrackErrorResponseBody{
+				Message: "patch path does not exist",
+			},
+		}, nil
+	}
+
+	if errors.Is(err, ErrConcurrentModification) {
+		log.Warnf("[%s] track was modified concurrently: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusConflict,
+			Body: PatchTrackErrorResponseBody{
+				Message: "track was modified concurrently",
+			},
+		}, nil
+	}
+
+	if errors.Is(err, ErrInvalidDocument) {
+		log.Warnf("[%s] patched document is not a track: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusUnprocessableEntity,
+			Body: PatchTrackErrorResponseBody{
+				Message: "patched document is not a valid track",
+			},
+		}, nil
+	}
+
+	if errors.Is(err, ErrReadOnlyField) {
+		log.Warnf("[%s] patch changes a read-only field: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusUnprocessableEntity,
+			Body: PatchTrackErrorResponseBody{
+				Message: "patch changes a read-only field",
+			},
+		}, nil
+	}
+
+	if errors.Is(err, ErrValidationFailed) {
+		log.Warnf("[%s] failed patched track validation: %s", context.ID, validationError.ErrorMessage)
+		return &api.APIResponse{
+			StatusCode: http.StatusUnprocessableEntity,
+			Body:       validationError,
+		}, nil
+	}
+
+	if errors.Is(err, updatetrack.ErrArtistNotFound) {
+		log.Warnf("[%s] artist does not exist: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body: PatchTrackErrorResponseBody{
+				Message: "artist does not exist",
+			},
+		}, nil
+	}
+
+	if errors.Is(err, updatetrack.ErrFeaturedArtistNotFound) {
+		log.Warnf("[%s] featured artist does not exist: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body: PatchTrackErrorResponseBody{
+				Message: "featured artist does not exist",
+			},
+		}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to patch database item: %w", err)
+	}
+
+	etag, err := api.ComputeETag(track)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute entity tag: %w", err)
+	}
+
+	log.Tracef("[%s] successfully completed request", context.ID)
+	return &api.APIResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			api.ETagHeader:         etag,
+			api.LastModifiedHeader: track.UpdatedAt.UTC().Format(http.TimeFormat),
+		},
+		Body: track,
+	}, nil
+}
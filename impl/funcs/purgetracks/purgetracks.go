@@ -0,0 +1,179 @@
+package purgetracks
+
+import (
+	stdcontext "context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gostream-official/tracks/impl/inject"
+	"github.com/gostream-official/tracks/impl/models"
+	"github.com/gostream-official/tracks/impl/revisions"
+	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/clock"
+	"github.com/gostream-official/tracks/pkg/parallel"
+	"github.com/gostream-official/tracks/pkg/store/query"
+	"github.com/revx-official/output/log"
+)
+
+// Description:
+//
+//	The response body for the purge tracks endpoint.
+type PurgeTracksResponseBody struct {
+
+	// The number of permanently removed tracks.
+	DeletedCount int64 `json:"deletedCount"`
+
+	// The deletion date up to which deleted tracks were removed.
+	DeletedBefore time.Time `json:"deletedBefore"`
+}
+
+// Description:
+//
+//	The error response body for the purge tracks endpoint.
+type PurgeTracksErrorResponseBody struct {
+
+	// The error message.
+	Message string `json:"message"`
+}
+
+// Description:
+//
+//	Creates the filter matching tracks which were deleted before the given date.
+//
+// Parameters:
+//
+//	deletedBefore The deletion date up to which tracks are matched, inclusive.
+//
+// Returns:
+//
+//	The created filter.
+func CreatePurgeFilter(deletedBefore time.Time) query.Filter {
+	return query.Filter{
+		Root: query.FilterOperatorLte{
+			Key:   "deletedAt",
+			Value: deletedBefore,
+		},
+	}
+}
+
+// Description:
+//
+//	Permanently removes all tracks which were deleted before the given date.
+//	Records a purge revision for each removed track, holding the track as it was removed,
+//	so the removal stays auditable after the track is gone.
+//	Runs within a transaction, so tracks are never removed without their purge revision.
+//
+// Parameters:
+//
+//	ctx 			The context of the operation.
+//	injector 		The injector.
+//	deletedBefore 	The deletion date up to which tracks are removed, inclusive.
+//	now 			The date of the removal.
+//	principal 		The principal removing the tracks.
+//
+// Returns:
+//
+//	The number of removed tracks.
+//	An error if a database request fails.
+func PurgeTracks(ctx stdcontext.Context, injector *inject.Injector, deletedBefore time.Time, now time.Time, principal string) (int64, error) {
+	var count int64
+
+	err := injector.Transactor.WithTransaction(ctx, func(tx stdcontext.Context) error {
+		filter := CreatePurgeFilter(deletedBefore)
+
+		tracks, err := injector.TrackStore.FindItems(tx, &filter)
+		if err != nil {
+			return err
+		}
+
+		if len(tracks) == 0 {
+			count = 0
+			return nil
+		}
+
+		trackIDs := make([]interface{}, len(tracks))
+		trackRevisions := make([]models.TrackRevision, len(tracks))
+
+		for index, track := range tracks {
+			purged := track
+			purged.Version++
+			purged.UpdatedAt = now
+			purged.UpdatedBy = principal
+
+			trackIDs[index] = track.ID
+			trackRevisions[index], err = revisions.NewRevision(models.RevisionOperationPurge, &tracks[index], purged)
+			if err != nil {
+				return err
+			}
+		}
+
+		revisionResult, err := injector.RevisionStore.CreateItems(tx, trackRevisions, true)
+		if err != nil {
+			return err
+		}
+
+		if len(revisionResult.Errors) > 0 {
+			return fmt.Errorf("purgetracks: failed to record revision of track %s: %s", trackRevisions[revisionResult.Errors[0].Index].TrackID, revisionResult.Errors[0].Message)
+		}
+
+		count, err = injector.TrackStore.DeleteItems(tx, &query.Filter{
+			Root: query.FilterOperatorIn{
+				Key:    "_id",
+				Values: trackIDs,
+			},
+		})
+
+		return err
+	})
+
+	return count, err
+}
+
+// Description:
+//
+//	The router handler for: Purge Tracks
+//	Permanently removes all tracks which are in the trash for longer than the trash retention period.
+//	Restricted to the configured administrator principals, all other principals are forbidden.
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//
+// Returns:
+//
+//	An API response object, or an error which is answered with an error status.
+func Handler(request *api.APIRequest, injector *inject.Injector) (*api.APIResponse, error) {
+	context := parallel.FromContext(request.Context)
+
+	principal := api.GetPrincipal(request)
+	if !injector.IsAdmin(principal) {
+		log.Warnf("[%s] principal is not allowed to purge tracks: %s", context.ID, principal)
+		return &api.APIResponse{
+			StatusCode: http.StatusForbidden,
+			Body: PurgeTracksErrorResponseBody{
+				Message: "principal is not an administrator",
+			},
+		}, nil
+	}
+
+	now := clock.Timestamp(injector.Clock)
+	deletedBefore := now.Add(-injector.TrashRetention)
+
+	log.Tracef("[%s] attempting to purge database items deleted before %s ...", context.ID, deletedBefore)
+	count, err := PurgeTracks(request.Context, injector, deletedBefore, now, principal)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge database items: %w", err)
+	}
+
+	log.Infof("[%s] purged %d deleted tracks, requested by: %s", context.ID, count, principal)
+	return &api.APIResponse{
+		StatusCode: http.StatusOK,
+		Body: PurgeTracksResponseBody{
+			DeletedCount:  count,
+			DeletedBefore: deletedBefore,
+		},
+	}, nil
+}
@@ -0,0 +1,208 @@
+package restoretrack
+
+import (
+	stdcontext "context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gostream-official/tracks/impl/funcs/updatetrack"
+	"github.com/gostream-official/tracks/impl/inject"
+	"github.com/gostream-official/tracks/impl/models"
+	"github.com/gostream-official/tracks/impl/revisions"
+	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/clock"
+	"github.com/gostream-official/tracks/pkg/parallel"
+	"github.com/gostream-official/tracks/pkg/store/query"
+	"github.com/revx-official/output/log"
+)
+
+var (
+
+	// Returned if the track to restore does not exist.
+	ErrTrackNotFound = errors.New("restoretrack: track not found")
+
+	// Returned if the track to restore is not deleted.
+	ErrTrackNotDeleted = errors.New("restoretrack: track not deleted")
+
+	// Returned if the track to restore does not satisfy the request precondition.
+	ErrPreconditionFailed = errors.New("restoretrack: precondition failed")
+)
+
+// Description:
+//
+//	The error response body for the restore track endpoint.
+type RestoreTrackErrorResponseBody struct {
+
+	// The error message.
+	Message string `json:"message"`
+}
+
+// Description:
+//
+//	Creates the update restoring a track from the trash.
+//
+// Parameters:
+//
+//	now 		The restoration date.
+//	principal 	The restoring principal.
+//
+// Returns:
+//
+//	The created update.
+func CreateRestoreUpdate(now time.Time, principal string) query.Update {
+	update := query.Update{
+		Root: query.UpdateOperatorUnset{
+			Unset: []string{"deletedAt"},
+		},
+	}
+
+	return updatetrack.StampUpdate(update, now, principal)
+}
+
+// Description:
+//
+//	Restores a deleted track, if it satisfies the precondition, and records its revision.
+//	The track is looked up, compared and restored within a single transaction.
+//
+// Parameters:
+//
+//	ctx 			The context of the operation.
+//	injector 		The injector, providing the stores and the transactor.
+//	id 				The id of the track to restore.
+//	update 			The update restoring the track.
+//	precondition 	The precondition of the request, nil if the request is not conditional.
+//
+// Returns:
+//
+//	The restored track.
+//	ErrTrackNotFound, ErrTrackNotDeleted or ErrPreconditionFailed, if the track cannot be restored,
+//	an error, if the database request failed.
+func RestoreTrack(ctx stdcontext.Context, injector *inject.Injector, id string, update query.Update, precondition *api.Precondition) (*models.TrackInfo, error) {
+	trackStore := injector.TrackStore
+
+	var restoredTrack *models.TrackInfo
+
+	err := injector.Transactor.WithTransaction(ctx, func(tx stdcontext.Context) error {
+		track, err := updatetrack.FindTrack(tx, trackStore, id, true)
+		if errors.Is(err, updatetrack.ErrTrackNotFound) {
+			return ErrTrackNotFound
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if track.DeletedAt == nil {
+			return ErrTrackNotDeleted
+		}
+
+		etag, err := api.ComputeETag(track)
+		if err != nil {
+			return err
+		}
+
+		if precondition != nil && !precondition.Matches(etag) {
+			return ErrPreconditionFailed
+		}
+
+		updateFilter := query.Filter{
+			Root: updatetrack.CreateVersionFilter(id, track.Version),
+		}
+
+		count, err := trackStore.UpdateItem(tx, &updateFilter, &update)
+		if err != nil {
+			return err
+		}
+
+		if count == 0 {
+			return ErrPreconditionFailed
+		}
+
+		restoredTrack, err = updatetrack.FindTrackByID(tx, trackStore, id)
+		if err != nil {
+			return err
+		}
+
+		return revisions.Record(tx, injector.RevisionStore, models.RevisionOperationRestore, track, *restoredTrack)
+	})
+
+	return restoredTrack, err
+}
+
+// Description:
+//
+//	The router handler for: Restore Track
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//
+// Returns:
+//
+//	An API response object, or an error which is answered with an error status.
+func Handler(request *api.APIRequest, injector *inject.Injector) (*api.APIResponse, error) {
+	context := parallel.FromContext(request.Context)
+
+	precondition, err := api.ParseIfMatch(request)
+	if err != nil {
+		log.Warnf("[%s] failed to parse precondition: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body: RestoreTrackErrorResponseBody{
+				Message: "invalid If-Match header",
+			},
+		}, nil
+	}
+
+	idToRestore := request.PathParameters["id"]
+	update := CreateRestoreUpdate(clock.Timestamp(injector.Clock), api.GetPrincipal(request))
+
+	log.Tracef("[%s] attempting to restore database item ...", context.ID)
+	track, err := RestoreTrack(request.Context, injector, idToRestore, update, precondition)
+
+	if errors.Is(err, ErrTrackNotFound) {
+		log.Warnf("[%s] could not find track: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusNotFound,
+		}, nil
+	}
+
+	if errors.Is(err, ErrTrackNotDeleted) {
+		log.Warnf("[%s] track is not deleted: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusConflict,
+			Body: RestoreTrackErrorResponseBody{
+				Message: "track is not deleted",
+			},
+		}, nil
+	}
+
+	if errors.Is(err, ErrPreconditionFailed) {
+		log.Warnf("[%s] track does not match the precondition: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusPreconditionFailed,
+		}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore database item: %w", err)
+	}
+
+	etag, err := api.ComputeETag(track)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute entity tag: %w", err)
+	}
+
+	log.Tracef("[%s] successfully completed request", context.ID)
+	return &api.APIResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			api.ETagHeader:         etag,
+			api.LastModifiedHeader: track.UpdatedAt.UTC().Format(http.TimeFormat),
+		},
+		Body: track,
+	}, nil
+}
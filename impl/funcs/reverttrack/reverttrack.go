@@ -0,0 +1,150 @@
+package reverttrack
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gostream-official/tracks/impl/funcs/gettrackrevision"
+	"github.com/gostream-official/tracks/impl/funcs/updatetrack"
+	"github.com/gostream-official/tracks/impl/inject"
+	"github.com/gostream-official/tracks/impl/models"
+	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/clock"
+	"github.com/gostream-official/tracks/pkg/parallel"
+	"github.com/revx-official/output/log"
+)
+
+// Description:
+//
+//	The router handler for: Revert Track
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//
+// Returns:
+//
+//	An API response object, or an error which is answered with an error status.
+func Handler(request *api.APIRequest, injector *inject.Injector) (*api.APIResponse, error) {
+	context := parallel.FromContext(request.Context)
+
+	id, validationErr := updatetrack.GetAndValidateID(request)
+	if validationErr != nil {
+		log.Warnf("[%s] failed path parameter validation: %s", context.ID, validationErr.ErrorMessage)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body:       validationErr,
+		}, nil
+	}
+
+	revisionNumber, revisionValidationErr := gettrackrevision.GetAndValidateRevision(request)
+	if revisionValidationErr != nil {
+		log.Warnf("[%s] failed path parameter validation: %s", context.ID, revisionValidationErr.ErrorMessage)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body:       revisionValidationErr,
+		}, nil
+	}
+
+	precondition, err := api.ParseIfMatch(request)
+	if err != nil {
+		log.Warnf("[%s] failed to parse precondition: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body: updatetrack.UpdateTrackErrorResponseBody{
+				Message: "invalid If-Match header",
+			},
+		}, nil
+	}
+
+	revision, err := gettrackrevision.FindRevision(request.Context, injector.RevisionStore, id, revisionNumber)
+
+	if errors.Is(err, gettrackrevision.ErrRevisionNotFound) {
+		log.Warnf("[%s] could not find revision: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusNotFound,
+		}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve database items: %w", err)
+	}
+
+	requestBody := updatetrack.CreateRequestBodyFromTrack(&revision.Snapshot)
+
+	validationError := updatetrack.ValidateRequestBody(requestBody)
+	if validationError != nil {
+		log.Warnf("[%s] failed revision validation: %s", context.ID, validationError.ErrorMessage)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body:       validationError,
+		}, nil
+	}
+
+	change := updatetrack.TrackChange{
+		ID:                id,
+		Update:            updatetrack.StampUpdate(updatetrack.CreateUpdateFromRequestBody(requestBody), clock.Timestamp(injector.Clock), api.GetPrincipal(request)),
+		ArtistID:          requestBody.ArtistID,
+		FeaturedArtistIDs: requestBody.FeaturedArtistIDs,
+		Precondition:      precondition,
+		Operation:         models.RevisionOperationRevert,
+	}
+
+	log.Tracef("[%s] attempting to revert database item to revision %d ...", context.ID, revisionNumber)
+	track, _, err := updatetrack.UpdateTrack(request.Context, injector, change)
+
+	if errors.Is(err, updatetrack.ErrTrackNotFound) {
+		log.Warnf("[%s] could not find track: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusNotFound,
+		}, nil
+	}
+
+	if errors.Is(err, updatetrack.ErrPreconditionFailed) {
+		log.Warnf("[%s] track does not match the precondition: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusPreconditionFailed,
+		}, nil
+	}
+
+	if errors.Is(err, updatetrack.ErrArtistNotFound) {
+		log.Warnf("[%s] artist does not exist: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body: updatetrack.UpdateTrackErrorResponseBody{
+				Message: "artist does not exist",
+			},
+		}, nil
+	}
+
+	if errors.Is(err, updatetrack.ErrFeaturedArtistNotFound) {
+		log.Warnf("[%s] featured artist does not exist: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body: updatetrack.UpdateTrackErrorResponseBody{
+				Message: "featured artist does not exist",
+			},
+		}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to update database item: %w", err)
+	}
+
+	etag, err := api.ComputeETag(track)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute entity tag: %w", err)
+	}
+
+	log.Tracef("[%s] successfully completed request", context.ID)
+	return &api.APIResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			api.ETagHeader:         etag,
+			api.LastModifiedHeader: track.UpdatedAt.UTC().Format(http.TimeFormat),
+		},
+		Body: track,
+	}, nil
+}
@@ -0,0 +1,264 @@
+package searchtracks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gostream-official/tracks/impl/funcs/gettracks"
+	"github.com/gostream-official/tracks/impl/inject"
+	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/parallel"
+	"github.com/gostream-official/tracks/pkg/store"
+	"github.com/gostream-official/tracks/pkg/store/query"
+	"github.com/revx-official/output/log"
+)
+
+const (
+
+	// The maximum size of the request body in bytes.
+	MaxBodySize = 64 * 1024
+
+	// The maximum nesting depth of the filter.
+	MaxFilterDepth = 8
+
+	// The maximum number of operators and values of the filter.
+	MaxFilterNodes = 256
+)
+
+// Description:
+//
+//	The request body for the search tracks endpoint.
+type SearchTracksRequestBody struct {
+
+	// The JSON encoded filter, e.g. '{"op":"and","args":[...]}'. Matches all tracks if omitted.
+	Filter json.RawMessage `json:"filter,omitempty"`
+
+	// The sort specification, e.g. '-trackStats.streams,title'.
+	Sort string `json:"sort,omitempty"`
+
+	// The projected fields, e.g. 'id,title,audioFeatures.tempo'. All fields are returned if omitted.
+	Fields string `json:"fields,omitempty"`
+
+	// The page size. Falls back to the default page size if omitted.
+	Limit uint32 `json:"limit,omitempty"`
+
+	// The cursor of the requested page, as returned by the previous page.
+	Cursor string `json:"cursor,omitempty"`
+
+	// Whether deleted tracks are included. Deleted tracks are hidden by default.
+	IncludeDeleted bool `json:"includeDeleted,omitempty"`
+}
+
+// Description:
+//
+//	The error response body for the search tracks endpoint.
+type SearchTracksErrorResponseBody struct {
+
+	// The error message.
+	Message string `json:"message"`
+}
+
+// Description:
+//
+//	Describes a validation error.
+type SearchTracksValidationError struct {
+
+	// The JSON field which is referenced by the error message.
+	FieldRef string `json:"ref"`
+
+	// The error message.
+	ErrorMessage string `json:"error"`
+}
+
+// Description:
+//
+//	Unmarshals the request body for this endpoint.
+//	Unknown fields are rejected.
+//
+// Parameters:
+//
+//	request The original request.
+//
+// Returns:
+//
+//	The unmarshalled request body, or an error when unmarshalling fails.
+func ExtractRequestBody(request *api.APIRequest) (*SearchTracksRequestBody, error) {
+	body := &SearchTracksRequestBody{}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(request.Body)))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// Description:
+//
+//	Creates the query filter from the request body.
+//
+// Parameters:
+//
+//	request The request body.
+//
+// Returns:
+//
+//	The query filter and the projected fields.
+//	A validation error if the filter, the sort specification, the projection or the limit is invalid.
+func CreateFilterFromRequestBody(request *SearchTracksRequestBody) (query.Filter, []query.SchemaField, *SearchTracksValidationError) {
+	filter := query.Filter{
+		Limit: request.Limit,
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = gettracks.DefaultPageSize
+	}
+
+	if filter.Limit > gettracks.MaxPageSize {
+		return query.Filter{}, nil, &SearchTracksValidationError{
+			FieldRef:     "limit",
+			ErrorMessage: fmt.Sprintf("value must be a number between 1 and %d", gettracks.MaxPageSize),
+		}
+	}
+
+	if len(request.Sort) > 0 {
+		sort, validationErr := gettracks.ParseAndValidateSort(request.Sort)
+		if validationErr != nil {
+			return query.Filter{}, nil, &SearchTracksValidationError{
+				FieldRef:     "sort",
+				ErrorMessage: validationErr.ErrorMessage,
+			}
+		}
+
+		filter.Sort = sort
+	}
+
+	if len(request.Filter) > 0 && string(request.Filter) != "null" {
+		root, err := query.UnmarshalQuery(request.Filter, query.DecodeOptions{
+			MaxDepth: MaxFilterDepth,
+			MaxNodes: MaxFilterNodes,
+			Schema:   gettracks.QuerySchema,
+		})
+
+		if err != nil {
+			return query.Filter{}, nil, &SearchTracksValidationError{
+				FieldRef:     "filter",
+				ErrorMessage: err.Error(),
+			}
+		}
+
+		filter.Root = root
+	}
+
+	if !request.IncludeDeleted {
+		filter.Root = gettracks.ExcludeDeleted(filter.Root)
+	}
+
+	var fields []query.SchemaField
+
+	if len(request.Fields) > 0 {
+		projection, err := query.ParseProjection(request.Fields, gettracks.QuerySchema)
+		if err != nil {
+			return query.Filter{}, nil, &SearchTracksValidationError{
+				FieldRef:     "fields",
+				ErrorMessage: err.Error(),
+			}
+		}
+
+		fields = projection
+		filter.Projection = gettracks.ProjectionKeys(fields)
+	}
+
+	return filter, fields, nil
+}
+
+// Description:
+//
+//	The router handler for: Search Tracks
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//
+// Returns:
+//
+//	An API response object, or an error which is answered with an error status.
+func Handler(request *api.APIRequest, injector *inject.Injector) (*api.APIResponse, error) {
+	context := parallel.FromContext(request.Context)
+
+	if len(request.Body) > MaxBodySize {
+		log.Warnf("[%s] request body exceeds %d bytes", context.ID, MaxBodySize)
+		return &api.APIResponse{
+			StatusCode: http.StatusRequestEntityTooLarge,
+			Body: SearchTracksErrorResponseBody{
+				Message: "request body too large",
+			},
+		}, nil
+	}
+
+	requestBody, err := ExtractRequestBody(request)
+	if err != nil {
+		log.Warnf("[%s] failed to extract request body: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body: SearchTracksErrorResponseBody{
+				Message: "invalid request body",
+			},
+		}, nil
+	}
+
+	filter, fields, validationErr := CreateFilterFromRequestBody(requestBody)
+	if validationErr != nil {
+		log.Warnf("[%s] failed request body validation: %s", context.ID, validationErr.ErrorMessage)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body:       validationErr,
+		}, nil
+	}
+
+	if filter.Root != nil {
+		log.Debugf("[%s] filter: %s", context.ID, filter.Root)
+	}
+
+	page, err := store.FindPage(request.Context, injector.TrackStore, &filter, requestBody.Cursor)
+
+	if errors.Is(err, store.ErrInvalidCursor) {
+		log.Warnf("[%s] received invalid cursor: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body: SearchTracksValidationError{
+				FieldRef:     "cursor",
+				ErrorMessage: "value is not a valid cursor for this query",
+			},
+		}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve database items: %w", err)
+	}
+
+	items, err := gettracks.ProjectItems(page.Items, fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to project items: %w", err)
+	}
+
+	return &api.APIResponse{
+		StatusCode: http.StatusOK,
+		Body: gettracks.GetTracksResponseBody{
+			Items: items,
+			Page: gettracks.GetTracksPageInfo{
+				Limit:      filter.Limit,
+				Count:      len(page.Items),
+				HasMore:    page.HasMore,
+				NextCursor: page.NextCursor,
+			},
+		},
+	}, nil
+}
@@ -1,17 +1,22 @@
 package updatetrack
 
 import (
+	stdcontext "context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/gostream-official/tracks/impl/artists"
+	"github.com/gostream-official/tracks/impl/funcs/gettracks"
 	"github.com/gostream-official/tracks/impl/inject"
 	"github.com/gostream-official/tracks/impl/models"
+	"github.com/gostream-official/tracks/impl/revisions"
 	"github.com/gostream-official/tracks/pkg/api"
 	"github.com/gostream-official/tracks/pkg/arrays"
-	"github.com/gostream-official/tracks/pkg/marshal"
+	"github.com/gostream-official/tracks/pkg/clock"
 	"github.com/gostream-official/tracks/pkg/parallel"
 	"github.com/gostream-official/tracks/pkg/store"
 	"github.com/gostream-official/tracks/pkg/store/query"
@@ -20,31 +25,47 @@ import (
 	"github.com/google/uuid"
 )
 
+var (
+
+	// Returned if a referenced artist does not exist.
+	ErrArtistNotFound = errors.New("updatetrack: artist not found")
+
+	// Returned if a referenced featured artist does not exist.
+	ErrFeaturedArtistNotFound = errors.New("updatetrack: featured artist not found")
+
+	// Returned if the track to update does not exist.
+	ErrTrackNotFound = errors.New("updatetrack: track not found")
+
+	// Returned if the track to update does not satisfy the request precondition.
+	ErrPreconditionFailed = errors.New("updatetrack: precondition failed")
+)
+
 // Description:
 //
 //	The request body for the update track endpoint.
+//	Replaces the track, fields missing from the request body are cleared.
 type UpdateTrackRequestBody struct {
 
 	// The artist.
-	ArtistID string `json:"artistId,omitempty"`
+	ArtistID string `json:"artistId"`
 
 	// A list of featured artists.
-	FeaturedArtistIDs []string `json:"featuredArtistIds,omitempty"`
+	FeaturedArtistIDs []string `json:"featuredArtistIds"`
 
 	// The track title.
-	Title string `json:"title,omitempty"`
+	Title string `json:"title"`
 
 	// The label that published the track.
-	Label string `json:"label,omitempty"`
+	Label string `json:"label"`
 
 	// The release date of the track.
-	ReleaseDate string `json:"releaseDate,omitempty"`
+	ReleaseDate string `json:"releaseDate"`
 
 	// The statistics of the track.
-	TrackStats UpdateTrackStatsRequestBody `json:"trackStats,omitempty"`
+	TrackStats UpdateTrackStatsRequestBody `json:"trackStats"`
 
 	// The audio features of the track.
-	AudioFeatures UpdateTrackAudioFeaturesRequestBody `json:"audioFeatures,omitempty"`
+	AudioFeatures UpdateTrackAudioFeaturesRequestBody `json:"audioFeatures"`
 }
 
 // Description:
@@ -53,10 +74,10 @@ type UpdateTrackRequestBody struct {
 type UpdateTrackStatsRequestBody struct {
 
 	// The stream count of the track.
-	Streams uint32 `json:"streams,omitempty"`
+	Streams uint32 `json:"streams"`
 
 	// The amount of likes of the track.
-	Likes uint32 `json:"likes,omitempty"`
+	Likes uint32 `json:"likes"`
 }
 
 // Description:
@@ -65,34 +86,34 @@ type UpdateTrackStatsRequestBody struct {
 type UpdateTrackAudioFeaturesRequestBody struct {
 
 	// The key of the track.
-	Key string `json:"key,omitempty"`
+	Key string `json:"key"`
 
 	// The tempo of the track.
-	Tempo float32 `json:"tempo,omitempty"`
+	Tempo float32 `json:"tempo"`
 
 	// The duration of the track.
-	Duration float32 `json:"duration,omitempty"`
+	Duration float32 `json:"duration"`
 
 	// The energy level of the track.
-	Energy float32 `json:"energy,omitempty"`
+	Energy float32 `json:"energy"`
 
 	// The danceability level of the track.
-	Danceability float32 `json:"danceability,omitempty"`
+	Danceability float32 `json:"danceability"`
 
 	// The accousticness level of the track.
-	Accousticness float32 `json:"accousticness,omitempty"`
+	Accousticness float32 `json:"accousticness"`
 
 	// The instrumentalness level of the track.
-	Instrumentalness float32 `json:"instrumentalness,omitempty"`
+	Instrumentalness float32 `json:"instrumentalness"`
 
 	// The liveness level of the track.
-	Liveness float32 `json:"liveness,omitempty"`
+	Liveness float32 `json:"liveness"`
 
 	// The track's loudness.
-	Loudness float32 `json:"loudness,omitempty"`
+	Loudness float32 `json:"loudness"`
 
 	// The track's time signature.
-	TimeSignature int `json:"timeSignature,omitempty"`
+	TimeSignature int `json:"timeSignature"`
 }
 
 // Description:
@@ -128,28 +149,6 @@ type UpdateTrackPathValidationError struct {
 	ErrorMessage string `json:"error"`
 }
 
-// Description:
-//
-//	Attempts to cast the input object to the endpoint injector.
-//	If this cast fails, we cannot proceed to process this request.
-//
-// Parameters:
-//
-//	object 	The injector object.
-//
-// Returns:
-//
-//	The injector if the cast is successful, an error otherwise.
-func GetSafeInjector(object interface{}) (*inject.Injector, error) {
-	injector, ok := object.(inject.Injector)
-
-	if !ok {
-		return nil, fmt.Errorf("updatetrack: failed to deduce injector")
-	}
-
-	return &injector, nil
-}
-
 // Description:
 //
 //	Unmarshals the request body for this endpoint.
@@ -212,56 +211,42 @@ func GetAndValidateID(request *api.APIRequest) (string, *UpdateTrackPathValidati
 //
 //	An error if the validation fails.
 func ValidateRequestBody(request *UpdateTrackRequestBody) *UpdateTrackValidationError {
+	artistID := strings.TrimSpace(request.ArtistID)
+	featuredArtistIDs := trimAll(request.FeaturedArtistIDs)
 
-	if request.ArtistID != "" {
-		artistID := strings.TrimSpace(request.ArtistID)
+	title := strings.TrimSpace(request.Title)
+	releaseDate := strings.TrimSpace(request.ReleaseDate)
 
-		_, err := uuid.Parse(artistID)
-		if err != nil {
-			return &UpdateTrackValidationError{
-				FieldRef:     "artistId",
-				ErrorMessage: "value is not a valid uuid",
-			}
+	_, err := uuid.Parse(artistID)
+	if err != nil {
+		return &UpdateTrackValidationError{
+			FieldRef:     "artistId",
+			ErrorMessage: "value is not a valid uuid",
 		}
-
 	}
 
-	if len(request.FeaturedArtistIDs) > 0 {
-		featuredArtistIDs := arrays.Map(request.FeaturedArtistIDs, func(artist string) string {
-			return strings.TrimSpace(artist)
-		})
-
-		for _, artist := range featuredArtistIDs {
-			_, err := uuid.Parse(artist)
-			if err != nil {
-				return &UpdateTrackValidationError{
-					FieldRef:     "featuredArtistIds",
-					ErrorMessage: "array contains invalid uuid",
-				}
+	for _, artist := range featuredArtistIDs {
+		_, err := uuid.Parse(artist)
+		if err != nil {
+			return &UpdateTrackValidationError{
+				FieldRef:     "featuredArtistIds",
+				ErrorMessage: "array contains invalid uuid",
 			}
 		}
 	}
 
-	if request.Title != "" {
-		title := strings.TrimSpace(request.Title)
-
-		if len(title) == 0 {
-			return &UpdateTrackValidationError{
-				FieldRef:     "title",
-				ErrorMessage: "value must not be empty",
-			}
+	if len(title) == 0 {
+		return &UpdateTrackValidationError{
+			FieldRef:     "title",
+			ErrorMessage: "value must not be empty",
 		}
 	}
 
-	if request.ReleaseDate != "" {
-		releaseDate := strings.TrimSpace(request.ReleaseDate)
-
-		_, err := time.Parse("2006-01-02", releaseDate)
-		if err != nil {
-			return &UpdateTrackValidationError{
-				FieldRef:     "releaseDate",
-				ErrorMessage: "expected following format: yyyy-MM-dd",
-			}
+	_, err = time.Parse("2006-01-02", releaseDate)
+	if err != nil {
+		return &UpdateTrackValidationError{
+			FieldRef:     "releaseDate",
+			ErrorMessage: "expected following format: yyyy-MM-dd",
 		}
 	}
 
@@ -303,20 +288,222 @@ func ValidateAudioFeaturesRequestBody(request *UpdateTrackAudioFeaturesRequestBo
 	return nil
 }
 
+// Description:
+//
+//	Creates the update operator from the request body.
+//	All fields of the track are replaced, including empty fields.
+//	Service maintained fields are left untouched.
+//
+// Parameters:
+//
+//	request The validated request body.
+//
+// Returns:
+//
+//	The update operator.
+func CreateUpdateFromRequestBody(request *UpdateTrackRequestBody) query.Update {
+	releaseDate, _ := time.Parse("2006-01-02", strings.TrimSpace(request.ReleaseDate))
+
+	return query.Update{
+		Root: query.UpdateOperatorSet{
+			Set: map[string]interface{}{
+				"artistId":          strings.TrimSpace(request.ArtistID),
+				"featuredArtistIds": trimAll(request.FeaturedArtistIDs),
+				"title":             strings.TrimSpace(request.Title),
+				"label":             request.Label,
+				"releaseDate":       releaseDate,
+				"trackStats": models.TrackStats{
+					Streams: request.TrackStats.Streams,
+					Likes:   request.TrackStats.Likes,
+				},
+				"audioFeatures": models.AudioFeatures{
+					Key:              request.AudioFeatures.Key,
+					Tempo:            request.AudioFeatures.Tempo,
+					Duration:         request.AudioFeatures.Duration,
+					Energy:           request.AudioFeatures.Energy,
+					Danceability:     request.AudioFeatures.Danceability,
+					Accousticness:    request.AudioFeatures.Accousticness,
+					Instrumentalness: request.AudioFeatures.Instrumentalness,
+					Liveness:         request.AudioFeatures.Liveness,
+					Loudness:         request.AudioFeatures.Loudness,
+					TimeSignature:    request.AudioFeatures.TimeSignature,
+				},
+			},
+		},
+	}
+}
+
+// Description:
+//
+//	Creates the request body replacing a track with itself.
+//	Serves as the document patches and reverts are applied to.
+//
+// Parameters:
+//
+//	track The track.
+//
+// Returns:
+//
+//	The request body.
+func CreateRequestBodyFromTrack(track *models.TrackInfo) *UpdateTrackRequestBody {
+	return &UpdateTrackRequestBody{
+		ArtistID:          track.ArtistID,
+		FeaturedArtistIDs: track.FeaturedArtistIDs,
+		Title:             track.Title,
+		Label:             track.Label,
+		ReleaseDate:       track.ReleaseDate.Format("2006-01-02"),
+		TrackStats: UpdateTrackStatsRequestBody{
+			Streams: track.TrackStats.Streams,
+			Likes:   track.TrackStats.Likes,
+		},
+		AudioFeatures: UpdateTrackAudioFeaturesRequestBody{
+			Key:              track.AudioFeatures.Key,
+			Tempo:            track.AudioFeatures.Tempo,
+			Duration:         track.AudioFeatures.Duration,
+			Energy:           track.AudioFeatures.Energy,
+			Danceability:     track.AudioFeatures.Danceability,
+			Accousticness:    track.AudioFeatures.Accousticness,
+			Instrumentalness: track.AudioFeatures.Instrumentalness,
+			Liveness:         track.AudioFeatures.Liveness,
+			Loudness:         track.AudioFeatures.Loudness,
+			TimeSignature:    track.AudioFeatures.TimeSignature,
+		},
+	}
+}
+
+// Description:
+//
+//	Adds the increment of the track version and the update of the modification date
+//	and the modifying principal to an update.
+//	An empty update remains empty.
+//
+// Parameters:
+//
+//	update 		The update to extend.
+//	now 		The modification date.
+//	principal 	The modifying principal.
+//
+// Returns:
+//
+//	The extended update.
+func StampUpdate(update query.Update, now time.Time, principal string) query.Update {
+	if update.Root == nil {
+		return update
+	}
+
+	operators := []query.IUpdate{update.Root}
+
+	combined, ok := update.Root.(query.UpdateOperatorCombine)
+	if ok {
+		operators = append([]query.IUpdate{}, combined.Operators...)
+	}
+
+	operators = append(operators, query.UpdateOperatorInc{
+		Inc: map[string]interface{}{
+			"version": int64(1),
+		},
+	}, query.UpdateOperatorSet{
+		Set: map[string]interface{}{
+			"updatedAt": now,
+			"updatedBy": principal,
+		},
+	})
+
+	return query.Update{
+		Root: query.UpdateOperatorCombine{Operators: operators},
+	}
+}
+
+// Description:
+//
+//	Creates a filter matching a track in one of the given versions.
+//	Tracks created before versioning have no version field and match version 0.
+//
+// Parameters:
+//
+//	id 			The id of the track.
+//	versions 	The versions to match.
+//
+// Returns:
+//
+//	The created filter.
+func CreateVersionFilter(id string, versions ...int64) query.IQuery {
+	values := make([]interface{}, 0, len(versions))
+
+	for _, version := range versions {
+		values = append(values, version)
+
+		if version == 0 {
+			values = append(values, nil)
+		}
+	}
+
+	return query.FilterOperatorAnd{
+		And: []query.IQuery{
+			query.FilterOperatorEq{
+				Key:   "_id",
+				Value: id,
+			},
+			query.FilterOperatorIn{
+				Key:    "version",
+				Values: values,
+			},
+		},
+	}
+}
+
+// Description:
+//
+//	Trims the whitespace of all strings.
+//
+// Parameters:
+//
+//	values The strings to trim.
+//
+// Returns:
+//
+//	The trimmed strings.
+func trimAll(values []string) []string {
+	return arrays.Map(values, func(value string) string {
+		return strings.TrimSpace(value)
+	})
+}
+
 // Description:
 //
 //	Searches a track with the given id in the database.
+//	Deleted tracks are not found.
 //
 // Parameters:
 //
+//	ctx 	The context of the operation.
 //	store 	The store to search through.
 //	id 		The id to search for.
 //
 // Returns:
 //
 //	The first matched track.
-//	An error if the query fails.
-func FindTrackByID(store *store.MongoStore[models.TrackInfo], id string) (*models.TrackInfo, error) {
+//	ErrTrackNotFound, if no track was found, an error if the query fails.
+func FindTrackByID(ctx stdcontext.Context, store store.Store[models.TrackInfo], id string) (*models.TrackInfo, error) {
+	return FindTrack(ctx, store, id, false)
+}
+
+// Description:
+//
+//	Searches a track with the given id in the database.
+//
+// Parameters:
+//
+//	ctx 			The context of the operation.
+//	store 			The store to search through.
+//	id 				The id to search for.
+//	includeDeleted 	Whether deleted tracks are found.
+//
+// Returns:
+//
+//	The first matched track.
+//	ErrTrackNotFound, if no track was found, an error if the query fails.
+func FindTrack(ctx stdcontext.Context, store store.Store[models.TrackInfo], id string, includeDeleted bool) (*models.TrackInfo, error) {
 	filter := query.Filter{
 		Root: query.FilterOperatorEq{
 			Key:   "_id",
@@ -325,13 +512,17 @@ func FindTrackByID(store *store.MongoStore[models.TrackInfo], id string) (*model
 		Limit: 1,
 	}
 
-	items, err := store.FindItems(&filter)
+	if !includeDeleted {
+		filter.Root = gettracks.ExcludeDeleted(filter.Root)
+	}
+
+	items, err := store.FindItems(ctx, &filter)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(items) == 0 {
-		return nil, fmt.Errorf("update: track not found")
+		return nil, ErrTrackNotFound
 	}
 
 	return &items[0], nil
@@ -339,33 +530,60 @@ func FindTrackByID(store *store.MongoStore[models.TrackInfo], id string) (*model
 
 // Description:
 //
-//	Checks whether the given artist id exists in the mongo store.
+//	Checks whether the given artist id exists in the artist store.
+//	Reads the artist within the transaction, see artists.Exists.
 //
 // Parameters:
 //
-//	store 		The mongo store to search.
+//	ctx 		The transaction context.
+//	store 		The artist store to search.
 //	artistID 	The artist id to search.
 //
 // Returns:
 //
 //	An error, if the artist could not be found or an error,
 //	if the database request failed, nothing if successful.
-func CheckIfArtistExists(store *store.MongoStore[models.ArtistInfo], artistID string) error {
-	filter := query.Filter{
-		Root: query.FilterOperatorEq{
-			Key:   "_id",
-			Value: artistID,
-		},
-		Limit: 1,
+func CheckIfArtistExists(ctx stdcontext.Context, store store.Store[models.ArtistInfo], artistID string) error {
+	err := artists.Exists(ctx, store, artistID)
+	if errors.Is(err, artists.ErrArtistNotFound) {
+		return ErrArtistNotFound
 	}
 
-	items, err := store.FindItems(&filter)
-	if err != nil {
-		return err
+	return err
+}
+
+// Description:
+//
+//	Checks whether the artist and all featured artists exist in the artist store.
+//
+// Parameters:
+//
+//	ctx 				The transaction context.
+//	store 				The artist store to search.
+//	artistID 			The artist id to search. Not checked if empty.
+//	featuredArtistIDs 	The featured artist ids to search.
+//
+// Returns:
+//
+//	ErrArtistNotFound or ErrFeaturedArtistNotFound, if an artist could not be found,
+//	an error, if the database request failed, nothing if successful.
+func CheckArtistReferences(ctx stdcontext.Context, store store.Store[models.ArtistInfo], artistID string, featuredArtistIDs []string) error {
+	if artistID != "" {
+		err := CheckIfArtistExists(ctx, store, artistID)
+		if err != nil {
+			return err
+		}
 	}
 
-	if len(items) == 0 {
-		return fmt.Errorf("artist not found")
+	for _, featuredArtist := range featuredArtistIDs {
+		err := CheckIfArtistExists(ctx, store, featuredArtist)
+		if errors.Is(err, ErrArtistNotFound) {
+			return ErrFeaturedArtistNotFound
+		}
+
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -373,29 +591,125 @@ func CheckIfArtistExists(store *store.MongoStore[models.ArtistInfo], artistID st
 
 // Description:
 //
-//	The router handler for track creation.
+//	A change to a single track.
+type TrackChange struct {
+
+	// The id of the track to change.
+	ID string
+
+	// The stamped update to apply. The track is left untouched if the update has no root.
+	Update query.Update
+
+	// The artist referenced by the change. Empty if the artist is not changed.
+	ArtistID string
+
+	// The featured artists referenced by the change.
+	FeaturedArtistIDs []string
+
+	// The precondition of the request, nil if the request is not conditional.
+	Precondition *api.Precondition
+
+	// The operation recorded in the revision of the change.
+	Operation models.RevisionOperation
+}
+
+// Description:
+//
+//	Applies a change to a track and records its revision, within a single transaction.
+//	The precondition and the artist references are verified before the update.
+//	Conditional changes update the track in the compared version only.
 //
 // Parameters:
 //
-//	request The incoming request.
-//	object 	The injector. Contains injected dependencies.
+//	ctx 		The context of the operation.
+//	injector 	The injector, providing the stores and the transactor.
+//	change 		The change to apply.
 //
 // Returns:
 //
-//	An API response object.
-func Handler(request *api.APIRequest, object interface{}) *api.APIResponse {
-	context := parallel.NewContext()
+//	The track after the change and the number of modified tracks.
+//	ErrTrackNotFound, ErrPreconditionFailed, ErrArtistNotFound or ErrFeaturedArtistNotFound,
+//	if the change cannot be applied, an error, if the database request failed.
+func UpdateTrack(ctx stdcontext.Context, injector *inject.Injector, change TrackChange) (*models.TrackInfo, int64, error) {
+	trackStore := injector.TrackStore
+	count := int64(0)
 
-	log.Infof("[%s] %s: %s", context.ID, request.Method, request.Path)
-	log.Tracef("[%s] request: %s", context.ID, marshal.Quick(request))
+	var updatedTrack *models.TrackInfo
 
-	injector, err := GetSafeInjector(object)
-	if err != nil {
-		log.Warnf("[%s] failed to get endpoint injector: %s", context.ID, err)
-		return &api.APIResponse{
-			StatusCode: http.StatusInternalServerError,
+	err := injector.Transactor.WithTransaction(ctx, func(tx stdcontext.Context) error {
+		track, err := FindTrackByID(tx, trackStore, change.ID)
+		if err != nil {
+			return err
+		}
+
+		etag, err := api.ComputeETag(track)
+		if err != nil {
+			return err
+		}
+
+		if change.Precondition != nil && !change.Precondition.Matches(etag) {
+			return ErrPreconditionFailed
+		}
+
+		err = CheckArtistReferences(tx, injector.ArtistStore, change.ArtistID, change.FeaturedArtistIDs)
+		if err != nil {
+			return err
+		}
+
+		updatedTrack = track
+		if change.Update.Root == nil {
+			return nil
+		}
+
+		updateFilter := query.Filter{
+			Root: query.FilterOperatorEq{
+				Key:   "_id",
+				Value: change.ID,
+			},
+		}
+
+		if change.Precondition != nil {
+			updateFilter.Root = CreateVersionFilter(change.ID, track.Version)
+		}
+
+		count, err = trackStore.UpdateItem(tx, &updateFilter, &change.Update)
+		if err != nil {
+			return err
+		}
+
+		if count == 0 && change.Precondition != nil {
+			return ErrPreconditionFailed
+		}
+
+		updatedTrack, err = FindTrackByID(tx, trackStore, change.ID)
+		if err != nil {
+			return err
+		}
+
+		if count == 0 {
+			return nil
 		}
-	}
+
+		return revisions.Record(tx, injector.RevisionStore, change.Operation, track, *updatedTrack)
+	})
+
+	return updatedTrack, count, err
+}
+
+// Description:
+//
+//	The router handler for track creation.
+//
+// Parameters:
+//
+//	request 	The incoming request.
+//	injector 	The injector. Contains injected dependencies.
+//
+// Returns:
+//
+//	An API response object, or an error which is answered with an error status.
+func Handler(request *api.APIRequest, injector *inject.Injector) (*api.APIResponse, error) {
+	context := parallel.FromContext(request.Context)
 
 	id, validationErr := GetAndValidateID(request)
 	if validationErr != nil {
@@ -403,18 +717,18 @@ func Handler(request *api.APIRequest, object interface{}) *api.APIResponse {
 		return &api.APIResponse{
 			StatusCode: http.StatusBadRequest,
 			Body:       validationErr,
-		}
+		}, nil
 	}
 
-	trackStore := store.NewMongoStore[models.TrackInfo](injector.MongoInstance, "gostream", "tracks")
-	artistStore := store.NewMongoStore[models.ArtistInfo](injector.MongoInstance, "gostream", "artists")
-
-	trackInfo, err := FindTrackByID(trackStore, id)
+	precondition, err := api.ParseIfMatch(request)
 	if err != nil {
-		log.Warnf("[%s] could not find track: %s", context.ID, err)
+		log.Warnf("[%s] failed to parse precondition: %s", context.ID, err)
 		return &api.APIResponse{
-			StatusCode: http.StatusNotFound,
-		}
+			StatusCode: http.StatusBadRequest,
+			Body: UpdateTrackErrorResponseBody{
+				Message: "invalid If-Match header",
+			},
+		}, nil
 	}
 
 	requestBody, err := ExtractRequestBody(request)
@@ -425,7 +739,7 @@ func Handler(request *api.APIRequest, object interface{}) *api.APIResponse {
 			Body: UpdateTrackErrorResponseBody{
 				Message: "invalid request body",
 			},
-		}
+		}, nil
 	}
 
 	validationError := ValidateRequestBody(requestBody)
@@ -434,156 +748,80 @@ func Handler(request *api.APIRequest, object interface{}) *api.APIResponse {
 		return &api.APIResponse{
 			StatusCode: http.StatusBadRequest,
 			Body:       validationError,
-		}
-	}
-
-	if requestBody.ArtistID != "" {
-		err = CheckIfArtistExists(artistStore, requestBody.ArtistID)
-		if err != nil {
-			log.Warnf("[%s] artist does not exist: %s", context.ID, err)
-			return &api.APIResponse{
-				StatusCode: http.StatusBadRequest,
-				Body: UpdateTrackErrorResponseBody{
-					Message: "artist does not exist",
-				},
-			}
-		}
-	}
-
-	if len(requestBody.FeaturedArtistIDs) > 0 {
-		for _, featuredArtist := range requestBody.FeaturedArtistIDs {
-			err = CheckIfArtistExists(artistStore, featuredArtist)
-			if err != nil {
-				log.Warnf("[%s] featured artist does not exist: %s", context.ID, err)
-				return &api.APIResponse{
-					StatusCode: http.StatusBadRequest,
-					Body: UpdateTrackErrorResponseBody{
-						Message: "featured artist does not exist",
-					},
-				}
-			}
-		}
-	}
-
-	if requestBody.ArtistID != "" {
-		trackInfo.ArtistID = requestBody.ArtistID
+		}, nil
 	}
 
-	if len(requestBody.FeaturedArtistIDs) > 0 {
-		trackInfo.FeaturedArtistIDs = requestBody.FeaturedArtistIDs
+	change := TrackChange{
+		ID:                id,
+		Update:            StampUpdate(CreateUpdateFromRequestBody(requestBody), clock.Timestamp(injector.Clock), api.GetPrincipal(request)),
+		ArtistID:          strings.TrimSpace(requestBody.ArtistID),
+		FeaturedArtistIDs: trimAll(requestBody.FeaturedArtistIDs),
+		Precondition:      precondition,
+		Operation:         models.RevisionOperationUpdate,
 	}
 
-	if requestBody.Title != "" {
-		trackInfo.Title = requestBody.Title
-	}
-
-	if requestBody.Label != "" {
-		trackInfo.Label = requestBody.Label
-	}
-
-	if requestBody.ReleaseDate != "" {
-		releaseDate, _ := time.Parse("2006-01-02", requestBody.ReleaseDate)
-		trackInfo.ReleaseDate = releaseDate
-	}
-
-	if requestBody.TrackStats.Streams != 0 {
-		trackInfo.TrackStats.Streams = requestBody.TrackStats.Streams
-	}
-
-	if requestBody.TrackStats.Likes != 0 {
-		trackInfo.TrackStats.Likes = requestBody.TrackStats.Likes
-	}
-
-	if requestBody.AudioFeatures.Key != "" {
-		trackInfo.AudioFeatures.Key = requestBody.AudioFeatures.Key
-	}
-
-	if requestBody.AudioFeatures.Tempo != 0 {
-		trackInfo.AudioFeatures.Tempo = requestBody.AudioFeatures.Tempo
-	}
-
-	if requestBody.AudioFeatures.Duration != 0 {
-		trackInfo.AudioFeatures.Duration = requestBody.AudioFeatures.Duration
-	}
-
-	if requestBody.AudioFeatures.Energy != 0 {
-		trackInfo.AudioFeatures.Energy = requestBody.AudioFeatures.Energy
-	}
-
-	if requestBody.AudioFeatures.Danceability != 0 {
-		trackInfo.AudioFeatures.Danceability = requestBody.AudioFeatures.Danceability
-	}
-
-	if requestBody.AudioFeatures.Accousticness != 0 {
-		trackInfo.AudioFeatures.Accousticness = requestBody.AudioFeatures.Accousticness
-	}
+	log.Tracef("[%s] attempting to update database item ...", context.ID)
+	updatedTrack, count, err := UpdateTrack(request.Context, injector, change)
 
-	if requestBody.AudioFeatures.Instrumentalness != 0 {
-		trackInfo.AudioFeatures.Instrumentalness = requestBody.AudioFeatures.Instrumentalness
+	if errors.Is(err, ErrTrackNotFound) {
+		log.Warnf("[%s] could not find track: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusNotFound,
+		}, nil
 	}
 
-	if requestBody.AudioFeatures.Liveness != 0 {
-		trackInfo.AudioFeatures.Liveness = requestBody.AudioFeatures.Liveness
+	if errors.Is(err, ErrPreconditionFailed) {
+		log.Warnf("[%s] track does not match the precondition: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusPreconditionFailed,
+		}, nil
 	}
 
-	if requestBody.AudioFeatures.Loudness != 0 {
-		trackInfo.AudioFeatures.Instrumentalness = requestBody.AudioFeatures.Loudness
+	if errors.Is(err, ErrArtistNotFound) {
+		log.Warnf("[%s] artist does not exist: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body: UpdateTrackErrorResponseBody{
+				Message: "artist does not exist",
+			},
+		}, nil
 	}
 
-	if requestBody.AudioFeatures.TimeSignature != 0 {
-		trackInfo.AudioFeatures.TimeSignature = requestBody.AudioFeatures.TimeSignature
+	if errors.Is(err, ErrFeaturedArtistNotFound) {
+		log.Warnf("[%s] featured artist does not exist: %s", context.ID, err)
+		return &api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body: UpdateTrackErrorResponseBody{
+				Message: "featured artist does not exist",
+			},
+		}, nil
 	}
 
-	updateFilter := query.Filter{
-		Root: query.FilterOperatorEq{
-			Key:   "_id",
-			Value: id,
-		},
+	if err != nil {
+		return nil, fmt.Errorf("failed to update database item: %w", err)
 	}
 
-	updateOperator := query.Update{
-		Root: query.UpdateOperatorSet{
-			Set: map[string]interface{}{
-				"artistId":                       trackInfo.ArtistID,
-				"featuredArtistIds":              trackInfo.FeaturedArtistIDs,
-				"title":                          trackInfo.Title,
-				"label":                          trackInfo.Label,
-				"releaseDate":                    trackInfo.ReleaseDate,
-				"trackStats.streams":             trackInfo.TrackStats.Streams,
-				"trackStats.likes":               trackInfo.TrackStats.Likes,
-				"audioFeatures.key":              trackInfo.AudioFeatures.Key,
-				"audioFeatures.tempo":            trackInfo.AudioFeatures.Tempo,
-				"audioFeatures.duration":         trackInfo.AudioFeatures.Duration,
-				"audioFeatures.energy":           trackInfo.AudioFeatures.Energy,
-				"audioFeatures.danceability":     trackInfo.AudioFeatures.Danceability,
-				"audioFeatures.accousticness":    trackInfo.AudioFeatures.Accousticness,
-				"audioFeatures.instrumentalness": trackInfo.AudioFeatures.Instrumentalness,
-				"audioFeatures.liveness":         trackInfo.AudioFeatures.Liveness,
-				"audioFeatures.loudness":         trackInfo.AudioFeatures.Loudness,
-				"audioFeatures.timeSignature":    trackInfo.AudioFeatures.TimeSignature,
-			},
-		},
+	etag, err := api.ComputeETag(updatedTrack)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute entity tag: %w", err)
 	}
 
-	log.Tracef("[%s] attempting to update database item ...", context.ID)
-	count, err := trackStore.UpdateItem(&updateFilter, &updateOperator)
-
-	if err != nil {
-		log.Errorf("[%s] failed to update database item: %s", context.ID, err)
-		return &api.APIResponse{
-			StatusCode: http.StatusInternalServerError,
-		}
+	headers := map[string]string{
+		api.ETagHeader:         etag,
+		api.LastModifiedHeader: updatedTrack.UpdatedAt.UTC().Format(http.TimeFormat),
 	}
 
 	if count == 0 {
 		log.Warnf("[%s] zero modified items", context.ID)
 		return &api.APIResponse{
 			StatusCode: http.StatusNoContent,
-		}
+			Headers:    headers,
+		}, nil
 	}
 
 	log.Tracef("[%s] successfully completed request", context.ID)
 	return &api.APIResponse{
 		StatusCode: http.StatusNoContent,
-	}
+		Headers:    headers,
+	}, nil
 }
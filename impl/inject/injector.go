@@ -1,6 +1,49 @@
 package inject
 
-import "github.com/gostream-official/tracks/pkg/store"
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gostream-official/tracks/impl/config"
+	"github.com/gostream-official/tracks/impl/models"
+	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/clock"
+	"github.com/gostream-official/tracks/pkg/health"
+	"github.com/gostream-official/tracks/pkg/idgen"
+	"github.com/gostream-official/tracks/pkg/metrics"
+	"github.com/gostream-official/tracks/pkg/store"
+	"github.com/gostream-official/tracks/pkg/store/query"
+)
+
+const (
+
+	// The default duration deleted tracks are kept in the trash.
+	DefaultTrashRetention = 30 * 24 * time.Hour
+
+	// The default maximum duration of a single health check.
+	DefaultHealthCheckTimeout = 2 * time.Second
+)
+
+// Description:
+//
+//	The indexes of the track store.
+//	Support filtering and sorting tracks by their audit fields and purging the trash.
+var TrackIndexes = []store.Index{
+	{Name: "createdAt", Keys: []query.SortKey{{Key: "createdAt", Order: query.SortAscending}}},
+	{Name: "createdBy", Keys: []query.SortKey{{Key: "createdBy", Order: query.SortAscending}}},
+	{Name: "updatedAt", Keys: []query.SortKey{{Key: "updatedAt", Order: query.SortAscending}}},
+	{Name: "updatedBy", Keys: []query.SortKey{{Key: "updatedBy", Order: query.SortAscending}}},
+	{Name: "deletedAt", Keys: []query.SortKey{{Key: "deletedAt", Order: query.SortAscending}}},
+}
+
+// Description:
+//
+//	The indexes of the track revision store.
+//	Support listing the revisions of a track in order.
+var RevisionIndexes = []store.Index{
+	{Name: "trackId_revision", Keys: []query.SortKey{{Key: "trackId", Order: query.SortAscending}, {Key: "revision", Order: query.SortAscending}}},
+}
 
 // Description:
 //
@@ -8,6 +51,208 @@ import "github.com/gostream-official/tracks/pkg/store"
 //	This object is used for endpoint dependency injection.
 type Injector struct {
 
-	// The MongoDB store instance.
-	MongoInstance *store.MongoInstance
+	// The track store.
+	TrackStore store.Store[models.TrackInfo]
+
+	// The artist store.
+	ArtistStore store.Store[models.ArtistInfo]
+
+	// The track revision store.
+	RevisionStore store.Store[models.TrackRevision]
+
+	// The transactor used for atomic operations spanning the stores.
+	Transactor store.Transactor
+
+	// The clock used for time-dependent operations.
+	Clock clock.Clock
+
+	// The generator used for new identifiers.
+	IDGenerator idgen.Generator
+
+	// The duration deleted tracks are kept in the trash before they can be purged.
+	TrashRetention time.Duration
+
+	// The principals allowed to use administrative endpoints, e.g. purging the trash.
+	AdminPrincipals []string
+
+	// The health checks of the service's dependencies.
+	Health *health.Registry
+
+	// The metrics of the service.
+	Metrics *metrics.Registry
+}
+
+// Description:
+//
+//	Creates an injector backed by MongoDB.
+//
+// Parameters:
+//
+//	instance 	The MongoDB instance.
+//	config 		The MongoDB configuration, providing database and collection names.
+//
+// Returns:
+//
+//	The created injector.
+func NewMongoInjector(instance *store.MongoInstance, config config.MongoConfig) *Injector {
+	registry := health.NewRegistry(DefaultHealthCheckTimeout)
+	registry.Register(health.NewChecker("mongo", instance.Ping))
+
+	metricsRegistry := newMetricsRegistry()
+	observer := newStoreObserver(metricsRegistry)
+
+	trackStore := store.NewMongoStore[models.TrackInfo](instance, config.Database, config.TracksCollection)
+	artistStore := store.NewMongoStore[models.ArtistInfo](instance, config.Database, config.ArtistsCollection)
+	revisionStore := store.NewMongoStore[models.TrackRevision](instance, config.Database, config.RevisionsCollection)
+
+	return &Injector{
+		TrackStore:     store.NewInstrumentedStore[models.TrackInfo](trackStore, config.TracksCollection, observer),
+		ArtistStore:    store.NewInstrumentedStore[models.ArtistInfo](artistStore, config.ArtistsCollection, observer),
+		RevisionStore:  store.NewInstrumentedStore[models.TrackRevision](revisionStore, config.RevisionsCollection, observer),
+		Transactor:     instance,
+		Clock:          clock.NewSystemClock(),
+		IDGenerator:    idgen.NewUUIDGenerator(),
+		TrashRetention: DefaultTrashRetention,
+		Health:         registry,
+		Metrics:        metricsRegistry,
+	}
+}
+
+// Description:
+//
+//	Creates an injector backed by the in-memory store.
+//
+// Parameters:
+//
+//	instance 	The in-memory instance.
+//	config 		The MongoDB configuration, providing database and collection names.
+//
+// Returns:
+//
+//	The created injector.
+func NewMemoryInjector(instance *store.MemoryInstance, config config.MongoConfig) *Injector {
+	return &Injector{
+		TrackStore:     store.NewMemoryStore[models.TrackInfo](instance, config.Database, config.TracksCollection),
+		ArtistStore:    store.NewMemoryStore[models.ArtistInfo](instance, config.Database, config.ArtistsCollection),
+		RevisionStore:  store.NewMemoryStore[models.TrackRevision](instance, config.Database, config.RevisionsCollection),
+		Transactor:     instance,
+		Clock:          clock.NewSystemClock(),
+		IDGenerator:    idgen.NewUUIDGenerator(),
+		TrashRetention: DefaultTrashRetention,
+		Health:         health.NewRegistry(DefaultHealthCheckTimeout),
+		Metrics:        newMetricsRegistry(),
+	}
+}
+
+// Description:
+//
+//	Creates the indexes of the injected stores, unless they already exist.
+//
+// Parameters:
+//
+//	ctx The context of the operation.
+//
+// Returns:
+//
+//	An error if an index cannot be created.
+func (injector *Injector) EnsureIndexes(ctx context.Context) error {
+	err := injector.TrackStore.EnsureIndexes(ctx, TrackIndexes)
+	if err != nil {
+		return err
+	}
+
+	return injector.RevisionStore.EnsureIndexes(ctx, RevisionIndexes)
+}
+
+// Description:
+//
+//	Creates the metrics registry of an injector, reporting the Go runtime metrics.
+//
+// Returns:
+//
+//	The created registry.
+func newMetricsRegistry() *metrics.Registry {
+	registry := metrics.NewRegistry()
+	registry.Register(metrics.NewRuntimeCollector())
+
+	return registry
+}
+
+// Description:
+//
+//	Creates a store observer recording the number, the errors and the duration of store operations.
+//
+// Parameters:
+//
+//	registry The metrics registry to register the store metrics with.
+//
+// Returns:
+//
+//	The store observer.
+func newStoreObserver(registry *metrics.Registry) store.OperationObserver {
+	operations := metrics.NewCounterVec("store_operations_total", "Number of store operations.", "collection", "operation", "result")
+	durations := metrics.NewHistogramVec("store_operation_duration_seconds", "Duration of store operations, in seconds.", nil, "collection", "operation")
+
+	registry.Register(operations, durations)
+
+	return func(collection string, operation string, duration time.Duration, err error) {
+		result := "success"
+		if err != nil {
+			result = "error"
+		}
+
+		operations.Inc(collection, operation, result)
+		durations.Observe(duration.Seconds(), collection, operation)
+	}
+}
+
+// Description:
+//
+//	Checks whether a principal may use administrative endpoints.
+//	The anonymous principal is never an administrator.
+//
+// Parameters:
+//
+//	principal The authenticated principal.
+//
+// Returns:
+//
+//	True, if the principal is a configured administrator.
+func (injector *Injector) IsAdmin(principal string) bool {
+	if principal == api.AnonymousPrincipal {
+		return false
+	}
+
+	for _, admin := range injector.AdminPrincipals {
+		if admin == principal {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Attempts to cast the input object to the endpoint injector.
+//	If this cast fails, we cannot proceed to process this request.
+//
+// Parameters:
+//
+//	object 	The injector object.
+//
+// Returns:
+//
+//	The injector if the cast is successful, an error otherwise.
+func GetSafeInjector(object interface{}) (*Injector, error) {
+	switch injector := object.(type) {
+	case *Injector:
+		if injector != nil {
+			return injector, nil
+		}
+	case Injector:
+		return &injector, nil
+	}
+
+	return nil, fmt.Errorf("inject: failed to deduce injector")
 }
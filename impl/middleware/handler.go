@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gostream-official/tracks/impl/inject"
+	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/parallel"
+	"github.com/gostream-official/tracks/pkg/router"
+	"github.com/revx-official/output/log"
+)
+
+// Description:
+//
+//	Function definition for endpoint handlers.
+//	Handlers receive the endpoint injector and answer client errors with a response themselves.
+//	All other failures are returned as error and answered by the handler adapter.
+type HandlerFunc = func(request *api.APIRequest, injector *inject.Injector) (*api.APIResponse, error)
+
+// Description:
+//
+//	Adapts an endpoint handler to the router.
+//	Casts the injected object to the endpoint injector and answers with an internal server error if this fails.
+//	Errors returned by the handler are logged and mapped to their status code with api.ErrorStatusCode,
+//	so deadline errors result in a gateway timeout and cancellation errors in a client closed request status.
+//
+// Parameters:
+//
+//	handler The handler to adapt.
+//
+// Returns:
+//
+//	The router handler.
+func Handle(handler HandlerFunc) router.RouterInjectionHandlerFunc {
+	return func(request *api.APIRequest, object interface{}) *api.APIResponse {
+		context := parallel.FromContext(request.Context)
+
+		injector, err := inject.GetSafeInjector(object)
+		if err != nil {
+			log.Errorf("[%s] failed to get endpoint injector: %s", context.ID, err)
+			return &api.APIResponse{
+				StatusCode: http.StatusInternalServerError,
+			}
+		}
+
+		response, err := handler(request, injector)
+		if err != nil {
+			log.Errorf("[%s] %s", context.ID, err)
+			return &api.APIResponse{
+				StatusCode: api.ErrorStatusCode(err),
+			}
+		}
+
+		return response
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/marshal"
+	"github.com/gostream-official/tracks/pkg/parallel"
+	"github.com/gostream-official/tracks/pkg/router"
+	"github.com/revx-official/output/log"
+)
+
+// Description:
+//
+//	Middleware logging incoming requests.
+//	Logs the method and path of each request, and the full request on trace level.
+//
+// Parameters:
+//
+//	next The handler to wrap.
+//
+// Returns:
+//
+//	The wrapped handler.
+func Logging(next router.RouterInjectionHandlerFunc) router.RouterInjectionHandlerFunc {
+	return func(request *api.APIRequest, injector interface{}) *api.APIResponse {
+		context := parallel.FromContext(request.Context)
+
+		log.Infof("[%s] %s: %s", context.ID, request.Method, request.Path)
+		log.Tracef("[%s] request: %s", context.ID, marshal.Quick(request))
+
+		return next(request, injector)
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/metrics"
+	"github.com/gostream-official/tracks/pkg/router"
+)
+
+// Description:
+//
+//	Creates a middleware recording the number and the duration of requests.
+//	Requests are labelled by method, route template and status code, so path parameters do not inflate the number of series.
+//	Panicking handlers are recorded with status 500.
+//
+// Parameters:
+//
+//	registry The metrics registry to register the request metrics with.
+//
+// Returns:
+//
+//	The middleware.
+func Metrics(registry *metrics.Registry) router.Middleware {
+	requests := metrics.NewCounterVec("http_requests_total", "Number of handled HTTP requests.", "method", "route", "status")
+	durations := metrics.NewHistogramVec("http_request_duration_seconds", "Duration of handled HTTP requests, in seconds.", nil, "method", "route", "status")
+
+	registry.Register(requests, durations)
+
+	return func(next router.RouterInjectionHandlerFunc) router.RouterInjectionHandlerFunc {
+		return func(request *api.APIRequest, injector interface{}) *api.APIResponse {
+			start := time.Now()
+			status := http.StatusInternalServerError
+
+			defer func() {
+				code := strconv.Itoa(status)
+
+				requests.Inc(request.Method, request.Route, code)
+				durations.Observe(time.Since(start).Seconds(), request.Method, request.Route, code)
+			}()
+
+			response := next(request, injector)
+			status = response.StatusCode
+
+			return response
+		}
+	}
+}
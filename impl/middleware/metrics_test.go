@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/metrics"
+	"github.com/gostream-official/tracks/pkg/router"
+)
+
+func TestMetricsLabelsRequestsByRouteTemplate(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	engine := router.NewGinRouter()
+	engine.Use(Metrics(registry))
+	engine.Handle("GET", "/tracks/:id", func(request *api.APIRequest) *api.APIResponse {
+		return &api.APIResponse{
+			StatusCode: http.StatusNotFound,
+		}
+	})
+
+	for _, id := range []string{"a", "b"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/tracks/"+id, nil))
+	}
+
+	var buffer bytes.Buffer
+
+	err := registry.WriteText(&buffer)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	text := buffer.String()
+
+	expected := `http_requests_total{method="GET",route="/tracks/:id",status="404"} 2`
+	if !strings.Contains(text, expected) {
+		t.Errorf("expected '%s' in:\n%s", expected, text)
+	}
+
+	if strings.Contains(text, `route="/tracks/a"`) || strings.Contains(text, `route="/tracks/b"`) {
+		t.Errorf("expected no raw paths as route in:\n%s", text)
+	}
+}
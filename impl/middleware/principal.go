@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/router"
+)
+
+// Description:
+//
+//	Middleware attaching the authenticated principal to the request context.
+//	The principal is taken from the principal header, which is trusted as is:
+//	the authenticating gateway in front of the service must set it, or strip it from client requests.
+//	Requests without the header keep the anonymous principal.
+//
+// Parameters:
+//
+//	next The handler to wrap.
+//
+// Returns:
+//
+//	The wrapped handler.
+func Principal(next router.RouterInjectionHandlerFunc) router.RouterInjectionHandlerFunc {
+	return func(request *api.APIRequest, injector interface{}) *api.APIResponse {
+		principal := strings.TrimSpace(request.Headers[api.PrincipalHeader])
+		if len(principal) == 0 {
+			return next(request, injector)
+		}
+
+		authenticated := *request
+		authenticated.Context = api.WithPrincipal(request.Context, principal)
+
+		return next(&authenticated, injector)
+	}
+}
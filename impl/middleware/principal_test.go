@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gostream-official/tracks/pkg/api"
+)
+
+func TestPrincipalAttachesHeaderToContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		expected string
+	}{
+		{name: "header", headers: map[string]string{api.PrincipalHeader: " alice "}, expected: "alice"},
+		{name: "blank header", headers: map[string]string{api.PrincipalHeader: " "}, expected: api.AnonymousPrincipal},
+		{name: "no header", headers: map[string]string{}, expected: api.AnonymousPrincipal},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var principal string
+
+			handler := Principal(func(request *api.APIRequest, injector interface{}) *api.APIResponse {
+				principal = api.GetPrincipal(request)
+				return &api.APIResponse{}
+			})
+
+			handler(&api.APIRequest{Context: context.Background(), Headers: test.headers}, nil)
+
+			if principal != test.expected {
+				t.Errorf("expected principal '%s', received '%s'", test.expected, principal)
+			}
+		})
+	}
+}
+
+func TestGetPrincipalIgnoresHeaderWithoutMiddleware(t *testing.T) {
+	request := &api.APIRequest{
+		Context: context.Background(),
+		Headers: map[string]string{api.PrincipalHeader: "mallory"},
+	}
+
+	if principal := api.GetPrincipal(request); principal != api.AnonymousPrincipal {
+		t.Errorf("expected the anonymous principal, received '%s'", principal)
+	}
+}
@@ -1,6 +1,14 @@
 package models
 
+// Description:
+//
+//	The data model definition for an artist.
+//	This is a direct reference to the database data model.
 type ArtistInfo struct {
-	ID   string
-	Name string
+
+	// The id of the artist (primary key).
+	ID string `json:"id" bson:"_id"`
+
+	// The name of the artist.
+	Name string `json:"name" bson:"name"`
 }
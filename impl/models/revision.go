@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gostream-official/tracks/pkg/diff"
+)
+
+// Description:
+//
+//	The operation which produced a track revision.
+type RevisionOperation string
+
+const (
+
+	// The track was created.
+	RevisionOperationCreate RevisionOperation = "create"
+
+	// The track was updated.
+	RevisionOperationUpdate RevisionOperation = "update"
+
+	// The track was moved to the trash.
+	RevisionOperationDelete RevisionOperation = "delete"
+
+	// The track was restored from the trash.
+	RevisionOperationRestore RevisionOperation = "restore"
+
+	// The track was reverted to a previous revision.
+	RevisionOperationRevert RevisionOperation = "revert"
+
+	// The track was permanently removed from the trash.
+	// The revision outlives the track, as record of the removal.
+	RevisionOperationPurge RevisionOperation = "purge"
+)
+
+// Description:
+//
+//	The data model definition for a track revision.
+//	Revisions are immutable and record every write to a track.
+//	This is a direct reference to the database data model.
+type TrackRevision struct {
+
+	// The id of the revision (primary key), composed of the track id and the revision number.
+	ID string `json:"id" bson:"_id"`
+
+	// The id of the revised track.
+	TrackID string `json:"trackId" bson:"trackId"`
+
+	// The revision number. Equals the version of the track after the write.
+	Revision int64 `json:"revision" bson:"revision"`
+
+	// The operation which produced the revision.
+	Operation RevisionOperation `json:"operation" bson:"operation"`
+
+	// The full track after the write.
+	Snapshot TrackInfo `json:"snapshot" bson:"snapshot"`
+
+	// The changed fields, compared to the previous revision.
+	Changes []diff.Change `json:"changes" bson:"changes"`
+
+	// The date of the write.
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+
+	// The principal who wrote to the track.
+	CreatedBy string `json:"createdBy" bson:"createdBy"`
+}
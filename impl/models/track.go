@@ -31,6 +31,30 @@ type TrackInfo struct {
 
 	// Some audio features of the track.
 	AudioFeatures AudioFeatures `json:"audioFeatures" bson:"audioFeatures"`
+
+	// The version of the track, starting at 1 and incremented on each write.
+	// Used for optimistic concurrency control.
+	Version int64 `json:"version" bson:"version"`
+
+	// The creation date of the track.
+	// Maintained by the service, read-only to clients.
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+
+	// The principal who created the track.
+	// Maintained by the service, read-only to clients.
+	CreatedBy string `json:"createdBy" bson:"createdBy"`
+
+	// The date of the last write to the track.
+	// Maintained by the service, read-only to clients.
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+
+	// The principal who last wrote to the track.
+	// Maintained by the service, read-only to clients.
+	UpdatedBy string `json:"updatedBy" bson:"updatedBy"`
+
+	// The date the track was moved to the trash. Nil if the track is not deleted.
+	// Deleted tracks are hidden from reads and purged after the trash retention period.
+	DeletedAt *time.Time `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
 }
 
 // Description:
@@ -0,0 +1,91 @@
+package revisions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gostream-official/tracks/impl/models"
+	"github.com/gostream-official/tracks/pkg/diff"
+	"github.com/gostream-official/tracks/pkg/store"
+)
+
+// Description:
+//
+//	Creates the id of a revision.
+//	Revisions of the same track with the same number share an id,
+//	so concurrent writes recording the same revision conflict.
+//
+// Parameters:
+//
+//	trackID 	The id of the revised track.
+//	revision 	The revision number.
+//
+// Returns:
+//
+//	The revision id.
+func CreateRevisionID(trackID string, revision int64) string {
+	return fmt.Sprintf("%s:%d", trackID, revision)
+}
+
+// Description:
+//
+//	Creates the revision of a write to a track.
+//	The revision number, date and principal are taken from the written track.
+//
+// Parameters:
+//
+//	operation 	The operation of the write.
+//	previous 	The track before the write. Nil if the track was created.
+//	current 	The track after the write.
+//
+// Returns:
+//
+//	The created revision.
+//	An error if the changes cannot be computed.
+func NewRevision(operation models.RevisionOperation, previous *models.TrackInfo, current models.TrackInfo) (models.TrackRevision, error) {
+	var from interface{}
+	if previous != nil {
+		from = *previous
+	}
+
+	changes, err := diff.Compare(from, current)
+	if err != nil {
+		return models.TrackRevision{}, err
+	}
+
+	return models.TrackRevision{
+		ID:        CreateRevisionID(current.ID, current.Version),
+		TrackID:   current.ID,
+		Revision:  current.Version,
+		Operation: operation,
+		Snapshot:  current,
+		Changes:   changes,
+		CreatedAt: current.UpdatedAt,
+		CreatedBy: current.UpdatedBy,
+	}, nil
+}
+
+// Description:
+//
+//	Records the revision of a write to a track.
+//	Should run within the transaction of the write.
+//
+// Parameters:
+//
+//	ctx 		The context of the operation.
+//	store 		The revision store.
+//	operation 	The operation of the write.
+//	previous 	The track before the write. Nil if the track was created.
+//	current 	The track after the write.
+//
+// Returns:
+//
+//	An error if the revision cannot be created or stored.
+func Record(ctx context.Context, store store.Store[models.TrackRevision], operation models.RevisionOperation, previous *models.TrackInfo, current models.TrackInfo) error {
+	revision, err := NewRevision(operation, previous, current)
+	if err != nil {
+		return err
+	}
+
+	return store.CreateItem(ctx, revision)
+}
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+
+	// The response header carrying the entity tag of the returned representation.
+	ETagHeader = "ETag"
+
+	// The response header carrying the modification date of the returned representation.
+	LastModifiedHeader = "Last-Modified"
+
+	// The request header carrying the entity tags a conditional write is limited to.
+	IfMatchHeader = "If-Match"
+
+	// The request header carrying the entity tags of representations the client already holds.
+	IfNoneMatchHeader = "If-None-Match"
+
+	// The request header carrying the modification date of the representation the client already holds.
+	IfModifiedSinceHeader = "If-Modified-Since"
+)
+
+// Description:
+//
+//	The precondition of a conditional request, parsed from the If-Match or If-None-Match header.
+type Precondition struct {
+
+	// Whether any current representation satisfies the precondition ('*').
+	Any bool
+
+	// The entity tags satisfying the precondition, including their quotes.
+	ETags []string
+}
+
+// Description:
+//
+//	Computes the strong entity tag of a representation from the hash of its JSON encoding.
+//
+// Parameters:
+//
+//	body The representation, as returned in the response body.
+//
+// Returns:
+//
+//	The entity tag, e.g. '"3f2a..."'.
+//	An error if the representation cannot be encoded.
+func ComputeETag(body interface{}) (string, error) {
+	bytes, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(bytes)
+	return `"` + hex.EncodeToString(hash[:16]) + `"`, nil
+}
+
+// Description:
+//
+//	Parses the If-Match header of a request.
+//	Weak entity tags never match, since If-Match uses the strong comparison function.
+//
+// Parameters:
+//
+//	request The request.
+//
+// Returns:
+//
+//	The precondition, or nil if the request is not conditional.
+//	An error if the header is malformed.
+func ParseIfMatch(request *APIRequest) (*Precondition, error) {
+	return parsePrecondition(request, IfMatchHeader, false)
+}
+
+// Description:
+//
+//	Parses the If-None-Match header of a request.
+//	Weak entity tags match their strong counterparts, since If-None-Match uses the weak comparison function.
+//
+// Parameters:
+//
+//	request The request.
+//
+// Returns:
+//
+//	The precondition, or nil if the request is not conditional.
+//	An error if the header is malformed.
+func ParseIfNoneMatch(request *APIRequest) (*Precondition, error) {
+	return parsePrecondition(request, IfNoneMatchHeader, true)
+}
+
+// Description:
+//
+//	Checks whether the precondition is satisfied by the current representation of a resource.
+//
+// Parameters:
+//
+//	etag The entity tag of the current representation.
+//
+// Returns:
+//
+//	True, if the precondition is satisfied.
+func (precondition *Precondition) Matches(etag string) bool {
+	if precondition.Any {
+		return true
+	}
+
+	for _, candidate := range precondition.ETags {
+		if candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Creates the response for a conditional GET request.
+//	Sets the ETag and the Last-Modified header and answers with 304 Not Modified,
+//	if the client already holds the current representation.
+//	If-Modified-Since is only evaluated if the request has no If-None-Match header.
+//
+// Parameters:
+//
+//	request 		The request.
+//	body 			The current representation.
+//	lastModified 	The modification date of the representation. Zero if unknown.
+//
+// Returns:
+//
+//	The response, with status 200 OK or 304 Not Modified.
+//	An error if the representation cannot be encoded or the If-None-Match header is malformed.
+func NewConditionalResponse(request *APIRequest, body interface{}, lastModified time.Time) (*APIResponse, error) {
+	etag, err := ComputeETag(body)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{
+		ETagHeader: etag,
+	}
+
+	if !lastModified.IsZero() {
+		headers[LastModifiedHeader] = lastModified.UTC().Format(http.TimeFormat)
+	}
+
+	notModified := &APIResponse{
+		StatusCode: http.StatusNotModified,
+		Headers:    headers,
+	}
+
+	precondition, err := ParseIfNoneMatch(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if precondition != nil && precondition.Matches(etag) {
+		return notModified, nil
+	}
+
+	if precondition == nil && !lastModified.IsZero() {
+		since, err := http.ParseTime(request.Headers[IfModifiedSinceHeader])
+		if err == nil && !lastModified.Truncate(time.Second).After(since) {
+			return notModified, nil
+		}
+	}
+
+	return &APIResponse{
+		StatusCode: http.StatusOK,
+		Headers:    headers,
+		Body:       body,
+	}, nil
+}
+
+// Description:
+//
+//	Parses a header containing a list of entity tags.
+//
+// Parameters:
+//
+//	request The request.
+//	name 	The header name.
+//	weak 	Whether weak entity tags match their strong counterparts.
+//
+// Returns:
+//
+//	The precondition, or nil if the header is not present.
+//	An error if the header is malformed.
+func parsePrecondition(request *APIRequest, name string, weak bool) (*Precondition, error) {
+	header, ok := request.Headers[name]
+	if !ok {
+		return nil, nil
+	}
+
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return &Precondition{Any: true}, nil
+	}
+
+	precondition := &Precondition{
+		ETags: make([]string, 0),
+	}
+
+	for _, part := range strings.Split(header, ",") {
+		tag := strings.TrimSpace(part)
+		isWeak := strings.HasPrefix(tag, "W/")
+		tag = strings.TrimPrefix(tag, "W/")
+
+		if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) || strings.Count(tag, `"`) != 2 {
+			return nil, fmt.Errorf("api: malformed entity tag in %s header: %s", name, part)
+		}
+
+		if isWeak && !weak {
+			continue
+		}
+
+		precondition.ETags = append(precondition.ETags, tag)
+	}
+
+	return precondition, nil
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"strings"
+)
+
+const (
+
+	// The request header carrying the principal authenticated by the upstream gateway.
+	// The gateway must set or strip this header on every request, since the service trusts it as is.
+	PrincipalHeader = "X-Authenticated-User"
+
+	// The principal of requests without an authenticated principal.
+	AnonymousPrincipal = "anonymous"
+)
+
+// Description:
+//
+//	The key type for storing the principal in a standard context.
+type principalKey struct{}
+
+// Description:
+//
+//	Attaches an authenticated principal to a standard context.
+//	Only the authentication middleware should attach principals, handlers read them with GetPrincipal.
+//
+// Parameters:
+//
+//	parent 		The standard context to derive from.
+//	principal 	The authenticated principal.
+//
+// Returns:
+//
+//	The derived standard context carrying the principal.
+func WithPrincipal(parent context.Context, principal string) context.Context {
+	return context.WithValue(parent, principalKey{}, strings.TrimSpace(principal))
+}
+
+// Description:
+//
+//	Gets the authenticated principal of a request.
+//	The principal is read from the request context, where it is attached by the authentication middleware.
+//	The principal header is never read directly, so handlers registered without the middleware
+//	cannot be impersonated by clients sending the header.
+//
+// Parameters:
+//
+//	request The request.
+//
+// Returns:
+//
+//	The principal, or the anonymous principal if the request carries none.
+func GetPrincipal(request *APIRequest) string {
+	if request.Context == nil {
+		return AnonymousPrincipal
+	}
+
+	principal, _ := request.Context.Value(principalKey{}).(string)
+	if len(principal) == 0 {
+		return AnonymousPrincipal
+	}
+
+	return principal
+}
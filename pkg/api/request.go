@@ -1,5 +1,7 @@
 package api
 
+import "context"
+
 // Description:
 //
 //	The representation of a HTTP request.
@@ -11,6 +13,9 @@ type APIRequest struct {
 	// The requested path.
 	Path string
 
+	// The registered route template of the request, e.g. '/tracks/:id'.
+	Route string
+
 	// The request method used.
 
 	Method string
@@ -22,8 +27,16 @@ type APIRequest struct {
 	PathParameters map[string]string `json:"pathParameters"`
 
 	// A key-value mapping of query parameters.
+	// Holds the first value of repeated query parameters.
 	QueryParameters map[string]string `json:"queryParameters"`
 
+	// A mapping of query parameters to all of their values, in order of occurrence.
+	QueryParameterValues map[string][]string `json:"queryParameterValues"`
+
 	// The request body.
 	Body string `json:"body"`
+
+	// The request context.
+	// Carries the parallel context, the request deadline and the cancellation signal.
+	Context context.Context `json:"-"`
 }
@@ -13,4 +13,8 @@ type APIResponse struct {
 
 	// The response body, represented as an object.
 	Body interface{} `json:"body"`
+
+	// The response body, written as is instead of the encoded body, e.g. for non-JSON responses.
+	// The content type is taken from the Content-Type header.
+	RawBody []byte `json:"-"`
 }
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+const (
+
+	// Non-standard status code for requests closed by the client before a response was sent.
+	StatusClientClosedRequest = 499
+)
+
+// Description:
+//
+//	Maps an error to the HTTP status code which should be returned for it.
+//	Deadline errors result in a gateway timeout, cancellation errors result in
+//	a client closed request status and all other errors in an internal server error.
+//
+// Parameters:
+//
+//	err The error to map.
+//
+// Returns:
+//
+//	The HTTP status code.
+func ErrorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest
+	}
+
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,100 @@
+package clock
+
+import "time"
+
+// Description:
+//
+//	The clock interface.
+//	Abstracts the current time, so that time-dependent code can be controlled.
+type Clock interface {
+
+	// Description:
+	//
+	//	Gets the current time.
+	//
+	// Returns:
+	//
+	//	The current time.
+	Now() time.Time
+}
+
+// Description:
+//
+//	A clock returning the current system time in UTC.
+type SystemClock struct{}
+
+// Description:
+//
+//	A clock which always returns the same point in time.
+type FixedClock struct {
+
+	// The time returned by the clock.
+	Time time.Time
+}
+
+// Description:
+//
+//	Creates a new system clock.
+//
+// Returns:
+//
+//	The created system clock.
+func NewSystemClock() *SystemClock {
+	return &SystemClock{}
+}
+
+// Description:
+//
+//	Creates a new fixed clock.
+//
+// Parameters:
+//
+//	time The time returned by the clock.
+//
+// Returns:
+//
+//	The created fixed clock.
+func NewFixedClock(time time.Time) *FixedClock {
+	return &FixedClock{
+		Time: time,
+	}
+}
+
+// Description:
+//
+//	Gets the current system time in UTC.
+//
+// Returns:
+//
+//	The current time.
+func (clock *SystemClock) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// Description:
+//
+//	Gets the fixed time of this clock.
+//
+// Returns:
+//
+//	The fixed time.
+func (clock *FixedClock) Now() time.Time {
+	return clock.Time
+}
+
+// Description:
+//
+//	Gets the current time of a clock as timestamp for stored documents.
+//	Truncates the time to milliseconds, the precision of MongoDB dates,
+//	so that stored timestamps equal the timestamps returned on creation.
+//
+// Parameters:
+//
+//	clock The clock to read.
+//
+// Returns:
+//
+//	The current time in UTC, truncated to milliseconds.
+func Timestamp(clock Clock) time.Time {
+	return clock.Now().UTC().Truncate(time.Millisecond)
+}
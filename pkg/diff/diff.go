@@ -0,0 +1,128 @@
+package diff
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// Description:
+//
+//	A change of a single field between two versions of an object.
+type Change struct {
+
+	// The dotted path of the changed field, e.g. 'audioFeatures.tempo'.
+	Field string `json:"field" bson:"field"`
+
+	// The previous value of the field. Nil if the field was added.
+	From interface{} `json:"from,omitempty" bson:"from,omitempty"`
+
+	// The current value of the field. Nil if the field was removed.
+	To interface{} `json:"to,omitempty" bson:"to,omitempty"`
+}
+
+// Description:
+//
+//	Computes the field-level changes between two versions of an object.
+//	Both versions are compared by their JSON encoding.
+//	Nested objects are compared field by field, arrays are compared as a whole.
+//
+// Parameters:
+//
+//	from 	The previous version. Nil if the object was created.
+//	to 		The current version. Nil if the object was removed.
+//
+// Returns:
+//
+//	The changes, sorted by field.
+//	An error if a version cannot be encoded as a JSON object.
+func Compare(from interface{}, to interface{}) ([]Change, error) {
+	fromFields, err := flatten(from)
+	if err != nil {
+		return nil, err
+	}
+
+	toFields, err := flatten(to)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]Change, 0)
+
+	for field, fromValue := range fromFields {
+		toValue, ok := toFields[field]
+		if !ok || !reflect.DeepEqual(fromValue, toValue) {
+			changes = append(changes, Change{Field: field, From: fromValue, To: toValue})
+		}
+	}
+
+	for field, toValue := range toFields {
+		_, ok := fromFields[field]
+		if !ok {
+			changes = append(changes, Change{Field: field, To: toValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+
+	return changes, nil
+}
+
+// Description:
+//
+//	Flattens the JSON encoding of an object into a mapping of dotted field paths to values.
+//
+// Parameters:
+//
+//	object The object to flatten. Nil results in an empty mapping.
+//
+// Returns:
+//
+//	The flattened fields.
+//	An error if the object cannot be encoded as a JSON object.
+func flatten(object interface{}) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+
+	bytes, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+
+	document := make(map[string]interface{})
+
+	err = json.Unmarshal(bytes, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	flattenInto(fields, "", document)
+	return fields, nil
+}
+
+// Description:
+//
+//	Adds the fields of a decoded JSON object to a flattened mapping.
+//
+// Parameters:
+//
+//	fields 		The flattened mapping to add to.
+//	prefix 		The dotted path of the object, empty for the root object.
+//	document 	The decoded JSON object.
+func flattenInto(fields map[string]interface{}, prefix string, document map[string]interface{}) {
+	for key, value := range document {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		nested, ok := value.(map[string]interface{})
+		if ok && len(nested) > 0 {
+			flattenInto(fields, path, nested)
+			continue
+		}
+
+		fields[path] = value
+	}
+}
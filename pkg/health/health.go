@@ -0,0 +1,322 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+
+	// The status of a ready service, or of an available dependency.
+	StatusUp = "up"
+
+	// The status of an unready service, or of an unavailable dependency.
+	StatusDown = "down"
+
+	// The status of a service which is shutting down.
+	StatusShuttingDown = "shutting down"
+)
+
+// Description:
+//
+//	A health check of a single dependency.
+type HealthChecker interface {
+
+	// Description:
+	//
+	//	The name of the checked dependency, e.g. 'mongo'.
+	//
+	// Returns:
+	//
+	//	The name of the dependency.
+	Name() string
+
+	// Description:
+	//
+	//	Checks whether the dependency is available.
+	//	Must honor ctx and return once it is done: a check exceeding the timeout is reported
+	//	as unavailable, but keeps its goroutine running until it returns.
+	//
+	// Parameters:
+	//
+	//	ctx The context bounding the check.
+	//
+	// Returns:
+	//
+	//	An error if the dependency is unavailable.
+	Check(ctx context.Context) error
+}
+
+// Description:
+//
+//	A health check defined by a function.
+type checkerFunc struct {
+
+	// The name of the checked dependency.
+	name string
+
+	// The check.
+	check func(ctx context.Context) error
+}
+
+// Description:
+//
+//	The result of a single health check.
+type CheckResult struct {
+
+	// The name of the checked dependency.
+	Name string `json:"name"`
+
+	// The status of the dependency, either 'up' or 'down'.
+	Status string `json:"status"`
+
+	// The duration of the check, in milliseconds.
+	LatencyMillis float64 `json:"latencyMs"`
+
+	// The error of the check. Empty if the dependency is available.
+	Error string `json:"error,omitempty"`
+}
+
+// Description:
+//
+//	The readiness report of the service.
+type Report struct {
+
+	// The status of the service, either 'up', 'down' or 'shutting down'.
+	Status string `json:"status"`
+
+	// The results of all health checks, in order of registration.
+	Checks []CheckResult `json:"checks"`
+}
+
+// Description:
+//
+//	The registry of the health checks of the service.
+//	Safe for concurrent use.
+type Registry struct {
+
+	// Guards the health checks.
+	mutex sync.RWMutex
+
+	// The registered health checks.
+	checkers []HealthChecker
+
+	// The maximum duration of a single health check.
+	timeout time.Duration
+
+	// Whether the service is shutting down.
+	shuttingDown atomic.Bool
+}
+
+// Description:
+//
+//	Creates a health check from a function.
+//
+// Parameters:
+//
+//	name 	The name of the checked dependency.
+//	check 	The check, returning an error if the dependency is unavailable.
+//
+// Returns:
+//
+//	The health check.
+func NewChecker(name string, check func(ctx context.Context) error) HealthChecker {
+	return &checkerFunc{
+		name:  name,
+		check: check,
+	}
+}
+
+// Description:
+//
+//	The name of the checked dependency.
+//
+// Returns:
+//
+//	The name of the dependency.
+func (checker *checkerFunc) Name() string {
+	return checker.name
+}
+
+// Description:
+//
+//	Checks whether the dependency is available.
+//
+// Parameters:
+//
+//	ctx The context bounding the check.
+//
+// Returns:
+//
+//	An error if the dependency is unavailable.
+func (checker *checkerFunc) Check(ctx context.Context) error {
+	return checker.check(ctx)
+}
+
+// Description:
+//
+//	Creates a new health check registry.
+//
+// Parameters:
+//
+//	timeout The maximum duration of a single health check.
+//
+// Returns:
+//
+//	The created registry.
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{
+		checkers: make([]HealthChecker, 0),
+		timeout:  timeout,
+	}
+}
+
+// Description:
+//
+//	Registers a health check.
+//
+// Parameters:
+//
+//	checker The health check to register.
+func (registry *Registry) Register(checker HealthChecker) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	registry.checkers = append(registry.checkers, checker)
+}
+
+// Description:
+//
+//	Sets the maximum duration of a single health check.
+//
+// Parameters:
+//
+//	timeout The maximum duration of a single health check.
+func (registry *Registry) SetTimeout(timeout time.Duration) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	registry.timeout = timeout
+}
+
+// Description:
+//
+//	Marks the service as shutting down.
+//	The service is reported unready from then on, so no new traffic is routed to it.
+func (registry *Registry) MarkShuttingDown() {
+	registry.shuttingDown.Store(true)
+}
+
+// Description:
+//
+//	Runs all health checks concurrently and reports the readiness of the service.
+//	The service is ready if all dependencies are available and it is not shutting down.
+//
+// Parameters:
+//
+//	ctx The context bounding the checks.
+//
+// Returns:
+//
+//	The readiness report.
+func (registry *Registry) Check(ctx context.Context) *Report {
+	registry.mutex.RLock()
+	checkers := append([]HealthChecker{}, registry.checkers...)
+	timeout := registry.timeout
+	registry.mutex.RUnlock()
+
+	results := make([]CheckResult, len(checkers))
+
+	var group sync.WaitGroup
+
+	for index, checker := range checkers {
+		group.Add(1)
+
+		go func(index int, checker HealthChecker) {
+			defer group.Done()
+			results[index] = runCheck(ctx, checker, timeout)
+		}(index, checker)
+	}
+
+	group.Wait()
+
+	report := &Report{
+		Status: StatusUp,
+		Checks: results,
+	}
+
+	for _, result := range results {
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+
+	if registry.shuttingDown.Load() {
+		report.Status = StatusShuttingDown
+	}
+
+	return report
+}
+
+// Description:
+//
+//	Runs a single health check.
+//	The check runs in its own goroutine, so a check ignoring the timeout cannot block the report.
+//	A panicking check is reported as unavailable.
+//
+// Parameters:
+//
+//	ctx 		The context bounding the check.
+//	checker 	The health check to run.
+//	timeout 	The maximum duration of the check. Zero disables the timeout.
+//
+// Returns:
+//
+//	The result of the check.
+func runCheck(ctx context.Context, checker HealthChecker, timeout time.Duration) CheckResult {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	checked := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				checked <- fmt.Errorf("health: check panicked: %v", recovered)
+			}
+		}()
+
+		checked <- checker.Check(ctx)
+	}()
+
+	var err error
+
+	select {
+	case err = <-checked:
+	case <-ctx.Done():
+		err = fmt.Errorf("health: check did not complete: %w", ctx.Err())
+	}
+
+	latency := time.Since(start)
+
+	result := CheckResult{
+		Name:          checker.Name(),
+		Status:        StatusUp,
+		LatencyMillis: float64(latency.Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
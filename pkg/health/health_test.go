@@ -0,0 +1,94 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckAggregatesResults(t *testing.T) {
+	up := NewChecker("up", func(ctx context.Context) error { return nil })
+	down := NewChecker("down", func(ctx context.Context) error { return errors.New("unavailable") })
+
+	tests := []struct {
+		name         string
+		checkers     []HealthChecker
+		shuttingDown bool
+		expected     string
+		statuses     []string
+	}{
+		{name: "no checks", expected: StatusUp, statuses: []string{}},
+		{name: "all up", checkers: []HealthChecker{up, up}, expected: StatusUp, statuses: []string{StatusUp, StatusUp}},
+		{name: "one down", checkers: []HealthChecker{up, down}, expected: StatusDown, statuses: []string{StatusUp, StatusDown}},
+		{name: "shutting down", checkers: []HealthChecker{down, up}, shuttingDown: true, expected: StatusShuttingDown, statuses: []string{StatusDown, StatusUp}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			registry := NewRegistry(time.Second)
+
+			for _, checker := range test.checkers {
+				registry.Register(checker)
+			}
+
+			if test.shuttingDown {
+				registry.MarkShuttingDown()
+			}
+
+			report := registry.Check(context.Background())
+			if report.Status != test.expected {
+				t.Errorf("expected status '%s', received '%s'", test.expected, report.Status)
+			}
+
+			if len(report.Checks) != len(test.statuses) {
+				t.Fatalf("expected %d results, received %d", len(test.statuses), len(report.Checks))
+			}
+
+			for index, result := range report.Checks {
+				if result.Name != test.checkers[index].Name() || result.Status != test.statuses[index] {
+					t.Errorf("unexpected result %d: %+v", index, result)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckTimesOutChecksIgnoringTheContext(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	registry := NewRegistry(20 * time.Millisecond)
+	registry.Register(NewChecker("stuck", func(ctx context.Context) error {
+		<-release
+		return nil
+	}))
+
+	done := make(chan *Report, 1)
+	go func() {
+		done <- registry.Check(context.Background())
+	}()
+
+	select {
+	case report := <-done:
+		if report.Status != StatusDown || !strings.Contains(report.Checks[0].Error, context.DeadlineExceeded.Error()) {
+			t.Errorf("expected the check to time out, received: %+v", report)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the report not to wait for the stuck check")
+	}
+}
+
+func TestCheckReportsPanicsAsDown(t *testing.T) {
+	registry := NewRegistry(time.Second)
+	registry.Register(NewChecker("panicking", func(ctx context.Context) error {
+		panic("connection pool is nil")
+	}))
+
+	report := registry.Check(context.Background())
+
+	if report.Status != StatusDown || !strings.Contains(report.Checks[0].Error, "connection pool is nil") {
+		t.Errorf("expected the panic to be reported as down, received: %+v", report)
+	}
+}
@@ -0,0 +1,85 @@
+package idgen
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// Description:
+//
+//	The id generator interface.
+//	Abstracts the creation of unique identifiers.
+type Generator interface {
+
+	// Description:
+	//
+	//	Creates a new unique identifier.
+	//
+	// Returns:
+	//
+	//	The created identifier.
+	NewID() string
+}
+
+// Description:
+//
+//	An id generator creating random (version 4) UUIDs.
+type UUIDGenerator struct{}
+
+// Description:
+//
+//	An id generator creating predictable UUIDs from an incrementing counter.
+//	Useful for reproducible identifiers.
+type SequenceGenerator struct {
+
+	// The last issued sequence number.
+	counter uint64
+}
+
+// Description:
+//
+//	Creates a new UUID generator.
+//
+// Returns:
+//
+//	The created UUID generator.
+func NewUUIDGenerator() *UUIDGenerator {
+	return &UUIDGenerator{}
+}
+
+// Description:
+//
+//	Creates a new sequence generator.
+//
+// Returns:
+//
+//	The created sequence generator.
+func NewSequenceGenerator() *SequenceGenerator {
+	return &SequenceGenerator{}
+}
+
+// Description:
+//
+//	Creates a new random UUID.
+//
+// Returns:
+//
+//	The created UUID.
+func (generator *UUIDGenerator) NewID() string {
+	return uuid.New().String()
+}
+
+// Description:
+//
+//	Creates the next UUID of the sequence.
+//	The first UUID is '00000000-0000-4000-8000-000000000001'.
+//
+// Returns:
+//
+//	The created UUID.
+func (generator *SequenceGenerator) NewID() string {
+	next := atomic.AddUint64(&generator.counter, 1)
+	return fmt.Sprintf("00000000-0000-4000-8000-%012x", next)
+}
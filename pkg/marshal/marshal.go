@@ -2,6 +2,7 @@ package marshal
 
 import (
 	"encoding/json"
+	"strings"
 )
 
 const (
@@ -82,3 +83,75 @@ func QuickWithIndent(object interface{}) string {
 
 	return string(bytes)
 }
+
+// Description:
+//
+//	Marshals an object into a generic JSON object containing only the given fields.
+//	Nested fields are referenced by dotted paths, e.g. 'audioFeatures.tempo'.
+//	Fields missing in the object are omitted.
+//
+// Parameters:
+//
+//	object 	The object to project.
+//	paths 	The JSON field paths to keep.
+//
+// Returns:
+//
+//	The projected object, or an error if the object cannot be marshalled into a JSON object.
+func Project(object interface{}, paths []string) (map[string]interface{}, error) {
+	bytes, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(bytes)))
+	decoder.UseNumber()
+
+	var source map[string]interface{}
+
+	err = decoder.Decode(&source)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]interface{})
+
+	for _, path := range paths {
+		segments := strings.Split(path, ".")
+		value, ok := interface{}(source), true
+
+		for _, segment := range segments {
+			var nested map[string]interface{}
+
+			nested, ok = value.(map[string]interface{})
+			if !ok {
+				break
+			}
+
+			value, ok = nested[segment]
+			if !ok {
+				break
+			}
+		}
+
+		if !ok {
+			continue
+		}
+
+		target := result
+
+		for _, segment := range segments[:len(segments)-1] {
+			nested, ok := target[segment].(map[string]interface{})
+			if !ok {
+				nested = make(map[string]interface{})
+				target[segment] = nested
+			}
+
+			target = nested
+		}
+
+		target[segments[len(segments)-1]] = value
+	}
+
+	return result, nil
+}
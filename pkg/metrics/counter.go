@@ -0,0 +1,88 @@
+package metrics
+
+import "fmt"
+
+// Description:
+//
+//	A counter partitioned by labels, e.g. the number of requests per route and status.
+//	Safe for concurrent use.
+type CounterVec struct {
+
+	// The counter values per label combination.
+	vector *vector[float64]
+}
+
+// Description:
+//
+//	Creates a new counter.
+//	Panics if the metric name or a label name is invalid.
+//
+// Parameters:
+//
+//	name 		The metric name, by convention ending with '_total'.
+//	help 		The help text of the metric.
+//	labelNames 	The label names.
+//
+// Returns:
+//
+//	The created counter.
+func NewCounterVec(name string, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{
+		vector: newVector[float64](name, help, labelNames),
+	}
+}
+
+// Description:
+//
+//	Increments the counter of a label combination by one.
+//
+// Parameters:
+//
+//	labelValues The label values, in order of the label names.
+func (counter *CounterVec) Inc(labelValues ...string) {
+	counter.Add(1, labelValues...)
+}
+
+// Description:
+//
+//	Increments the counter of a label combination.
+//	Panics if the delta is negative, since counters only ever increase.
+//
+// Parameters:
+//
+//	delta 		The non-negative increment.
+//	labelValues The label values, in order of the label names.
+func (counter *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metrics: counter '%s' cannot decrease", counter.vector.name))
+	}
+
+	counter.vector.update(labelValues, func(value *float64) {
+		*value += delta
+	})
+}
+
+// Description:
+//
+//	Collects the current counter values.
+//
+// Returns:
+//
+//	The metric family of the counter.
+func (counter *CounterVec) Collect() []Family {
+	family := Family{
+		Name:    counter.vector.name,
+		Help:    counter.vector.help,
+		Type:    TypeCounter,
+		Samples: make([]Sample, 0),
+	}
+
+	counter.vector.each(func(labels []Label, value *float64) {
+		family.Samples = append(family.Samples, Sample{
+			Labels: labels,
+			Value:  *value,
+		})
+	})
+
+	return []Family{family}
+}
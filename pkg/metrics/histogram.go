@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Description:
+//
+//	A histogram partitioned by labels, e.g. the request durations per route and status.
+//	Safe for concurrent use.
+type HistogramVec struct {
+
+	// The upper bounds of the buckets, ascending, without the implicit '+Inf' bucket.
+	buckets []float64
+
+	// The observations per label combination.
+	vector *vector[histogramValue]
+}
+
+// Description:
+//
+//	The observations of a histogram for a single label combination.
+type histogramValue struct {
+
+	// The number of observations per bucket, not cumulative.
+	// The last count holds the observations above the greatest upper bound.
+	counts []uint64
+
+	// The sum of all observations.
+	sum float64
+
+	// The number of observations.
+	count uint64
+}
+
+// Description:
+//
+//	Creates a new histogram.
+//	Panics if the metric name, a label name or a bucket is invalid.
+//
+// Parameters:
+//
+//	name 		The metric name, by convention ending with the unit, e.g. '_seconds'.
+//	help 		The help text of the metric.
+//	buckets 	The upper bounds of the buckets. Uses DefaultBuckets if empty.
+//	labelNames 	The label names. Must not include 'le'.
+//
+// Returns:
+//
+//	The created histogram.
+func NewHistogramVec(name string, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	sorted := append([]float64{}, buckets...)
+	sort.Float64s(sorted)
+
+	for index, bound := range sorted {
+		if math.IsNaN(bound) || math.IsInf(bound, 0) || (index > 0 && bound == sorted[index-1]) {
+			panic(fmt.Sprintf("metrics: invalid bucket %v of histogram '%s'", bound, name))
+		}
+	}
+
+	for _, labelName := range labelNames {
+		if labelName == "le" {
+			panic(fmt.Sprintf("metrics: histogram '%s' cannot use the reserved label 'le'", name))
+		}
+	}
+
+	return &HistogramVec{
+		buckets: sorted,
+		vector:  newVector[histogramValue](name, help, labelNames),
+	}
+}
+
+// Description:
+//
+//	Records an observation for a label combination.
+//
+// Parameters:
+//
+//	observation The observed value, e.g. a duration in seconds.
+//	labelValues The label values, in order of the label names.
+func (histogram *HistogramVec) Observe(observation float64, labelValues ...string) {
+	bucket := sort.SearchFloat64s(histogram.buckets, observation)
+
+	histogram.vector.update(labelValues, func(value *histogramValue) {
+		if value.counts == nil {
+			value.counts = make([]uint64, len(histogram.buckets)+1)
+		}
+
+		value.counts[bucket]++
+		value.sum += observation
+		value.count++
+	})
+}
+
+// Description:
+//
+//	Collects the current histogram values.
+//	Reports the cumulative bucket counts, the sum and the count of each label combination.
+//
+// Returns:
+//
+//	The metric family of the histogram.
+func (histogram *HistogramVec) Collect() []Family {
+	family := Family{
+		Name:    histogram.vector.name,
+		Help:    histogram.vector.help,
+		Type:    TypeHistogram,
+		Samples: make([]Sample, 0),
+	}
+
+	histogram.vector.each(func(labels []Label, value *histogramValue) {
+		var cumulative uint64
+
+		for index, count := range value.counts {
+			cumulative += count
+
+			bound := math.Inf(1)
+			if index < len(histogram.buckets) {
+				bound = histogram.buckets[index]
+			}
+
+			family.Samples = append(family.Samples, Sample{
+				Suffix: "_bucket",
+				Labels: append(append([]Label{}, labels...), Label{Name: "le", Value: formatFloat(bound)}),
+				Value:  float64(cumulative),
+			})
+		}
+
+		family.Samples = append(family.Samples,
+			Sample{Suffix: "_sum", Labels: labels, Value: value.sum},
+			Sample{Suffix: "_count", Labels: labels, Value: float64(value.count)},
+		)
+	})
+
+	return []Family{family}
+}
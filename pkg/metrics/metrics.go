@@ -0,0 +1,334 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+
+	// The content type of the Prometheus text exposition format.
+	TextContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+const (
+
+	// The type of metrics which only ever increase, e.g. the number of requests.
+	TypeCounter = "counter"
+
+	// The type of metrics which can go up and down, e.g. the number of goroutines.
+	TypeGauge = "gauge"
+
+	// The type of metrics sampling observations into buckets, e.g. request durations.
+	TypeHistogram = "histogram"
+)
+
+// Description:
+//
+//	The default histogram buckets, in seconds.
+//	Cover durations from 5 milliseconds to 10 seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Description:
+//
+//	A label of a sample.
+type Label struct {
+
+	// The label name.
+	Name string
+
+	// The label value.
+	Value string
+}
+
+// Description:
+//
+//	A single sample of a metric family.
+type Sample struct {
+
+	// The suffix appended to the family name, e.g. '_bucket'. Empty for plain samples.
+	Suffix string
+
+	// The labels of the sample.
+	Labels []Label
+
+	// The sample value.
+	Value float64
+}
+
+// Description:
+//
+//	A family of samples sharing a metric name, help text and type.
+type Family struct {
+
+	// The metric name.
+	Name string
+
+	// The help text of the metric.
+	Help string
+
+	// The metric type, e.g. 'counter'.
+	Type string
+
+	// The samples of the metric.
+	Samples []Sample
+}
+
+// Description:
+//
+//	A source of metric families, collected on each scrape.
+type Collector interface {
+
+	// Description:
+	//
+	//	Collects the current samples.
+	//
+	// Returns:
+	//
+	//	The metric families of the collector.
+	Collect() []Family
+}
+
+// Description:
+//
+//	The registry of all collectors of the service.
+//	Safe for concurrent use.
+type Registry struct {
+
+	// Guards the collectors.
+	mutex sync.RWMutex
+
+	// The registered collectors, in order of registration.
+	collectors []Collector
+}
+
+// Description:
+//
+//	Creates a new, empty metrics registry.
+//
+// Returns:
+//
+//	The created registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		collectors: make([]Collector, 0),
+	}
+}
+
+// Description:
+//
+//	Registers collectors.
+//
+// Parameters:
+//
+//	collectors The collectors to register.
+func (registry *Registry) Register(collectors ...Collector) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	registry.collectors = append(registry.collectors, collectors...)
+}
+
+// Description:
+//
+//	Collects the metric families of all registered collectors.
+//	Families are sorted by name, so the output is stable across scrapes.
+//
+// Returns:
+//
+//	The collected metric families.
+//	An error if two collectors report the same metric name.
+func (registry *Registry) Gather() ([]Family, error) {
+	registry.mutex.RLock()
+	collectors := append([]Collector{}, registry.collectors...)
+	registry.mutex.RUnlock()
+
+	families := make([]Family, 0)
+	names := make(map[string]bool)
+
+	for _, collector := range collectors {
+		for _, family := range collector.Collect() {
+			if names[family.Name] {
+				return nil, fmt.Errorf("metrics: metric '%s' is collected more than once", family.Name)
+			}
+
+			names[family.Name] = true
+			families = append(families, family)
+		}
+	}
+
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].Name < families[j].Name
+	})
+
+	return families, nil
+}
+
+// Description:
+//
+//	Stores the values of a metric per label combination.
+//	Shared by counters and histograms.
+//
+// Type Parameters:
+//
+//	V The type of the stored values.
+type vector[V interface{}] struct {
+
+	// Guards the values.
+	mutex sync.Mutex
+
+	// The metric name.
+	name string
+
+	// The help text of the metric.
+	help string
+
+	// The label names, in order.
+	labelNames []string
+
+	// The values, keyed by the joined label values.
+	values map[string]*entry[V]
+}
+
+// Description:
+//
+//	The value of a metric for a single label combination.
+//
+// Type Parameters:
+//
+//	V The type of the stored value.
+type entry[V interface{}] struct {
+
+	// The label values, in order of the label names.
+	labelValues []string
+
+	// The value.
+	value V
+}
+
+// Description:
+//
+//	Creates a new vector.
+//	Panics if the metric name or a label name is invalid.
+//
+// Parameters:
+//
+//	name 		The metric name.
+//	help 		The help text of the metric.
+//	labelNames 	The label names.
+//
+// Returns:
+//
+//	The created vector.
+func newVector[V interface{}](name string, help string, labelNames []string) *vector[V] {
+	if !isValidName(name, true) {
+		panic(fmt.Sprintf("metrics: invalid metric name '%s'", name))
+	}
+
+	for _, labelName := range labelNames {
+		if !isValidName(labelName, false) || strings.HasPrefix(labelName, "__") {
+			panic(fmt.Sprintf("metrics: invalid label name '%s' of metric '%s'", labelName, name))
+		}
+	}
+
+	return &vector[V]{
+		name:       name,
+		help:       help,
+		labelNames: append([]string{}, labelNames...),
+		values:     make(map[string]*entry[V]),
+	}
+}
+
+// Description:
+//
+//	Updates the value of a label combination, creating it if needed.
+//	Panics if the number of label values does not match the number of label names.
+//
+// Parameters:
+//
+//	labelValues The label values, in order of the label names.
+//	update 		The update applied to the value, while the vector is locked.
+func (vector *vector[V]) update(labelValues []string, update func(value *V)) {
+	if len(labelValues) != len(vector.labelNames) {
+		panic(fmt.Sprintf("metrics: metric '%s' expects %d label values, received %d", vector.name, len(vector.labelNames), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	vector.mutex.Lock()
+	defer vector.mutex.Unlock()
+
+	current, ok := vector.values[key]
+	if !ok {
+		current = &entry[V]{
+			labelValues: append([]string{}, labelValues...),
+		}
+
+		vector.values[key] = current
+	}
+
+	update(&current.value)
+}
+
+// Description:
+//
+//	Visits all label combinations, sorted by their label values.
+//
+// Parameters:
+//
+//	visit The visitor, called with the labels and the value of each combination while the vector is locked.
+func (vector *vector[V]) each(visit func(labels []Label, value *V)) {
+	vector.mutex.Lock()
+	defer vector.mutex.Unlock()
+
+	keys := make([]string, 0, len(vector.values))
+	for key := range vector.values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		current := vector.values[key]
+		labels := make([]Label, len(vector.labelNames))
+
+		for index, labelName := range vector.labelNames {
+			labels[index] = Label{Name: labelName, Value: current.labelValues[index]}
+		}
+
+		visit(labels, &current.value)
+	}
+}
+
+// Description:
+//
+//	Checks whether a metric or label name is valid.
+//	Metric names may contain colons, label names may not.
+//
+// Parameters:
+//
+//	name 		The name to check.
+//	allowColon 	Whether the name may contain colons.
+//
+// Returns:
+//
+//	True, if the name is valid.
+func isValidName(name string, allowColon bool) bool {
+	if len(name) == 0 {
+		return false
+	}
+
+	for index, char := range name {
+		switch {
+		case char >= 'a' && char <= 'z', char >= 'A' && char <= 'Z', char == '_':
+		case char == ':' && allowColon:
+		case char >= '0' && char <= '9' && index > 0:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import "runtime"
+
+// Description:
+//
+//	Collects metrics of the Go runtime, e.g. goroutines, memory and garbage collection.
+type RuntimeCollector struct{}
+
+// Description:
+//
+//	Creates a new Go runtime collector.
+//
+// Returns:
+//
+//	The created collector.
+func NewRuntimeCollector() *RuntimeCollector {
+	return &RuntimeCollector{}
+}
+
+// Description:
+//
+//	Collects the current Go runtime metrics.
+//	Reading the memory statistics briefly stops the world, so scrapes should not be too frequent.
+//
+// Returns:
+//
+//	The metric families of the Go runtime.
+func (collector *RuntimeCollector) Collect() []Family {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	return []Family{
+		{
+			Name:    "go_info",
+			Help:    "Information about the Go environment.",
+			Type:    TypeGauge,
+			Samples: []Sample{{Labels: []Label{{Name: "version", Value: runtime.Version()}}, Value: 1}},
+		},
+		gauge("go_goroutines", "Number of goroutines that currently exist.", float64(runtime.NumGoroutine())),
+		gauge("go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", float64(stats.Alloc)),
+		counter("go_memstats_alloc_bytes_total", "Total number of bytes allocated, even if freed.", float64(stats.TotalAlloc)),
+		gauge("go_memstats_sys_bytes", "Number of bytes obtained from the operating system.", float64(stats.Sys)),
+		gauge("go_memstats_heap_inuse_bytes", "Number of heap bytes that are in use.", float64(stats.HeapInuse)),
+		gauge("go_memstats_heap_objects", "Number of allocated objects.", float64(stats.HeapObjects)),
+		counter("go_gc_cycles_total", "Number of completed garbage collection cycles.", float64(stats.NumGC)),
+		counter("go_gc_pause_seconds_total", "Total duration of garbage collection pauses, in seconds.", float64(stats.PauseTotalNs)/1e9),
+	}
+}
+
+// Description:
+//
+//	Creates a gauge family with a single unlabelled sample.
+//
+// Parameters:
+//
+//	name 	The metric name.
+//	help 	The help text of the metric.
+//	value 	The sample value.
+//
+// Returns:
+//
+//	The metric family.
+func gauge(name string, help string, value float64) Family {
+	return Family{
+		Name:    name,
+		Help:    help,
+		Type:    TypeGauge,
+		Samples: []Sample{{Value: value}},
+	}
+}
+
+// Description:
+//
+//	Creates a counter family with a single unlabelled sample.
+//
+// Parameters:
+//
+//	name 	The metric name.
+//	help 	The help text of the metric.
+//	value 	The sample value.
+//
+// Returns:
+//
+//	The metric family.
+func counter(name string, help string, value float64) Family {
+	return Family{
+		Name:    name,
+		Help:    help,
+		Type:    TypeCounter,
+		Samples: []Sample{{Value: value}},
+	}
+}
@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Description:
+//
+//	Escapes backslashes and line feeds in help texts.
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// Description:
+//
+//	Escapes backslashes, double quotes and line feeds in label values.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Description:
+//
+//	Writes the metric families of all registered collectors in the Prometheus text exposition format (version 0.0.4).
+//
+// Parameters:
+//
+//	writer The writer to write to.
+//
+// Returns:
+//
+//	An error if the metrics cannot be gathered or written.
+func (registry *Registry) WriteText(writer io.Writer) error {
+	families, err := registry.Gather()
+	if err != nil {
+		return err
+	}
+
+	return WriteText(writer, families)
+}
+
+// Description:
+//
+//	Writes metric families in the Prometheus text exposition format (version 0.0.4).
+//
+// Example:
+//
+//	# HELP http_requests_total Number of handled HTTP requests.
+//	# TYPE http_requests_total counter
+//	http_requests_total{method="GET",route="/tracks/:id",status="200"} 3
+//
+// Parameters:
+//
+//	writer 		The writer to write to.
+//	families 	The metric families to write.
+//
+// Returns:
+//
+//	An error if writing fails.
+func WriteText(writer io.Writer, families []Family) error {
+	buffered := bufio.NewWriter(writer)
+
+	for _, family := range families {
+		buffered.WriteString("# HELP " + family.Name + " " + helpEscaper.Replace(family.Help) + "\n")
+		buffered.WriteString("# TYPE " + family.Name + " " + family.Type + "\n")
+
+		for _, sample := range family.Samples {
+			buffered.WriteString(family.Name + sample.Suffix)
+			writeLabels(buffered, sample.Labels)
+			buffered.WriteString(" " + formatFloat(sample.Value) + "\n")
+		}
+	}
+
+	return buffered.Flush()
+}
+
+// Description:
+//
+//	Writes the labels of a sample, e.g. '{method="GET",status="200"}'.
+//	Writes nothing if the sample has no labels.
+//
+// Parameters:
+//
+//	writer 	The writer to write to.
+//	labels 	The labels to write.
+func writeLabels(writer *bufio.Writer, labels []Label) {
+	if len(labels) == 0 {
+		return
+	}
+
+	writer.WriteByte('{')
+
+	for index, label := range labels {
+		if index > 0 {
+			writer.WriteByte(',')
+		}
+
+		writer.WriteString(label.Name + `="` + labelValueEscaper.Replace(label.Value) + `"`)
+	}
+
+	writer.WriteByte('}')
+}
+
+// Description:
+//
+//	Formats a sample value, using the notation of the exposition format for special values.
+//
+// Parameters:
+//
+//	value The value to format.
+//
+// Returns:
+//
+//	The formatted value, e.g. '0.25', '+Inf' or 'NaN'.
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
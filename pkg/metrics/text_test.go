@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteTextCounter(t *testing.T) {
+	counter := NewCounterVec("requests_total", "Number of requests.\nCounted per path.", "path", "status")
+	counter.Add(2, `C:\tracks`, "200")
+	counter.Inc(`/tracks/"quoted"`, "404")
+	counter.Inc("/tracks\nnext", "500")
+
+	registry := NewRegistry()
+	registry.Register(counter)
+
+	expected := `# HELP requests_total Number of requests.\nCounted per path.
+# TYPE requests_total counter
+requests_total{path="/tracks\nnext",status="500"} 1
+requests_total{path="/tracks/\"quoted\"",status="404"} 1
+requests_total{path="C:\\tracks",status="200"} 2
+`
+
+	assertText(t, registry, expected)
+}
+
+func TestWriteTextHistogram(t *testing.T) {
+	histogram := NewHistogramVec("request_duration_seconds", "Duration of requests.", []float64{1, 0.5}, "route")
+	histogram.Observe(0.25, "/tracks/:id")
+	histogram.Observe(0.5, "/tracks/:id")
+	histogram.Observe(4, "/tracks/:id")
+
+	registry := NewRegistry()
+	registry.Register(histogram)
+
+	expected := `# HELP request_duration_seconds Duration of requests.
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{route="/tracks/:id",le="0.5"} 2
+request_duration_seconds_bucket{route="/tracks/:id",le="1"} 2
+request_duration_seconds_bucket{route="/tracks/:id",le="+Inf"} 3
+request_duration_seconds_sum{route="/tracks/:id"} 4.75
+request_duration_seconds_count{route="/tracks/:id"} 3
+`
+
+	assertText(t, registry, expected)
+}
+
+// Description:
+//
+//	Writes the metrics of a registry and compares them to the expected exposition.
+//
+// Parameters:
+//
+//	t 			The test.
+//	registry 	The registry to write.
+//	expected 	The expected text exposition.
+func assertText(t *testing.T, registry *Registry, expected string) {
+	var buffer bytes.Buffer
+
+	err := registry.WriteText(&buffer)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if buffer.String() != expected {
+		t.Errorf("expected:\n%s\nreceived:\n%s", expected, buffer.String())
+	}
+}
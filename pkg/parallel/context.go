@@ -1,6 +1,7 @@
 package parallel
 
 import (
+	stdcontext "context"
 	"crypto/sha256"
 	"encoding/base64"
 	"strconv"
@@ -54,3 +55,49 @@ func NewContext() *Context {
 		LongID:  decoded,
 	}
 }
+
+// Description:
+//
+//	The key type for storing parallel contexts in a standard context.
+type contextKey struct{}
+
+// Description:
+//
+//	Attaches a parallel context to a standard context.
+//
+// Parameters:
+//
+//	parent 	The standard context to derive from.
+//	context The parallel context to attach.
+//
+// Returns:
+//
+//	The derived standard context carrying the parallel context.
+func WithContext(parent stdcontext.Context, context *Context) stdcontext.Context {
+	return stdcontext.WithValue(parent, contextKey{}, context)
+}
+
+// Description:
+//
+//	Gets the parallel context attached to a standard context.
+//	If no parallel context is attached, a new one is created.
+//
+// Parameters:
+//
+//	context The standard context. May be nil.
+//
+// Returns:
+//
+//	The attached parallel context, or a new one.
+func FromContext(context stdcontext.Context) *Context {
+	if context == nil {
+		return NewContext()
+	}
+
+	parallelContext, ok := context.Value(contextKey{}).(*Context)
+	if !ok || parallelContext == nil {
+		return NewContext()
+	}
+
+	return parallelContext
+}
@@ -0,0 +1,576 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+
+	// Returned if a patch document is malformed.
+	ErrInvalidPatch = errors.New("patch: invalid patch")
+
+	// Returned if a JSON patch references a location which does not exist in the document.
+	ErrPathNotFound = errors.New("patch: path not found")
+
+	// Returned if a JSON patch test operation does not match the document.
+	ErrTestFailed = errors.New("patch: test failed")
+)
+
+// Description:
+//
+//	A single operation of a JSON patch (RFC 6902).
+type Operation struct {
+
+	// The operation: add, remove, replace, move, copy or test.
+	Op string
+
+	// The JSON pointer (RFC 6901) to the target location.
+	Path string
+
+	// The JSON pointer to the source location of move and copy operations.
+	From string
+
+	// The decoded value of add, replace and test operations.
+	Value interface{}
+}
+
+// Description:
+//
+//	The encoding of a single JSON patch operation.
+//	Pointers distinguish missing members from empty or null members.
+type encodedOperation struct {
+
+	// The operation.
+	Op *string `json:"op"`
+
+	// The target location.
+	Path *string `json:"path"`
+
+	// The source location.
+	From *string `json:"from"`
+
+	// The encoded value. Empty if the member is missing, 'null' if the value is null.
+	Value json.RawMessage `json:"value"`
+}
+
+// Description:
+//
+//	Decodes and validates a JSON patch (RFC 6902).
+//
+// Parameters:
+//
+//	patch The encoded JSON patch, an array of operations.
+//
+// Returns:
+//
+//	The decoded operations.
+//	ErrInvalidPatch if the patch is malformed.
+func ParseJSONPatch(patch []byte) ([]Operation, error) {
+	encoded := make([]encodedOperation, 0)
+
+	err := json.Unmarshal(patch, &encoded)
+	if err != nil {
+		return nil, wrapInvalidPatch(err)
+	}
+
+	operations := make([]Operation, 0, len(encoded))
+
+	for index, item := range encoded {
+		if item.Op == nil || item.Path == nil {
+			return nil, fmt.Errorf("%w: operation %d requires op and path", ErrInvalidPatch, index)
+		}
+
+		operation := Operation{
+			Op:   *item.Op,
+			Path: *item.Path,
+		}
+
+		switch operation.Op {
+		case "add", "replace", "test":
+			if len(item.Value) == 0 {
+				return nil, fmt.Errorf("%w: operation %d requires a value", ErrInvalidPatch, index)
+			}
+
+			err = json.Unmarshal(item.Value, &operation.Value)
+			if err != nil {
+				return nil, wrapInvalidPatch(err)
+			}
+		case "move", "copy":
+			if item.From == nil {
+				return nil, fmt.Errorf("%w: operation %d requires from", ErrInvalidPatch, index)
+			}
+
+			operation.From = *item.From
+		case "remove":
+		default:
+			return nil, fmt.Errorf("%w: operation %d has unknown op '%s'", ErrInvalidPatch, index, operation.Op)
+		}
+
+		operations = append(operations, operation)
+	}
+
+	return operations, nil
+}
+
+// Description:
+//
+//	Applies the operations of a JSON patch (RFC 6902) to a decoded JSON document.
+//	The operations are applied in order. If an operation fails, the patch is not applied.
+//
+// Parameters:
+//
+//	document 	The decoded JSON document to patch. Left unmodified.
+//	operations 	The operations to apply.
+//
+// Returns:
+//
+//	The patched document.
+//	ErrInvalidPatch, ErrPathNotFound or ErrTestFailed, if an operation cannot be applied.
+func ApplyJSONPatch(document interface{}, operations []Operation) (interface{}, error) {
+	result := clone(document)
+
+	for index, operation := range operations {
+		var err error
+
+		result, err = applyOperation(result, operation)
+		if err != nil {
+			return nil, fmt.Errorf("%w (operation %d: %s %s)", err, index, operation.Op, operation.Path)
+		}
+	}
+
+	return result, nil
+}
+
+// Description:
+//
+//	Applies a single JSON patch operation to a decoded JSON document.
+//
+// Parameters:
+//
+//	document 	The decoded JSON document. May be modified.
+//	operation 	The operation to apply.
+//
+// Returns:
+//
+//	The patched document.
+//	An error if the operation cannot be applied.
+func applyOperation(document interface{}, operation Operation) (interface{}, error) {
+	path, err := parsePointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case "add":
+		return add(document, path, clone(operation.Value))
+	case "remove":
+		result, _, err := remove(document, path)
+		return result, err
+	case "replace":
+		return replace(document, path, clone(operation.Value))
+	case "test":
+		value, err := get(document, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !reflect.DeepEqual(value, operation.Value) {
+			return nil, ErrTestFailed
+		}
+
+		return document, nil
+	}
+
+	from, err := parsePointer(operation.From)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case "move":
+		if isPrefix(from, path) {
+			return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidPatch)
+		}
+
+		result, value, err := remove(document, from)
+		if err != nil {
+			return nil, err
+		}
+
+		return add(result, path, value)
+	case "copy":
+		value, err := get(document, from)
+		if err != nil {
+			return nil, err
+		}
+
+		return add(document, path, clone(value))
+	}
+
+	return nil, fmt.Errorf("%w: unknown op '%s'", ErrInvalidPatch, operation.Op)
+}
+
+// Description:
+//
+//	Parses a JSON pointer (RFC 6901) into its reference tokens.
+//
+// Parameters:
+//
+//	pointer The JSON pointer. Empty for the whole document.
+//
+// Returns:
+//
+//	The unescaped reference tokens.
+//	ErrInvalidPatch if the pointer is malformed.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: pointer '%s' must start with '/'", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for index, token := range tokens {
+		tokens[index] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// Description:
+//
+//	Checks whether a path is a proper prefix of another path.
+//
+// Parameters:
+//
+//	prefix 	The potential prefix.
+//	path 	The path.
+//
+// Returns:
+//
+//	True, if the path is located below the prefix.
+func isPrefix(prefix []string, path []string) bool {
+	if len(prefix) >= len(path) {
+		return false
+	}
+
+	for index := range prefix {
+		if prefix[index] != path[index] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Description:
+//
+//	Parses an array index token.
+//
+// Parameters:
+//
+//	token 		The reference token.
+//	length 		The length of the array.
+//	allowEnd 	Whether the index may address the end of the array ('-' or the length).
+//
+// Returns:
+//
+//	The index.
+//	ErrPathNotFound if the token is not a valid index of the array.
+func parseIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, ErrPathNotFound
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, ErrPathNotFound
+	}
+
+	if index > length || (index == length && !allowEnd) {
+		return 0, ErrPathNotFound
+	}
+
+	return index, nil
+}
+
+// Description:
+//
+//	Navigates to the parent of a location and replaces it with the result of a function.
+//
+// Parameters:
+//
+//	document 	The decoded JSON document. May be modified.
+//	path 		The reference tokens of the location. Must not be empty.
+//	apply 		Computes the new parent from the parent and the last token of the location.
+//
+// Returns:
+//
+//	The modified document.
+//	ErrPathNotFound if the parent does not exist, or the error of the function.
+func update(document interface{}, path []string, apply func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return apply(document, path[0])
+	}
+
+	switch node := document.(type) {
+	case map[string]interface{}:
+		child, ok := node[path[0]]
+		if !ok {
+			return nil, ErrPathNotFound
+		}
+
+		result, err := update(child, path[1:], apply)
+		if err != nil {
+			return nil, err
+		}
+
+		node[path[0]] = result
+		return node, nil
+	case []interface{}:
+		index, err := parseIndex(path[0], len(node), false)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := update(node[index], path[1:], apply)
+		if err != nil {
+			return nil, err
+		}
+
+		node[index] = result
+		return node, nil
+	}
+
+	return nil, ErrPathNotFound
+}
+
+// Description:
+//
+//	Gets the value at a location.
+//
+// Parameters:
+//
+//	document 	The decoded JSON document.
+//	path 		The reference tokens of the location.
+//
+// Returns:
+//
+//	The value.
+//	ErrPathNotFound if the location does not exist.
+func get(document interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := document.(type) {
+		case map[string]interface{}:
+			child, ok := node[token]
+			if !ok {
+				return nil, ErrPathNotFound
+			}
+
+			document = child
+		case []interface{}:
+			index, err := parseIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+
+			document = node[index]
+		default:
+			return nil, ErrPathNotFound
+		}
+	}
+
+	return document, nil
+}
+
+// Description:
+//
+//	Adds a value at a location.
+//	Object members are added or replaced, array elements are inserted.
+//
+// Parameters:
+//
+//	document 	The decoded JSON document. May be modified.
+//	path 		The reference tokens of the location.
+//	value 		The value to add.
+//
+// Returns:
+//
+//	The modified document.
+//	ErrPathNotFound if the parent of the location does not exist.
+func add(document interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return update(document, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			index, err := parseIndex(token, len(node), true)
+			if err != nil {
+				return nil, err
+			}
+
+			result := make([]interface{}, 0, len(node)+1)
+			result = append(result, node[:index]...)
+			result = append(result, value)
+			return append(result, node[index:]...), nil
+		}
+
+		return nil, ErrPathNotFound
+	})
+}
+
+// Description:
+//
+//	Removes the value at a location.
+//
+// Parameters:
+//
+//	document 	The decoded JSON document. May be modified.
+//	path 		The reference tokens of the location. Must not be empty.
+//
+// Returns:
+//
+//	The modified document and the removed value.
+//	ErrPathNotFound if the location does not exist.
+func remove(document interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+
+	var removed interface{}
+
+	result, err := update(document, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, ErrPathNotFound
+			}
+
+			removed = value
+			delete(node, token)
+			return node, nil
+		case []interface{}:
+			index, err := parseIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+
+			removed = node[index]
+
+			result := make([]interface{}, 0, len(node)-1)
+			result = append(result, node[:index]...)
+			return append(result, node[index+1:]...), nil
+		}
+
+		return nil, ErrPathNotFound
+	})
+
+	return result, removed, err
+}
+
+// Description:
+//
+//	Replaces the value at a location.
+//
+// Parameters:
+//
+//	document 	The decoded JSON document. May be modified.
+//	path 		The reference tokens of the location.
+//	value 		The replacing value.
+//
+// Returns:
+//
+//	The modified document.
+//	ErrPathNotFound if the location does not exist.
+func replace(document interface{}, path []string, value interface{}) (interface{}, error) {
+	_, err := get(document, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return update(document, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			index, err := parseIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+
+			node[index] = value
+			return node, nil
+		}
+
+		return nil, ErrPathNotFound
+	})
+}
+
+// Description:
+//
+//	Deep copies a decoded JSON value.
+//
+// Parameters:
+//
+//	value The value to copy.
+//
+// Returns:
+//
+//	The copy.
+func clone(value interface{}) interface{} {
+	switch node := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(node))
+		for key, child := range node {
+			result[key] = clone(child)
+		}
+
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(node))
+		for index, child := range node {
+			result[index] = clone(child)
+		}
+
+		return result
+	}
+
+	return value
+}
+
+// Description:
+//
+//	Wraps a decoding error of a patch document.
+//
+// Parameters:
+//
+//	err The decoding error.
+//
+// Returns:
+//
+//	An error matching ErrInvalidPatch.
+func wrapInvalidPatch(err error) error {
+	return fmt.Errorf("%w: %s", ErrInvalidPatch, err)
+}
@@ -0,0 +1,288 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// Description:
+//
+//	A patch test case.
+//	Documents, patches and results are given as JSON, like they are received and sent.
+type patchTest struct {
+
+	// The name of the test case.
+	name string
+
+	// The encoded document to patch.
+	document string
+
+	// The encoded patch.
+	patch string
+
+	// The encoded patched document. Ignored if an error is expected.
+	expected string
+
+	// The expected error, nil if the patch applies.
+	err error
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []patchTest{
+		{
+			name:     "add member",
+			document: `{"title":"Strobe"}`,
+			patch:    `[{"op":"add","path":"/label","value":"mau5trap"}]`,
+			expected: `{"title":"Strobe","label":"mau5trap"}`,
+		},
+		{
+			name:     "add replaces existing member",
+			document: `{"title":"Strobe"}`,
+			patch:    `[{"op":"add","path":"/title","value":"Ghosts"}]`,
+			expected: `{"title":"Ghosts"}`,
+		},
+		{
+			name:     "add inserts array element",
+			document: `{"ids":["a","c"]}`,
+			patch:    `[{"op":"add","path":"/ids/1","value":"b"}]`,
+			expected: `{"ids":["a","b","c"]}`,
+		},
+		{
+			name:     "add appends with end index",
+			document: `{"ids":["a"]}`,
+			patch:    `[{"op":"add","path":"/ids/-","value":"b"}]`,
+			expected: `{"ids":["a","b"]}`,
+		},
+		{
+			name:     "add beyond array end",
+			document: `{"ids":["a"]}`,
+			patch:    `[{"op":"add","path":"/ids/2","value":"b"}]`,
+			err:      ErrPathNotFound,
+		},
+		{
+			name:     "add below missing parent",
+			document: `{}`,
+			patch:    `[{"op":"add","path":"/stats/likes","value":1}]`,
+			err:      ErrPathNotFound,
+		},
+		{
+			name:     "remove member",
+			document: `{"title":"Strobe","label":"mau5trap"}`,
+			patch:    `[{"op":"remove","path":"/label"}]`,
+			expected: `{"title":"Strobe"}`,
+		},
+		{
+			name:     "remove array element",
+			document: `{"ids":["a","b","c"]}`,
+			patch:    `[{"op":"remove","path":"/ids/1"}]`,
+			expected: `{"ids":["a","c"]}`,
+		},
+		{
+			name:     "remove end index",
+			document: `{"ids":["a"]}`,
+			patch:    `[{"op":"remove","path":"/ids/-"}]`,
+			err:      ErrPathNotFound,
+		},
+		{
+			name:     "remove missing member",
+			document: `{}`,
+			patch:    `[{"op":"remove","path":"/label"}]`,
+			err:      ErrPathNotFound,
+		},
+		{
+			name:     "replace nested member",
+			document: `{"stats":{"likes":1,"streams":2}}`,
+			patch:    `[{"op":"replace","path":"/stats/likes","value":5}]`,
+			expected: `{"stats":{"likes":5,"streams":2}}`,
+		},
+		{
+			name:     "replace missing member",
+			document: `{}`,
+			patch:    `[{"op":"replace","path":"/label","value":"mau5trap"}]`,
+			err:      ErrPathNotFound,
+		},
+		{
+			name:     "replace leading zero index",
+			document: `{"ids":["a","b"]}`,
+			patch:    `[{"op":"replace","path":"/ids/01","value":"c"}]`,
+			err:      ErrPathNotFound,
+		},
+		{
+			name:     "move member",
+			document: `{"title":"Strobe","label":"mau5trap"}`,
+			patch:    `[{"op":"move","from":"/label","path":"/title"}]`,
+			expected: `{"title":"mau5trap"}`,
+		},
+		{
+			name:     "move array element",
+			document: `{"ids":["a","b","c"]}`,
+			patch:    `[{"op":"move","from":"/ids/0","path":"/ids/-"}]`,
+			expected: `{"ids":["b","c","a"]}`,
+		},
+		{
+			name:     "move into itself",
+			document: `{"stats":{"likes":1}}`,
+			patch:    `[{"op":"move","from":"/stats","path":"/stats/copy"}]`,
+			err:      ErrInvalidPatch,
+		},
+		{
+			name:     "copy member",
+			document: `{"stats":{"likes":1}}`,
+			patch:    `[{"op":"copy","from":"/stats","path":"/previous"}]`,
+			expected: `{"stats":{"likes":1},"previous":{"likes":1}}`,
+		},
+		{
+			name:     "copy missing member",
+			document: `{}`,
+			patch:    `[{"op":"copy","from":"/stats","path":"/previous"}]`,
+			err:      ErrPathNotFound,
+		},
+		{
+			name:     "test passes then applies",
+			document: `{"title":"Strobe","ids":["a"]}`,
+			patch:    `[{"op":"test","path":"/ids","value":["a"]},{"op":"replace","path":"/title","value":"Ghosts"}]`,
+			expected: `{"title":"Ghosts","ids":["a"]}`,
+		},
+		{
+			name:     "test fails",
+			document: `{"version":2}`,
+			patch:    `[{"op":"test","path":"/version","value":1}]`,
+			err:      ErrTestFailed,
+		},
+		{
+			name:     "test missing member",
+			document: `{}`,
+			patch:    `[{"op":"test","path":"/version","value":1}]`,
+			err:      ErrPathNotFound,
+		},
+		{
+			name:     "escaped tokens",
+			document: `{"a/b":1,"c~d":2}`,
+			patch:    `[{"op":"remove","path":"/a~1b"},{"op":"replace","path":"/c~0d","value":3}]`,
+			expected: `{"c~d":3}`,
+		},
+		{
+			name:     "escape decoding order",
+			document: `{"~1":1}`,
+			patch:    `[{"op":"remove","path":"/~01"}]`,
+			expected: `{}`,
+		},
+		{
+			name:     "empty path replaces document",
+			document: `{"title":"Strobe"}`,
+			patch:    `[{"op":"replace","path":"","value":{"title":"Ghosts"}}]`,
+			expected: `{"title":"Ghosts"}`,
+		},
+		{
+			name:     "failing operation discards earlier operations",
+			document: `{"title":"Strobe"}`,
+			patch:    `[{"op":"replace","path":"/title","value":"Ghosts"},{"op":"test","path":"/title","value":"Strobe"}]`,
+			err:      ErrTestFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			document := decode(t, test.document)
+
+			operations, err := ParseJSONPatch([]byte(test.patch))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			patched, err := ApplyJSONPatch(document, operations)
+
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("expected error '%s', received '%v'", test.err, err)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+
+				assertDocument(t, patched, test.expected)
+			}
+
+			assertDocument(t, document, test.document)
+		})
+	}
+}
+
+func TestParseJSONPatchRejectsMalformedPatches(t *testing.T) {
+	patches := []string{
+		`{"op":"add","path":"/title","value":"Strobe"}`,
+		`[{"path":"/title","value":"Strobe"}]`,
+		`[{"op":"add","value":"Strobe"}]`,
+		`[{"op":"add","path":"/title"}]`,
+		`[{"op":"move","path":"/title"}]`,
+		`[{"op":"frob","path":"/title"}]`,
+		`[`,
+	}
+
+	for _, patch := range patches {
+		_, err := ParseJSONPatch([]byte(patch))
+		if !errors.Is(err, ErrInvalidPatch) {
+			t.Errorf("expected patch '%s' to be rejected, received '%v'", patch, err)
+		}
+	}
+
+	operations, err := ParseJSONPatch([]byte(`[{"op":"add","path":"/label","value":null}]`))
+	if err != nil {
+		t.Fatalf("expected a null value to be accepted, received '%s'", err)
+	}
+
+	if operations[0].Value != nil {
+		t.Errorf("expected a null value, received '%v'", operations[0].Value)
+	}
+
+	_, err = ApplyJSONPatch(map[string]interface{}{}, []Operation{{Op: "remove", Path: "title"}})
+	if !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("expected a pointer without leading slash to be rejected, received '%v'", err)
+	}
+}
+
+// Description:
+//
+//	Decodes a JSON document, failing the test if decoding fails.
+//
+// Parameters:
+//
+//	t 			The test.
+//	encoded 	The encoded document.
+//
+// Returns:
+//
+//	The decoded document.
+func decode(t *testing.T, encoded string) interface{} {
+	t.Helper()
+
+	var decoded interface{}
+
+	err := json.Unmarshal([]byte(encoded), &decoded)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	return decoded
+}
+
+// Description:
+//
+//	Fails the test if a decoded document does not equal the expected encoded document.
+//
+// Parameters:
+//
+//	t 			The test.
+//	document 	The decoded document.
+//	expected 	The expected encoded document.
+func assertDocument(t *testing.T, document interface{}, expected string) {
+	t.Helper()
+
+	if !reflect.DeepEqual(document, decode(t, expected)) {
+		encoded, _ := json.Marshal(document)
+		t.Errorf("expected document '%s', received '%s'", expected, encoded)
+	}
+}
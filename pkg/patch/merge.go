@@ -0,0 +1,93 @@
+package patch
+
+import "encoding/json"
+
+const (
+
+	// The media type of a JSON merge patch (RFC 7396).
+	MergePatchMediaType = "application/merge-patch+json"
+
+	// The media type of a JSON patch (RFC 6902).
+	JSONPatchMediaType = "application/json-patch+json"
+)
+
+// Description:
+//
+//	Decodes a JSON merge patch (RFC 7396).
+//
+// Parameters:
+//
+//	patch The encoded merge patch.
+//
+// Returns:
+//
+//	The decoded merge patch.
+//	ErrInvalidPatch if the patch is not valid JSON.
+func ParseMergePatch(patch []byte) (interface{}, error) {
+	var decoded interface{}
+
+	err := json.Unmarshal(patch, &decoded)
+	if err != nil {
+		return nil, wrapInvalidPatch(err)
+	}
+
+	return decoded, nil
+}
+
+// Description:
+//
+//	Applies a JSON merge patch (RFC 7396) to a decoded JSON document.
+//	Members set to null in the patch are removed from the document.
+//
+// Parameters:
+//
+//	document 	The decoded JSON document to patch. Left unmodified.
+//	patch 		The decoded merge patch.
+//
+// Returns:
+//
+//	The patched document.
+func ApplyMergePatch(document interface{}, patch interface{}) interface{} {
+	return mergeValue(document, clone(patch))
+}
+
+// Description:
+//
+//	Merges a decoded patch value into a decoded document value.
+//	Patch values which are not objects replace the document value.
+//
+// Parameters:
+//
+//	document 	The decoded document value.
+//	patch 		The decoded patch value.
+//
+// Returns:
+//
+//	The merged value.
+func mergeValue(document interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	documentObject, ok := document.(map[string]interface{})
+	if !ok {
+		documentObject = make(map[string]interface{})
+	}
+
+	result := make(map[string]interface{}, len(documentObject))
+	for key, value := range documentObject {
+		result[key] = value
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+
+		result[key] = mergeValue(result[key], value)
+	}
+
+	return result
+}
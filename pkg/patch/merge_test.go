@@ -0,0 +1,92 @@
+package patch
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	tests := []patchTest{
+		{
+			name:     "replace member",
+			document: `{"title":"Strobe","label":"mau5trap"}`,
+			patch:    `{"title":"Ghosts"}`,
+			expected: `{"title":"Ghosts","label":"mau5trap"}`,
+		},
+		{
+			name:     "add member",
+			document: `{"title":"Strobe"}`,
+			patch:    `{"label":"mau5trap"}`,
+			expected: `{"title":"Strobe","label":"mau5trap"}`,
+		},
+		{
+			name:     "null removes member",
+			document: `{"title":"Strobe","label":"mau5trap"}`,
+			patch:    `{"label":null}`,
+			expected: `{"title":"Strobe"}`,
+		},
+		{
+			name:     "null removes missing member",
+			document: `{"title":"Strobe"}`,
+			patch:    `{"label":null}`,
+			expected: `{"title":"Strobe"}`,
+		},
+		{
+			name:     "nested merge",
+			document: `{"stats":{"likes":1,"streams":2}}`,
+			patch:    `{"stats":{"likes":5,"streams":null}}`,
+			expected: `{"stats":{"likes":5}}`,
+		},
+		{
+			name:     "nested merge into missing member",
+			document: `{}`,
+			patch:    `{"stats":{"likes":5,"streams":null}}`,
+			expected: `{"stats":{"likes":5}}`,
+		},
+		{
+			name:     "arrays are replaced",
+			document: `{"ids":["a","b"]}`,
+			patch:    `{"ids":["c"]}`,
+			expected: `{"ids":["c"]}`,
+		},
+		{
+			name:     "object replaces scalar",
+			document: `{"stats":1}`,
+			patch:    `{"stats":{"likes":5}}`,
+			expected: `{"stats":{"likes":5}}`,
+		},
+		{
+			name:     "non-object patch replaces document",
+			document: `{"title":"Strobe"}`,
+			patch:    `["Strobe"]`,
+			expected: `["Strobe"]`,
+		},
+		{
+			name:     "empty patch",
+			document: `{"title":"Strobe"}`,
+			patch:    `{}`,
+			expected: `{"title":"Strobe"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			document := decode(t, test.document)
+
+			patch, err := ParseMergePatch([]byte(test.patch))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			assertDocument(t, ApplyMergePatch(document, patch), test.expected)
+			assertDocument(t, document, test.document)
+		})
+	}
+}
+
+func TestParseMergePatchRejectsInvalidJSON(t *testing.T) {
+	_, err := ParseMergePatch([]byte(`{"title":`))
+	if !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("expected an invalid patch error, received '%v'", err)
+	}
+}
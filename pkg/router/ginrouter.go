@@ -1,14 +1,21 @@
 package router
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/parallel"
+	"github.com/revx-official/output/log"
 )
 
 // Description:
@@ -18,6 +25,22 @@ type GinRouter struct {
 
 	// The gin engine.
 	engine *gin.Engine
+
+	// The handlers of routes with custom methods, e.g. '/tracks:batch', keyed by method and path.
+	// Gin treats colons as path variables, so these routes are dispatched when no other route matches.
+	customRoutes map[string]gin.HandlerFunc
+
+	// The global middlewares, outermost first.
+	middlewares []Middleware
+
+	// The hook called for recovered panics. Nil if no hook is registered.
+	crashHandler CrashHandlerFunc
+
+	// The hooks called on shutdown, in order of registration.
+	shutdownHooks []ShutdownHookFunc
+
+	// The hooks called when the shutdown starts, in order of registration.
+	drainHooks []DrainHookFunc
 }
 
 // Description:
@@ -41,9 +64,14 @@ func NewGinRouter() *GinRouter {
 	engine.RedirectTrailingSlash = true
 	engine.RedirectFixedPath = true
 
-	return &GinRouter{
-		engine: engine,
+	router := &GinRouter{
+		engine:       engine,
+		customRoutes: make(map[string]gin.HandlerFunc),
 	}
+
+	engine.Use(router.recoverPanics)
+	engine.NoRoute(router.handleCustomRoute)
+	return router
 }
 
 // Description:
@@ -57,8 +85,8 @@ func NewGinRouter() *GinRouter {
 //	path   	The path to handle.
 //	handler	The handler responsible for handling the request.
 func (router *GinRouter) Handle(method string, path string, handler RouterHandlerFunc) {
-	router.engine.Handle(method, path, func(context *gin.Context) {
-		internalRouteHandler(path, context, handler)
+	router.HandleWith(method, path, func(request *api.APIRequest, _ interface{}) *api.APIResponse {
+		return handler(request)
 	})
 }
 
@@ -79,9 +107,11 @@ func (router *GinRouter) Handle(method string, path string, handler RouterHandle
 //
 //	The router injector which allows object injection for the registered endpoint.
 func (router *GinRouter) HandleWith(method string, path string, handler RouterInjectionHandlerFunc) *RouterInjector {
-	injector := &RouterInjector{}
+	injector := &RouterInjector{
+		Middlewares: append([]Middleware{}, router.middlewares...),
+	}
 
-	router.engine.Handle(method, path, func(context *gin.Context) {
+	router.register(method, path, func(context *gin.Context) {
 		internalRouteInjectionHandler(path, context, handler, injector)
 	})
 
@@ -90,55 +120,204 @@ func (router *GinRouter) HandleWith(method string, path string, handler RouterIn
 
 // Description:
 //
-//	Starts the HTTP server for this router and listens to all registered routes.
+//	Registers global middlewares, wrapping the handlers of all routes registered afterwards.
+//	Global middlewares run before group and route middlewares, in the order of registration.
+//
+// Parameters:
+//
+//	middlewares The middlewares to register.
+func (router *GinRouter) Use(middlewares ...Middleware) {
+	router.middlewares = append(router.middlewares, middlewares...)
+}
+
+// Description:
+//
+//	Creates a group of routes sharing a path prefix and middlewares.
+//
+// Parameters:
+//
+//	prefix 		The path prefix of the group.
+//	middlewares The middlewares of the group.
 //
 // Returns:
 //
-//	An error if serving the router fails.
-func (router *GinRouter) Run(port uint16) error {
-	portFmt := fmt.Sprintf(":%d", port)
+//	The route group.
+func (router *GinRouter) Group(prefix string, middlewares ...Middleware) *RouterGroup {
+	return NewRouterGroup(router, prefix, middlewares...)
+}
 
-	// server := &http.Server{
-	// 	Addr:    portFmt,
-	// 	Handler: router.engine,
-	// }
+// Description:
+//
+//	Registers the hook called for each panic recovered from a route handler,
+//	e.g. to forward crashes to an error tracker.
+//	The hook is called after the crash is logged and before the error response is written.
+//
+// Parameters:
+//
+//	hook The crash hook. Nil removes the hook.
+func (router *GinRouter) OnCrash(hook CrashHandlerFunc) {
+	router.crashHandler = hook
+}
 
-	// // Setting this to false apparently reduces memory usage.
-	// // However, setting this to true apparently is the standard and improves performance.
-	// server.SetKeepAlivesEnabled(true)
+// Description:
+//
+//	Registers a gin handler for the given method and path.
+//	Paths with custom methods, e.g. '/tracks:batch', are registered as custom routes.
+//
+// Parameters:
+//
+//	method 	The http method to handle.
+//	path   	The path to handle.
+//	handler	The gin handler.
+func (router *GinRouter) register(method string, path string, handler gin.HandlerFunc) {
+	if !hasCustomMethod(path) {
+		router.engine.Handle(method, path, handler)
+		return
+	}
 
-	// return server.ListenAndServe()
+	key := method + " " + path
+	if _, ok := router.customRoutes[key]; ok {
+		panic(fmt.Sprintf("router: custom route '%s' is already registered", key))
+	}
 
-	return router.engine.Run(portFmt)
+	router.customRoutes[key] = handler
 }
 
 // Description:
 //
-//	Internal handler method for incoming requests.
-//	Triggered by the gin framework.
+//	Dispatches requests which do not match any gin route to the custom routes.
+//	Falls back to gin's not found response if no custom route matches either.
 //
 // Parameters:
 //
-//	pathHandle 	The registered path handle.
-//	context 	The internal gin context.
-//	handler 	The registered handler function.
-func internalRouteHandler(pathHandle string, context *gin.Context, handler RouterHandlerFunc) {
-	request := context.Request
+//	context The internal gin context.
+func (router *GinRouter) handleCustomRoute(context *gin.Context) {
+	handler, ok := router.customRoutes[context.Request.Method+" "+context.Request.URL.Path]
+	if !ok {
+		return
+	}
 
-	internalRequest, err := transformRequest(pathHandle, request)
+	handler(context)
+}
 
-	if err != nil {
-		panic("router: cannot transform request")
+// Description:
+//
+//	Registers a hook called on shutdown, e.g. to stop background workers or to close database connections.
+//	Hooks are called after in-flight requests are drained, in reverse order of registration.
+//
+// Parameters:
+//
+//	hook The shutdown hook.
+func (router *GinRouter) OnShutdown(hook ShutdownHookFunc) {
+	router.shutdownHooks = append(router.shutdownHooks, hook)
+}
+
+// Description:
+//
+//	Registers a hook called when the shutdown starts, before in-flight requests are drained,
+//	e.g. to report the service as unready. Hooks are called in order of registration.
+//
+// Parameters:
+//
+//	hook The drain hook.
+func (router *GinRouter) OnDraining(hook DrainHookFunc) {
+	router.drainHooks = append(router.drainHooks, hook)
+}
+
+// Description:
+//
+//	Handles a single HTTP request with the registered routes, without starting a server.
+//	Implements http.Handler, e.g. for serving the router from tests.
+//
+// Parameters:
+//
+//	writer 	The response writer.
+//	request The request to handle.
+func (router *GinRouter) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	router.engine.ServeHTTP(writer, request)
+}
+
+// Description:
+//
+//	Starts the HTTP server for this router and listens to all registered routes.
+//	Blocks until the process receives SIGINT or SIGTERM, then shuts down gracefully:
+//	calls the drain hooks, keeps serving for the shutdown delay, so load balancers observe the
+//	unready state and stop routing new requests, then stops accepting new connections,
+//	drains in-flight requests and calls the shutdown hooks.
+//	Connections still active after the shutdown timeout are closed forcibly.
+//
+// Parameters:
+//
+//	port 			The port to listen on.
+//	shutdownDelay 	The duration between calling the drain hooks and closing the listener.
+//	shutdownTimeout The maximum duration of draining in-flight requests, and of calling the shutdown hooks.
+//
+// Returns:
+//
+//	An error if serving the router fails, in-flight requests cannot be drained in time or a shutdown hook fails.
+func (router *GinRouter) Run(port uint16, shutdownDelay time.Duration, shutdownTimeout time.Duration) error {
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: router.engine,
 	}
 
-	internalResponse := handler(internalRequest)
-	applyResponse(internalResponse, context)
+	signals, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	served := make(chan error, 1)
+
+	go func() {
+		served <- server.ListenAndServe()
+	}()
+
+	var serveErr error
+
+	select {
+	case serveErr = <-served:
+		log.Errorf("failed to serve: %s", serveErr)
+	case <-signals.Done():
+		log.Infof("received shutdown signal, draining in-flight requests ...")
+	}
+
+	// Restores the default signal handling, so a second signal terminates the process immediately.
+	stop()
+
+	for _, hook := range router.drainHooks {
+		hook()
+	}
+
+	if serveErr == nil && shutdownDelay > 0 {
+		log.Infof("waiting %s before closing the listener ...", shutdownDelay)
+		time.Sleep(shutdownDelay)
+	}
+
+	drainContext, cancelDrain := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelDrain()
+
+	drainErr := server.Shutdown(drainContext)
+	if drainErr != nil {
+		log.Warnf("failed to drain in-flight requests: %s", drainErr)
+		server.Close()
+	}
+
+	log.Infof("calling shutdown hooks ...")
+
+	hookContext, cancelHooks := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelHooks()
+
+	hookErr := callShutdownHooks(hookContext, router.shutdownHooks)
+	if hookErr != nil {
+		log.Errorf("failed to call shutdown hooks: %s", hookErr)
+	}
+
+	log.Infof("shutdown completed")
+	return errors.Join(serveErr, drainErr, hookErr)
 }
 
 // Description:
 //
 //	Internal handler method for incoming requests.
-//	Allows object injection for the route handler.
+//	Allows object injection for the route handler and runs the route middlewares.
 //	Triggered by the gin framework.
 //
 // Parameters:
@@ -150,13 +329,28 @@ func internalRouteHandler(pathHandle string, context *gin.Context, handler Route
 func internalRouteInjectionHandler(pathHandle string, context *gin.Context, handler RouterInjectionHandlerFunc, injector *RouterInjector) {
 	request := context.Request
 
-	internalRequest, err := transformRequest(pathHandle, request)
+	requestContext, cancel := createRequestContext(request, injector.Timeout)
+	defer cancel()
 
+	requestID := parallel.FromContext(requestContext).ID
+	context.Header(RequestIDHeader, requestID)
+
+	internalRequest, err := transformRequest(pathHandle, request)
 	if err != nil {
-		panic("router: cannot transform request")
+		log.Warnf("[%s] cannot transform request: %s", requestID, err)
+		applyResponse(&api.APIResponse{
+			StatusCode: http.StatusBadRequest,
+			Body: ErrorResponseBody{
+				Message:   "malformed request",
+				RequestID: requestID,
+			},
+		}, context)
+		return
 	}
 
-	internalResponse := handler(internalRequest, injector.Injector)
+	internalRequest.Context = requestContext
+
+	internalResponse := Chain(handler, injector.Middlewares...)(internalRequest, injector.Injector)
 	applyResponse(internalResponse, context)
 }
 
@@ -176,6 +370,7 @@ func transformRequest(pathHandle string, request *http.Request) (*api.APIRequest
 	result := api.APIRequest{
 		Url:             request.URL.String(),
 		Path:            request.URL.Path,
+		Route:           pathHandle,
 		Method:          request.Method,
 		Headers:         make(map[string]string),
 		PathParameters:  make(map[string]string),
@@ -193,12 +388,16 @@ func transformRequest(pathHandle string, request *http.Request) (*api.APIRequest
 
 	result.PathParameters = pathParameters
 
-	queryParameters, err := extractQueryParameters(request.URL.String())
+	queryParameterValues, err := extractQueryParameters(request.URL.RawQuery)
 	if err != nil {
 		return nil, err
 	}
 
-	result.QueryParameters = queryParameters
+	for key, values := range queryParameterValues {
+		result.QueryParameters[key] = values[0]
+	}
+
+	result.QueryParameterValues = queryParameterValues
 
 	defer request.Body.Close()
 
@@ -254,27 +453,64 @@ func extractPathParameters(handle string, path string) (map[string]string, error
 
 // Description:
 //
-//	Extracts all query parameters from the request path.
+//	Checks whether a path contains a custom method, i.e. a colon within a path segment.
+//	Colons at the start of a path segment denote path variables.
+//
+// Example:
+//   - handle: 	/tracks:batch
 //
 // Parameters:
 //
-//	path The actual request path.
+//	path The registered path handle.
 //
 // Returns:
 //
-//	A key-value map of the extracted query parameters.
-func extractQueryParameters(path string) (map[string]string, error) {
-	parameters := make(map[string]string)
-
-	parsedURL, err := url.Parse(path)
-	if err != nil {
-		return nil, err
+//	True, if the path contains a custom method.
+func hasCustomMethod(path string) bool {
+	for _, segment := range strings.Split(path, "/") {
+		if strings.Index(segment, ":") > 0 {
+			return true
+		}
 	}
 
-	query := parsedURL.Query()
+	return false
+}
+
+// Description:
+//
+//	Extracts all query parameters from the raw query of a request.
+//	Pairs are separated by '&' only. Unlike url.ParseQuery, semicolons are kept
+//	as part of the values, since they are meaningful in textual queries, e.g. 'q=a==1;b==2'.
+//
+// Parameters:
+//
+//	rawQuery The raw query, without the leading '?'.
+//
+// Returns:
+//
+//	A map of the extracted query parameters to their values, in order of occurrence.
+//	An error if a key or value is not properly escaped.
+func extractQueryParameters(rawQuery string) (map[string][]string, error) {
+	parameters := make(map[string][]string)
+
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+
+		key, value, _ := strings.Cut(pair, "=")
+
+		key, err := url.QueryUnescape(key)
+		if err != nil {
+			return nil, fmt.Errorf("router: malformed query parameter name: %w", err)
+		}
 
-	for key, values := range query {
-		parameters[key] = strings.Join(values, ",")
+		value, err = url.QueryUnescape(value)
+		if err != nil {
+			return nil, fmt.Errorf("router: malformed value of query parameter '%s': %w", key, err)
+		}
+
+		parameters[key] = append(parameters[key], value)
 	}
 
 	return parameters, nil
@@ -283,6 +519,8 @@ func extractQueryParameters(path string) (map[string]string, error) {
 // Description:
 //
 //	Applies a router response to the internal gin context.
+//	Raw bodies are written as is, all other bodies are encoded as JSON.
+//	Responses with status 1xx, 204 No Content or 304 Not Modified are written without body.
 //
 // Parameters:
 //
@@ -293,10 +531,39 @@ func applyResponse(response *api.APIResponse, context *gin.Context) {
 		context.Header(key, value)
 	}
 
-	if response.Body == nil {
+	if (response.Body == nil && response.RawBody == nil) || !bodyAllowedForStatus(response.StatusCode) {
 		context.Status(response.StatusCode)
 		return
 	}
 
+	if response.RawBody != nil {
+		context.Data(response.StatusCode, context.Writer.Header().Get("Content-Type"), response.RawBody)
+		return
+	}
+
 	context.JSON(response.StatusCode, response.Body)
 }
+
+// Description:
+//
+//	Checks whether a response with the given status code may carry a body.
+//
+// Parameters:
+//
+//	status The response status code.
+//
+// Returns:
+//
+//	True, if the response may carry a body.
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent:
+		return false
+	case status == http.StatusNotModified:
+		return false
+	}
+
+	return true
+}
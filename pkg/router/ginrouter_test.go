@@ -0,0 +1,56 @@
+package router
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestTransformRequestKeepsSemicolonsInQuery(t *testing.T) {
+	request := httptest.NewRequest("GET", "/tracks?q=audioFeatures.tempo=ge=120;(label==Spinnin,label==Armada)&limit=10", nil)
+
+	result, err := transformRequest("/tracks", request)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string]string{
+		"q":     "audioFeatures.tempo=ge=120;(label==Spinnin,label==Armada)",
+		"limit": "10",
+	}
+
+	if !reflect.DeepEqual(result.QueryParameters, expected) {
+		t.Errorf("expected query parameters %v, received %v", expected, result.QueryParameters)
+	}
+}
+
+func TestTransformRequestKeepsRepeatedQueryValues(t *testing.T) {
+	request := httptest.NewRequest("GET", "/tracks?q=a==1&q=b%3D%3D2&fields=title", nil)
+
+	result, err := transformRequest("/tracks", request)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string][]string{
+		"q":      {"a==1", "b==2"},
+		"fields": {"title"},
+	}
+
+	if !reflect.DeepEqual(result.QueryParameterValues, expected) {
+		t.Errorf("expected query parameter values %v, received %v", expected, result.QueryParameterValues)
+	}
+
+	if result.QueryParameters["q"] != "a==1" {
+		t.Errorf("expected first value 'a==1', received '%s'", result.QueryParameters["q"])
+	}
+}
+
+func TestTransformRequestRejectsMalformedQuery(t *testing.T) {
+	request := httptest.NewRequest("GET", "/tracks?q=%zz", nil)
+
+	_, err := transformRequest("/tracks", request)
+	if err == nil {
+		t.Fatal("expected an error for a malformed query")
+	}
+}
@@ -0,0 +1,118 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/revx-official/output/log"
+)
+
+// Description:
+//
+//	The error response body written by the router itself,
+//	e.g. for malformed requests or recovered panics.
+type ErrorResponseBody struct {
+
+	// The error message.
+	Message string `json:"message"`
+
+	// The id of the request, as returned in the X-Request-ID header.
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// Description:
+//
+//	A panic recovered while handling a request.
+type Crash struct {
+
+	// The value the handler panicked with.
+	Recovered interface{}
+
+	// The stack trace of the panicking goroutine.
+	Stack []byte
+
+	// The id of the request. Empty if the panic occurred before the request was assigned an id.
+	RequestID string
+
+	// The request method.
+	Method string
+
+	// The request path.
+	Path string
+}
+
+// Description:
+//
+//	Function definition for hooks called for recovered panics.
+type CrashHandlerFunc = func(crash *Crash)
+
+// Description:
+//
+//	Gin middleware recovering from panics in route handlers.
+//	Logs the panic with its stack trace, calls the crash hook and responds
+//	with an internal server error, unless the response is already written.
+//
+// Parameters:
+//
+//	context The internal gin context.
+func (router *GinRouter) recoverPanics(context *gin.Context) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+
+		// Aborted handlers are expected to end the connection without response, see net/http.
+		if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+			panic(recovered)
+		}
+
+		crash := &Crash{
+			Recovered: recovered,
+			Stack:     debug.Stack(),
+			RequestID: context.Writer.Header().Get(RequestIDHeader),
+			Method:    context.Request.Method,
+			Path:      context.Request.URL.Path,
+		}
+
+		log.Errorf("[%s] recovered from panic in %s %s: %v\n%s", crash.RequestID, crash.Method, crash.Path, crash.Recovered, crash.Stack)
+		router.notifyCrash(crash)
+
+		if context.Writer.Written() {
+			context.Abort()
+			return
+		}
+
+		context.AbortWithStatusJSON(http.StatusInternalServerError, ErrorResponseBody{
+			Message:   "internal server error",
+			RequestID: crash.RequestID,
+		})
+	}()
+
+	context.Next()
+}
+
+// Description:
+//
+//	Calls the crash hook, if registered.
+//	Panics of the hook itself are logged and dropped.
+//
+// Parameters:
+//
+//	crash The recovered panic.
+func (router *GinRouter) notifyCrash(crash *Crash) {
+	if router.crashHandler == nil {
+		return
+	}
+
+	defer func() {
+		recovered := recover()
+		if recovered != nil {
+			log.Errorf("[%s] crash hook panicked: %v", crash.RequestID, recovered)
+		}
+	}()
+
+	router.crashHandler(crash)
+}
@@ -1,6 +1,20 @@
 package router
 
-import "github.com/gostream-official/tracks/pkg/api"
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gostream-official/tracks/pkg/api"
+	"github.com/gostream-official/tracks/pkg/parallel"
+)
+
+const (
+
+	// The response header carrying the id of the request's parallel context.
+	RequestIDHeader = "X-Request-ID"
+)
 
 // Description:
 //
@@ -12,6 +26,23 @@ type RouterHandlerFunc = func(request *api.APIRequest) *api.APIResponse
 //	Function definition for router endpoint handlers, which support object injection.
 type RouterInjectionHandlerFunc = func(request *api.APIRequest, injector interface{}) *api.APIResponse
 
+// Description:
+//
+//	Function definition for router middlewares.
+//	A middleware wraps a handler, it may act before and after calling the next handler
+//	or answer the request itself without calling it.
+type Middleware = func(next RouterInjectionHandlerFunc) RouterInjectionHandlerFunc
+
+// Description:
+//
+//	Function definition for hooks called when the shutdown starts, before in-flight requests are drained.
+type DrainHookFunc = func()
+
+// Description:
+//
+//	Function definition for hooks called on shutdown, after in-flight requests are drained.
+type ShutdownHookFunc = func(ctx context.Context) error
+
 // Description:
 //
 //	The router interface.
@@ -47,14 +78,78 @@ type Router interface {
 	//	The router injector which allows object injection for the registered endpoint.
 	HandleWith(method string, path string, handler RouterInjectionHandlerFunc) *RouterInjector
 
+	// Description:
+	//
+	//	Registers global middlewares, wrapping the handlers of all routes registered afterwards.
+	//	Global middlewares run before group and route middlewares, in the order of registration.
+	//
+	// Parameters:
+	//
+	//	middlewares The middlewares to register.
+	Use(middlewares ...Middleware)
+
+	// Description:
+	//
+	//	Creates a group of routes sharing a path prefix and middlewares.
+	//
+	// Parameters:
+	//
+	//	prefix 		The path prefix of the group.
+	//	middlewares The middlewares of the group.
+	//
+	// Returns:
+	//
+	//	The route group.
+	Group(prefix string, middlewares ...Middleware) *RouterGroup
+
+	// Description:
+	//
+	//	Registers the hook called for each panic recovered from a route handler,
+	//	e.g. to forward crashes to an error tracker.
+	//	Panics are always logged and answered with an internal server error.
+	//
+	// Parameters:
+	//
+	//	hook The crash hook. Nil removes the hook.
+	OnCrash(hook CrashHandlerFunc)
+
+	// Description:
+	//
+	//	Registers a hook called on shutdown, e.g. to stop background workers or to close database connections.
+	//	Hooks are called after in-flight requests are drained, in reverse order of registration.
+	//
+	// Parameters:
+	//
+	//	hook The shutdown hook.
+	OnShutdown(hook ShutdownHookFunc)
+
+	// Description:
+	//
+	//	Registers a hook called when the shutdown starts, before in-flight requests are drained,
+	//	e.g. to report the service as unready. Hooks are called in order of registration.
+	//
+	// Parameters:
+	//
+	//	hook The drain hook.
+	OnDraining(hook DrainHookFunc)
+
 	// Description:
 	//
 	//	Starts the HTTP server for this router and listens to all registered routes.
+	//	Blocks until the process receives SIGINT or SIGTERM, then shuts down gracefully:
+	//	calls the drain hooks and keeps serving for the shutdown delay,
+	//	then stops accepting new connections, drains in-flight requests and calls the shutdown hooks.
+	//
+	// Parameters:
+	//
+	//	port 			The port to listen on.
+	//	shutdownDelay 	The duration between calling the drain hooks and closing the listener.
+	//	shutdownTimeout The maximum duration of draining in-flight requests, and of calling the shutdown hooks.
 	//
 	// Returns:
 	//
-	//	An error if serving the router fails.
-	Run(port uint16) error
+	//	An error if serving the router fails, in-flight requests cannot be drained in time or a shutdown hook fails.
+	Run(port uint16, shutdownDelay time.Duration, shutdownTimeout time.Duration) error
 }
 
 // Description:
@@ -65,6 +160,30 @@ type RouterInjector struct {
 
 	// The object to inject.
 	Injector interface{}
+
+	// The maximum duration of a request to this endpoint.
+	// Zero means the request is not bounded.
+	Timeout time.Duration
+
+	// The middlewares wrapping the endpoint handler, outermost first.
+	// Starts with the global and group middlewares at the time the endpoint is registered.
+	Middlewares []Middleware
+}
+
+// Description:
+//
+//	A group of routes sharing a path prefix and middlewares.
+//	Built on the Router interface, so all router implementations behave identically.
+type RouterGroup struct {
+
+	// The router routes are registered on.
+	router Router
+
+	// The path prefix of the group.
+	prefix string
+
+	// The middlewares of the group, outermost first.
+	middlewares []Middleware
 }
 
 // Description:
@@ -78,6 +197,110 @@ func Default() Router {
 	return NewGinRouter()
 }
 
+// Description:
+//
+//	Creates a route group on a router.
+//	Used by router implementations to implement Router.Group.
+//
+// Parameters:
+//
+//	router 		The router routes are registered on.
+//	prefix 		The path prefix of the group.
+//	middlewares The middlewares of the group.
+//
+// Returns:
+//
+//	The route group.
+func NewRouterGroup(router Router, prefix string, middlewares ...Middleware) *RouterGroup {
+	return &RouterGroup{
+		router:      router,
+		prefix:      prefix,
+		middlewares: append([]Middleware{}, middlewares...),
+	}
+}
+
+// Description:
+//
+//	Wraps a handler with middlewares.
+//	The first middleware is the outermost one and sees the request first.
+//
+// Parameters:
+//
+//	handler 	The handler to wrap.
+//	middlewares The middlewares, outermost first.
+//
+// Returns:
+//
+//	The wrapped handler.
+func Chain(handler RouterInjectionHandlerFunc, middlewares ...Middleware) RouterInjectionHandlerFunc {
+	for index := len(middlewares) - 1; index >= 0; index-- {
+		handler = middlewares[index](handler)
+	}
+
+	return handler
+}
+
+// Description:
+//
+//	Registers middlewares of the group, wrapping the handlers of all group routes registered afterwards.
+//
+// Parameters:
+//
+//	middlewares The middlewares to register.
+func (group *RouterGroup) Use(middlewares ...Middleware) {
+	group.middlewares = append(group.middlewares, middlewares...)
+}
+
+// Description:
+//
+//	Creates a nested group of routes.
+//	The nested group extends the path prefix and runs its middlewares after the ones of this group.
+//
+// Parameters:
+//
+//	prefix 		The path prefix of the nested group, relative to this group.
+//	middlewares The middlewares of the nested group.
+//
+// Returns:
+//
+//	The nested route group.
+func (group *RouterGroup) Group(prefix string, middlewares ...Middleware) *RouterGroup {
+	return NewRouterGroup(group.router, group.prefix+prefix, append(append([]Middleware{}, group.middlewares...), middlewares...)...)
+}
+
+// Description:
+//
+//	Registers a new HTTP handler function for the given method and path within the group.
+//
+// Parameters:
+//
+//	method 	The http method to handle.
+//	path   	The path to handle, relative to the group.
+//	handler	The handler responsible for handling the request.
+func (group *RouterGroup) Handle(method string, path string, handler RouterHandlerFunc) {
+	group.HandleWith(method, path, func(request *api.APIRequest, _ interface{}) *api.APIResponse {
+		return handler(request)
+	})
+}
+
+// Description:
+//
+//	Registers a new HTTP handler function for the given method and path within the group.
+//	This method allows object injection for the router handler.
+//
+// Parameters:
+//
+//	method 	The http method to handle.
+//	path   	The path to handle, relative to the group.
+//	handler	The handler responsible for handling the request.
+//
+// Returns:
+//
+//	The router injector which allows object injection for the registered endpoint.
+func (group *RouterGroup) HandleWith(method string, path string, handler RouterInjectionHandlerFunc) *RouterInjector {
+	return group.router.HandleWith(method, group.prefix+path, handler).Use(group.middlewares...)
+}
+
 // Description:
 //
 //	Injects the given object to the endpoint this method is called on.
@@ -85,6 +308,95 @@ func Default() Router {
 // Parameters:
 //
 //	object The object to inject.
-func (handler *RouterInjector) Inject(object interface{}) {
+//
+// Returns:
+//
+//	The router injector, allowing further configuration of the endpoint.
+func (handler *RouterInjector) Inject(object interface{}) *RouterInjector {
 	handler.Injector = object
+	return handler
+}
+
+// Description:
+//
+//	Bounds the duration of requests to the endpoint this method is called on.
+//	The request context passed to the handler is cancelled once the timeout elapses.
+//
+// Parameters:
+//
+//	timeout The maximum request duration. Zero disables the timeout.
+//
+// Returns:
+//
+//	The router injector, allowing further configuration of the endpoint.
+func (handler *RouterInjector) WithTimeout(timeout time.Duration) *RouterInjector {
+	handler.Timeout = timeout
+	return handler
+}
+
+// Description:
+//
+//	Registers middlewares of the endpoint this method is called on.
+//	Route middlewares run after the global and group middlewares, in the order of registration.
+//
+// Parameters:
+//
+//	middlewares The middlewares to register.
+//
+// Returns:
+//
+//	The router injector, allowing further configuration of the endpoint.
+func (handler *RouterInjector) Use(middlewares ...Middleware) *RouterInjector {
+	handler.Middlewares = append(handler.Middlewares, middlewares...)
+	return handler
+}
+
+// Description:
+//
+//	Calls shutdown hooks in reverse order of registration.
+//	All hooks are called, even if a hook fails.
+//
+// Parameters:
+//
+//	ctx 	The context bounding the shutdown.
+//	hooks 	The hooks, in order of registration.
+//
+// Returns:
+//
+//	The errors of all failed hooks, nil if all hooks succeed.
+func callShutdownHooks(ctx context.Context, hooks []ShutdownHookFunc) error {
+	errs := make([]error, 0)
+
+	for index := len(hooks) - 1; index >= 0; index-- {
+		err := hooks[index](ctx)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Description:
+//
+//	Derives the context for an incoming request.
+//	The derived context carries a new parallel context and is cancelled
+//	when the client disconnects or the timeout elapses.
+//
+// Parameters:
+//
+//	request The incoming HTTP request.
+//	timeout The maximum request duration. Zero disables the timeout.
+//
+// Returns:
+//
+//	The request context and the function releasing its resources.
+func createRequestContext(request *http.Request, timeout time.Duration) (context.Context, context.CancelFunc) {
+	requestContext := parallel.WithContext(request.Context(), parallel.NewContext())
+
+	if timeout <= 0 {
+		return context.WithCancel(requestContext)
+	}
+
+	return context.WithTimeout(requestContext, timeout)
 }
@@ -0,0 +1,643 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gostream-official/tracks/pkg/store/query"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Description:
+//
+//	The operator of a group accumulator.
+type AccumulatorOperator string
+
+const (
+
+	// Counts the documents of a group.
+	AccumulatorCount AccumulatorOperator = "$count"
+
+	// Sums the numeric values of a group. Non-numeric values are ignored.
+	AccumulatorSum AccumulatorOperator = "$sum"
+
+	// Averages the numeric values of a group. Non-numeric values are ignored.
+	AccumulatorAvg AccumulatorOperator = "$avg"
+
+	// Selects the lowest value of a group. Null and missing values are ignored.
+	AccumulatorMin AccumulatorOperator = "$min"
+
+	// Selects the highest value of a group. Null and missing values are ignored.
+	AccumulatorMax AccumulatorOperator = "$max"
+)
+
+// Description:
+//
+//	An aggregation pipeline.
+//	Built stage by stage, e.g. NewAggregation().Match(filter).Group(group).Sort(keys...).
+type Aggregation struct {
+
+	// The stages, applied in order.
+	Stages []AggregationStage
+}
+
+// Description:
+//
+//	A single stage of an aggregation pipeline.
+type AggregationStage interface {
+
+	// Description:
+	//
+	//	Compiles the stage into a MongoDB BSON document.
+	//
+	// Returns:
+	//
+	//	A MongoDB bson document representing the stage.
+	Compile() bson.M
+
+	// Description:
+	//
+	//	Applies the stage to in-memory documents.
+	//
+	// Parameters:
+	//
+	//	documents The input documents of the stage.
+	//
+	// Returns:
+	//
+	//	The output documents of the stage.
+	Apply(documents []bson.M) []bson.M
+}
+
+// Description:
+//
+//	An expression computing a value from a document.
+type Expression interface {
+
+	// Description:
+	//
+	//	Compiles the expression into a MongoDB aggregation expression.
+	//
+	// Returns:
+	//
+	//	A MongoDB aggregation expression.
+	Compile() interface{}
+
+	// Description:
+	//
+	//	Evaluates the expression against an in-memory document.
+	//
+	// Parameters:
+	//
+	//	document The document to evaluate against.
+	//
+	// Returns:
+	//
+	//	The computed value, nil if the value is missing.
+	Evaluate(document bson.M) interface{}
+}
+
+// Description:
+//
+//	The 'match' stage. Passes the documents matching a filter.
+type MatchStage struct {
+
+	// The aggregation stage interface implementation.
+	AggregationStage
+
+	// The filter documents must match.
+	Filter query.IQuery
+}
+
+// Description:
+//
+//	The 'group' stage. Groups documents by a key and accumulates values per group.
+//	Outputs one document per group, with the group key as '_id' and one field per accumulator.
+type GroupStage struct {
+
+	// The aggregation stage interface implementation.
+	AggregationStage
+
+	// The expression computing the group key. All documents form a single group if nil.
+	By Expression
+
+	// The accumulators computing the output fields.
+	Accumulators []Accumulator
+}
+
+// Description:
+//
+//	The 'sort' stage. Sorts the documents.
+type SortStage struct {
+
+	// The aggregation stage interface implementation.
+	AggregationStage
+
+	// The sort specification.
+	Keys []query.SortKey
+}
+
+// Description:
+//
+//	The 'limit' stage. Passes the first documents only.
+type LimitStage struct {
+
+	// The aggregation stage interface implementation.
+	AggregationStage
+
+	// The maximum number of documents.
+	Limit int64
+}
+
+// Description:
+//
+//	Accumulates the values of a group into an output field.
+type Accumulator struct {
+
+	// The output field.
+	Field string
+
+	// The accumulator operator.
+	Operator AccumulatorOperator
+
+	// The document key of the accumulated values. Ignored for AccumulatorCount.
+	Key string
+}
+
+// Description:
+//
+//	The value of a document field.
+type FieldExpression struct {
+
+	// The expression interface implementation.
+	Expression
+
+	// The document key to refer to.
+	Key string
+}
+
+// Description:
+//
+//	The year of a date field, in UTC.
+type YearExpression struct {
+
+	// The expression interface implementation.
+	Expression
+
+	// The document key of the date field.
+	Key string
+}
+
+// Description:
+//
+//	Creates a new, empty aggregation pipeline.
+//
+// Returns:
+//
+//	The created aggregation pipeline.
+func NewAggregation() *Aggregation {
+	return &Aggregation{
+		Stages: make([]AggregationStage, 0),
+	}
+}
+
+// Description:
+//
+//	Appends a 'match' stage.
+//
+// Parameters:
+//
+//	filter The filter documents must match. The stage is skipped if nil.
+//
+// Returns:
+//
+//	The aggregation pipeline.
+func (aggregation *Aggregation) Match(filter query.IQuery) *Aggregation {
+	if filter == nil {
+		return aggregation
+	}
+
+	aggregation.Stages = append(aggregation.Stages, MatchStage{Filter: filter})
+	return aggregation
+}
+
+// Description:
+//
+//	Appends a 'group' stage.
+//
+// Parameters:
+//
+//	by 				The expression computing the group key. All documents form a single group if nil.
+//	accumulators 	The accumulators computing the output fields.
+//
+// Returns:
+//
+//	The aggregation pipeline.
+func (aggregation *Aggregation) Group(by Expression, accumulators ...Accumulator) *Aggregation {
+	aggregation.Stages = append(aggregation.Stages, GroupStage{By: by, Accumulators: accumulators})
+	return aggregation
+}
+
+// Description:
+//
+//	Appends a 'sort' stage.
+//
+// Parameters:
+//
+//	keys The sort specification.
+//
+// Returns:
+//
+//	The aggregation pipeline.
+func (aggregation *Aggregation) Sort(keys ...query.SortKey) *Aggregation {
+	aggregation.Stages = append(aggregation.Stages, SortStage{Keys: keys})
+	return aggregation
+}
+
+// Description:
+//
+//	Appends a 'limit' stage.
+//
+// Parameters:
+//
+//	limit The maximum number of documents.
+//
+// Returns:
+//
+//	The aggregation pipeline.
+func (aggregation *Aggregation) Limit(limit int64) *Aggregation {
+	aggregation.Stages = append(aggregation.Stages, LimitStage{Limit: limit})
+	return aggregation
+}
+
+// Description:
+//
+//	Compiles the aggregation pipeline into MongoDB BSON documents.
+//
+// Returns:
+//
+//	The MongoDB aggregation pipeline.
+func (aggregation *Aggregation) Compile() bson.A {
+	pipeline := bson.A{}
+
+	for _, stage := range aggregation.Stages {
+		pipeline = append(pipeline, stage.Compile())
+	}
+
+	return pipeline
+}
+
+// Description:
+//
+//	Applies the aggregation pipeline to in-memory documents.
+//
+// Parameters:
+//
+//	documents The input documents. They are not modified.
+//
+// Returns:
+//
+//	The output documents of the last stage.
+func (aggregation *Aggregation) Apply(documents []bson.M) []bson.M {
+	for _, stage := range aggregation.Stages {
+		documents = stage.Apply(documents)
+	}
+
+	return documents
+}
+
+// Description:
+//
+//	Compiles the stage into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing the stage.
+func (stage MatchStage) Compile() bson.M {
+	return bson.M{"$match": stage.Filter.Compile()}
+}
+
+// Description:
+//
+//	Compiles the stage into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing the stage.
+func (stage GroupStage) Compile() bson.M {
+	group := bson.M{"_id": nil}
+
+	if stage.By != nil {
+		group["_id"] = stage.By.Compile()
+	}
+
+	for _, accumulator := range stage.Accumulators {
+		if accumulator.Operator == AccumulatorCount {
+			group[accumulator.Field] = bson.M{"$sum": 1}
+		} else {
+			group[accumulator.Field] = bson.M{string(accumulator.Operator): "$" + accumulator.Key}
+		}
+	}
+
+	return bson.M{"$group": group}
+}
+
+// Description:
+//
+//	Compiles the stage into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing the stage.
+func (stage SortStage) Compile() bson.M {
+	return bson.M{"$sort": query.CompileSort(stage.Keys)}
+}
+
+// Description:
+//
+//	Compiles the stage into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing the stage.
+func (stage LimitStage) Compile() bson.M {
+	return bson.M{"$limit": stage.Limit}
+}
+
+// Description:
+//
+//	Applies the stage to in-memory documents.
+//
+// Parameters:
+//
+//	documents The input documents of the stage.
+//
+// Returns:
+//
+//	The documents matching the filter.
+func (stage MatchStage) Apply(documents []bson.M) []bson.M {
+	result := make([]bson.M, 0)
+
+	for _, document := range documents {
+		if stage.Filter.Evaluate(document) {
+			result = append(result, document)
+		}
+	}
+
+	return result
+}
+
+// Description:
+//
+//	Applies the stage to in-memory documents.
+//
+// Parameters:
+//
+//	documents The input documents of the stage.
+//
+// Returns:
+//
+//	One document per group, in order of the first occurrence of the group key.
+func (stage GroupStage) Apply(documents []bson.M) []bson.M {
+	keys := make([]interface{}, 0)
+	groups := make([][]bson.M, 0)
+
+	for _, document := range documents {
+		var key interface{}
+
+		if stage.By != nil {
+			key = stage.By.Evaluate(document)
+		}
+
+		index := 0
+		for index < len(keys) && query.CompareValues(keys[index], key) != 0 {
+			index++
+		}
+
+		if index == len(keys) {
+			keys = append(keys, key)
+			groups = append(groups, make([]bson.M, 0))
+		}
+
+		groups[index] = append(groups[index], document)
+	}
+
+	result := make([]bson.M, len(groups))
+
+	for index, group := range groups {
+		output := bson.M{"_id": keys[index]}
+
+		for _, accumulator := range stage.Accumulators {
+			output[accumulator.Field] = accumulator.apply(group)
+		}
+
+		result[index] = output
+	}
+
+	return result
+}
+
+// Description:
+//
+//	Applies the stage to in-memory documents.
+//
+// Parameters:
+//
+//	documents The input documents of the stage.
+//
+// Returns:
+//
+//	The sorted documents.
+func (stage SortStage) Apply(documents []bson.M) []bson.M {
+	result := append([]bson.M{}, documents...)
+	query.SortDocuments(result, stage.Keys)
+
+	return result
+}
+
+// Description:
+//
+//	Applies the stage to in-memory documents.
+//
+// Parameters:
+//
+//	documents The input documents of the stage.
+//
+// Returns:
+//
+//	The first documents.
+func (stage LimitStage) Apply(documents []bson.M) []bson.M {
+	if stage.Limit >= 0 && int64(len(documents)) > stage.Limit {
+		return documents[:stage.Limit]
+	}
+
+	return documents
+}
+
+// Description:
+//
+//	Compiles the expression into a MongoDB aggregation expression.
+//
+// Returns:
+//
+//	A MongoDB aggregation expression.
+func (expression FieldExpression) Compile() interface{} {
+	return "$" + expression.Key
+}
+
+// Description:
+//
+//	Compiles the expression into a MongoDB aggregation expression.
+//
+// Returns:
+//
+//	A MongoDB aggregation expression.
+func (expression YearExpression) Compile() interface{} {
+	return bson.M{"$year": "$" + expression.Key}
+}
+
+// Description:
+//
+//	Evaluates the expression against an in-memory document.
+//
+// Parameters:
+//
+//	document The document to evaluate against.
+//
+// Returns:
+//
+//	The field value, nil if the field is missing.
+func (expression FieldExpression) Evaluate(document bson.M) interface{} {
+	value, ok := query.GetPath(document, expression.Key)
+	if !ok {
+		return nil
+	}
+
+	return query.Canonical(value)
+}
+
+// Description:
+//
+//	Evaluates the expression against an in-memory document.
+//
+// Parameters:
+//
+//	document The document to evaluate against.
+//
+// Returns:
+//
+//	The year, nil if the field is missing or not a date.
+func (expression YearExpression) Evaluate(document bson.M) interface{} {
+	value, ok := query.GetPath(document, expression.Key)
+	if !ok {
+		return nil
+	}
+
+	date, ok := query.Canonical(value).(primitive.DateTime)
+	if !ok {
+		return nil
+	}
+
+	return int32(date.Time().UTC().Year())
+}
+
+// Description:
+//
+//	Accumulates the values of a group.
+//
+// Parameters:
+//
+//	documents The documents of the group.
+//
+// Returns:
+//
+//	The accumulated value.
+func (accumulator Accumulator) apply(documents []bson.M) interface{} {
+	if accumulator.Operator == AccumulatorCount {
+		return int32(len(documents))
+	}
+
+	var result interface{}
+	var sum interface{} = int32(0)
+	count := 0
+
+	for _, document := range documents {
+		value, ok := query.GetPath(document, accumulator.Key)
+		if !ok || value == nil {
+			continue
+		}
+
+		value = query.Canonical(value)
+
+		switch accumulator.Operator {
+		case AccumulatorSum, AccumulatorAvg:
+			if query.IsNumber(value) {
+				sum = query.AddNumbers(sum, value)
+				count++
+			}
+		case AccumulatorMin:
+			if result == nil || query.CompareValues(value, result) < 0 {
+				result = value
+			}
+		case AccumulatorMax:
+			if result == nil || query.CompareValues(value, result) > 0 {
+				result = value
+			}
+		}
+	}
+
+	switch accumulator.Operator {
+	case AccumulatorSum:
+		return sum
+	case AccumulatorAvg:
+		if count == 0 {
+			return nil
+		}
+
+		return query.ToFloat(sum) / float64(count)
+	}
+
+	return result
+}
+
+// Description:
+//
+//	Runs an aggregation pipeline and decodes the output documents.
+//
+// Parameters:
+//
+//	ctx 		The context of the operation.
+//	store 		The store to aggregate.
+//	aggregation The aggregation pipeline.
+//
+// Type Parameters:
+//
+//	T The type of documents stored in the store.
+//	R The type of the output documents.
+//
+// Returns:
+//
+//	The decoded output documents.
+//	An error if the aggregation fails or an output document cannot be decoded.
+func AggregateInto[R interface{}, T interface{}](ctx context.Context, store Store[T], aggregation *Aggregation) ([]R, error) {
+	documents, err := store.Aggregate(ctx, aggregation)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]R, 0, len(documents))
+
+	for _, document := range documents {
+		var result R
+
+		err = query.FromDocument(document, &result)
+		if err != nil {
+			return nil, fmt.Errorf("store: cannot decode aggregation result: %w", err)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,159 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gostream-official/tracks/pkg/store/query"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Description:
+//
+//	An aggregation test case.
+//	The expected output follows the documented MongoDB semantics of the compiled pipeline,
+//	so the in-memory evaluation is checked against what MongoDB would return.
+type aggregationTest struct {
+
+	// The name of the test case.
+	name string
+
+	// The aggregation pipeline under test.
+	aggregation *Aggregation
+
+	// The expected compiled MongoDB pipeline.
+	compiled bson.A
+
+	// The input documents.
+	documents []bson.M
+
+	// The output documents MongoDB returns for the input documents.
+	// Groups are listed in order of the first occurrence of their key, MongoDB returns them unordered.
+	expected []bson.M
+}
+
+func TestAggregationGroupBy(t *testing.T) {
+	tracks := []bson.M{
+		{"_id": "1", "artistId": "a", "label": "mau5trap", "releaseDate": date(2009, 9, 22), "audioFeatures": bson.M{"key": "A Minor", "tempo": 128.0}, "trackStats": bson.M{"streams": int64(10)}},
+		{"_id": "2", "artistId": "a", "label": "mau5trap", "releaseDate": date(2009, 12, 31), "audioFeatures": bson.M{"key": "C Major", "tempo": 126.0}, "trackStats": bson.M{"streams": int64(20)}},
+		{"_id": "3", "artistId": "b", "label": "Armada", "releaseDate": date(2010, 1, 1), "audioFeatures": bson.M{"key": "A Minor", "tempo": 140.0}, "trackStats": bson.M{"streams": int64(30)}},
+		{"_id": "4", "artistId": "b", "label": nil, "audioFeatures": bson.M{"tempo": nil}},
+	}
+
+	tests := []aggregationTest{
+		{
+			name:        "release year groups dates by their year and missing dates as null",
+			aggregation: NewAggregation().Group(YearExpression{Key: "releaseDate"}, Accumulator{Field: "count", Operator: AccumulatorCount}),
+			compiled:    bson.A{bson.M{"$group": bson.M{"_id": bson.M{"$year": "$releaseDate"}, "count": bson.M{"$sum": 1}}}},
+			documents:   tracks,
+			expected:    []bson.M{{"_id": int32(2009), "count": int32(2)}, {"_id": int32(2010), "count": int32(1)}, {"_id": nil, "count": int32(1)}},
+		},
+		{
+			name:        "release year uses the UTC year",
+			aggregation: NewAggregation().Group(YearExpression{Key: "releaseDate"}, Accumulator{Field: "count", Operator: AccumulatorCount}),
+			compiled:    bson.A{bson.M{"$group": bson.M{"_id": bson.M{"$year": "$releaseDate"}, "count": bson.M{"$sum": 1}}}},
+			documents:   []bson.M{{"releaseDate": primitive.NewDateTimeFromTime(time.Date(2010, 1, 1, 0, 30, 0, 0, time.FixedZone("CET", 3600)))}},
+			expected:    []bson.M{{"_id": int32(2009), "count": int32(1)}},
+		},
+		{
+			name:        "label groups null and missing labels together",
+			aggregation: NewAggregation().Group(FieldExpression{Key: "label"}, Accumulator{Field: "count", Operator: AccumulatorCount}),
+			compiled:    bson.A{bson.M{"$group": bson.M{"_id": "$label", "count": bson.M{"$sum": 1}}}},
+			documents:   append(tracks, bson.M{"_id": "5"}),
+			expected:    []bson.M{{"_id": "mau5trap", "count": int32(2)}, {"_id": "Armada", "count": int32(1)}, {"_id": nil, "count": int32(2)}},
+		},
+		{
+			name:        "key groups by a nested field",
+			aggregation: NewAggregation().Group(FieldExpression{Key: "audioFeatures.key"}, Accumulator{Field: "count", Operator: AccumulatorCount}),
+			compiled:    bson.A{bson.M{"$group": bson.M{"_id": "$audioFeatures.key", "count": bson.M{"$sum": 1}}}},
+			documents:   tracks,
+			expected:    []bson.M{{"_id": "A Minor", "count": int32(2)}, {"_id": "C Major", "count": int32(1)}, {"_id": nil, "count": int32(1)}},
+		},
+		{
+			name: "artist accumulates numbers and ignores null and missing values",
+			aggregation: NewAggregation().Group(FieldExpression{Key: "artistId"},
+				Accumulator{Field: "totalStreams", Operator: AccumulatorSum, Key: "trackStats.streams"},
+				Accumulator{Field: "averageTempo", Operator: AccumulatorAvg, Key: "audioFeatures.tempo"},
+				Accumulator{Field: "minTempo", Operator: AccumulatorMin, Key: "audioFeatures.tempo"},
+				Accumulator{Field: "maxReleaseDate", Operator: AccumulatorMax, Key: "releaseDate"},
+			),
+			compiled: bson.A{bson.M{"$group": bson.M{
+				"_id":            "$artistId",
+				"totalStreams":   bson.M{"$sum": "$trackStats.streams"},
+				"averageTempo":   bson.M{"$avg": "$audioFeatures.tempo"},
+				"minTempo":       bson.M{"$min": "$audioFeatures.tempo"},
+				"maxReleaseDate": bson.M{"$max": "$releaseDate"},
+			}}},
+			documents: tracks,
+			expected: []bson.M{
+				{"_id": "a", "totalStreams": int64(30), "averageTempo": 127.0, "minTempo": 126.0, "maxReleaseDate": date(2009, 12, 31)},
+				{"_id": "b", "totalStreams": int64(30), "averageTempo": 140.0, "minTempo": 140.0, "maxReleaseDate": date(2010, 1, 1)},
+			},
+		},
+		{
+			name: "accumulators without values",
+			aggregation: NewAggregation().Group(nil,
+				Accumulator{Field: "totalLikes", Operator: AccumulatorSum, Key: "trackStats.likes"},
+				Accumulator{Field: "averageTempo", Operator: AccumulatorAvg, Key: "audioFeatures.tempo"},
+				Accumulator{Field: "maxTempo", Operator: AccumulatorMax, Key: "audioFeatures.tempo"},
+			),
+			compiled: bson.A{bson.M{"$group": bson.M{
+				"_id":          nil,
+				"totalLikes":   bson.M{"$sum": "$trackStats.likes"},
+				"averageTempo": bson.M{"$avg": "$audioFeatures.tempo"},
+				"maxTempo":     bson.M{"$max": "$audioFeatures.tempo"},
+			}}},
+			documents: tracks[3:],
+			expected:  []bson.M{{"_id": nil, "totalLikes": int32(0), "averageTempo": nil, "maxTempo": nil}},
+		},
+		{
+			name: "match, group, sort and limit",
+			aggregation: NewAggregation().
+				Match(query.FilterOperatorExists{Key: "releaseDate", Exists: true}).
+				Group(FieldExpression{Key: "label"}, Accumulator{Field: "count", Operator: AccumulatorCount}).
+				Sort(query.SortKey{Key: "count", Order: query.SortDescending}, query.SortKey{Key: "_id", Order: query.SortAscending}).
+				Limit(1),
+			compiled: bson.A{
+				bson.M{"$match": bson.M{"releaseDate": bson.M{"$exists": true}}},
+				bson.M{"$group": bson.M{"_id": "$label", "count": bson.M{"$sum": 1}}},
+				bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+				bson.M{"$limit": int64(1)},
+			},
+			documents: tracks,
+			expected:  []bson.M{{"_id": "mau5trap", "count": int32(2)}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			compiled := test.aggregation.Compile()
+			if !reflect.DeepEqual(compiled, test.compiled) {
+				t.Errorf("expected compiled pipeline %v, received %v", test.compiled, compiled)
+			}
+
+			output := test.aggregation.Apply(test.documents)
+			if !reflect.DeepEqual(output, test.expected) {
+				t.Errorf("expected output %v, received %v", test.expected, output)
+			}
+		})
+	}
+}
+
+// Description:
+//
+//	Creates a BSON date at midnight UTC.
+//
+// Parameters:
+//
+//	year 	The year.
+//	month 	The month.
+//	day 	The day.
+//
+// Returns:
+//
+//	The BSON date.
+func date(year int, month time.Month, day int) primitive.DateTime {
+	return primitive.NewDateTimeFromTime(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
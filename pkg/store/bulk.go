@@ -0,0 +1,64 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/gostream-official/tracks/pkg/store/query"
+)
+
+// Description:
+//
+//	A single update of a bulk write.
+type BulkUpdate struct {
+
+	// The filter used for searching the documents to update.
+	Filter *query.Filter
+
+	// The update operator used for updating the filtered documents.
+	Update *query.Update
+
+	// Whether all filtered documents are updated, instead of the first one only.
+	Multi bool
+}
+
+// Description:
+//
+//	The result of a bulk write.
+type BulkResult struct {
+
+	// The number of inserted documents.
+	InsertedCount int64
+
+	// The number of documents matched by the update filters.
+	MatchedCount int64
+
+	// The number of modified documents.
+	ModifiedCount int64
+
+	// The errors of the failed writes, in the order of their index.
+	// In ordered mode, the writes following the first failed write are not executed.
+	Errors []BulkError
+}
+
+// Description:
+//
+//	Describes a failed write of a bulk write.
+type BulkError struct {
+
+	// The index of the failed write within the bulk write.
+	Index int
+
+	// The error message.
+	Message string
+}
+
+// Description:
+//
+//	Formats the bulk error.
+//
+// Returns:
+//
+//	The error message, including the index of the failed write.
+func (err BulkError) Error() string {
+	return fmt.Sprintf("store: bulk write error at index %d: %s", err.Index, err.Message)
+}
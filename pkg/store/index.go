@@ -0,0 +1,15 @@
+package store
+
+import "github.com/gostream-official/tracks/pkg/store/query"
+
+// Description:
+//
+//	The definition of an index of a store.
+type Index struct {
+
+	// The name of the index.
+	Name string
+
+	// The indexed document keys and their order.
+	Keys []query.SortKey
+}
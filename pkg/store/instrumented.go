@@ -0,0 +1,335 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/gostream-official/tracks/pkg/store/query"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+
+	// The operation name of CreateItem.
+	OperationCreateItem = "create_item"
+
+	// The operation name of CreateItems.
+	OperationCreateItems = "create_items"
+
+	// The operation name of FindItems.
+	OperationFindItems = "find_items"
+
+	// The operation name of CountItems.
+	OperationCountItems = "count_items"
+
+	// The operation name of Aggregate.
+	OperationAggregate = "aggregate"
+
+	// The operation name of UpdateItem.
+	OperationUpdateItem = "update_item"
+
+	// The operation name of UpdateItems.
+	OperationUpdateItems = "update_items"
+
+	// The operation name of DeleteItem.
+	OperationDeleteItem = "delete_item"
+
+	// The operation name of DeleteItems.
+	OperationDeleteItems = "delete_items"
+
+	// The operation name of EnsureIndexes.
+	OperationEnsureIndexes = "ensure_indexes"
+)
+
+// Description:
+//
+//	Function definition for observers of store operations, e.g. to record metrics.
+//
+// Parameters:
+//
+//	collection 	The name of the collection the operation ran on.
+//	operation 	The name of the operation, e.g. 'find_items'.
+//	duration 	The duration of the operation.
+//	err 		The error of the operation. Nil if the operation succeeded.
+type OperationObserver = func(collection string, operation string, duration time.Duration, err error)
+
+// Description:
+//
+//	A store reporting each operation to an observer.
+//	Wraps another store, e.g. a MongoDB store.
+//
+// Type Parameters:
+//
+//	T The type of document stored in the store.
+type InstrumentedStore[T interface{}] struct {
+
+	// The wrapped store.
+	store Store[T]
+
+	// The name of the collection of the wrapped store.
+	collection string
+
+	// The observer of the operations.
+	observer OperationObserver
+}
+
+// Description:
+//
+//	Creates a new instrumented store.
+//
+// Parameters:
+//
+//	store 		The store to wrap.
+//	collection 	The name of the collection of the wrapped store, reported to the observer.
+//	observer 	The observer of the operations.
+//
+// Type Parameters:
+//
+//	T The type of document stored in the store.
+//
+// Returns:
+//
+//	The created instrumented store.
+func NewInstrumentedStore[T interface{}](store Store[T], collection string, observer OperationObserver) *InstrumentedStore[T] {
+	return &InstrumentedStore[T]{
+		store:      store,
+		collection: collection,
+		observer:   observer,
+	}
+}
+
+// Description:
+//
+//	Creates a new item.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	item 	The item to create.
+//
+// Returns:
+//
+//	An error if creation fails.
+func (store *InstrumentedStore[T]) CreateItem(ctx context.Context, item T) error {
+	start := time.Now()
+
+	err := store.store.CreateItem(ctx, item)
+	store.observe(OperationCreateItem, start, err)
+
+	return err
+}
+
+// Description:
+//
+//	Creates multiple items in a single bulk write.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	items 	The items to create.
+//	ordered Whether the items are created in order, stopping at the first failed item.
+//			Otherwise, all items are attempted, regardless of failed items.
+//
+// Returns:
+//
+//	The bulk result, containing the errors of the failed items.
+//	An error if the bulk write as a whole fails.
+func (store *InstrumentedStore[T]) CreateItems(ctx context.Context, items []T, ordered bool) (*BulkResult, error) {
+	start := time.Now()
+
+	result, err := store.store.CreateItems(ctx, items, ordered)
+	store.observe(OperationCreateItems, start, err)
+
+	return result, err
+}
+
+// Description:
+//
+//	Queries items in the store.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	filter 	The query filter to use.
+//
+// Returns:
+//
+//	An array of all items matching the given query filter.
+//	An error if the query fails.
+func (store *InstrumentedStore[T]) FindItems(ctx context.Context, filter *query.Filter) ([]T, error) {
+	start := time.Now()
+
+	items, err := store.store.FindItems(ctx, filter)
+	store.observe(OperationFindItems, start, err)
+
+	return items, err
+}
+
+// Description:
+//
+//	Counts items in the store.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	filter 	The query filter to use. The limit caps the count, if greater than zero.
+//
+// Returns:
+//
+//	The number of items matching the given query filter.
+//	An error if the query fails.
+func (store *InstrumentedStore[T]) CountItems(ctx context.Context, filter *query.Filter) (int64, error) {
+	start := time.Now()
+
+	count, err := store.store.CountItems(ctx, filter)
+	store.observe(OperationCountItems, start, err)
+
+	return count, err
+}
+
+// Description:
+//
+//	Runs an aggregation pipeline on the items in the store.
+//
+// Parameters:
+//
+//	ctx 		The context of the operation.
+//	aggregation The aggregation pipeline to run.
+//
+// Returns:
+//
+//	The output documents of the pipeline.
+//	An error if the aggregation fails.
+func (store *InstrumentedStore[T]) Aggregate(ctx context.Context, aggregation *Aggregation) ([]bson.M, error) {
+	start := time.Now()
+
+	documents, err := store.store.Aggregate(ctx, aggregation)
+	store.observe(OperationAggregate, start, err)
+
+	return documents, err
+}
+
+// Description:
+//
+//	Updates a single item.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	filter 	The filter used for searching the documents to update.
+//	update 	The update operator used for updating the filtered documents.
+//
+// Returns:
+//
+//	The number of modified documents.
+//	An error if the update fails.
+func (store *InstrumentedStore[T]) UpdateItem(ctx context.Context, filter *query.Filter, update *query.Update) (int64, error) {
+	start := time.Now()
+
+	modified, err := store.store.UpdateItem(ctx, filter, update)
+	store.observe(OperationUpdateItem, start, err)
+
+	return modified, err
+}
+
+// Description:
+//
+//	Runs multiple updates in a single bulk write.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	updates The updates to run.
+//	ordered Whether the updates run in order, stopping at the first failed update.
+//			Otherwise, all updates are attempted, regardless of failed updates.
+//
+// Returns:
+//
+//	The bulk result, containing the errors of the failed updates.
+//	An error if the bulk write as a whole fails.
+func (store *InstrumentedStore[T]) UpdateItems(ctx context.Context, updates []BulkUpdate, ordered bool) (*BulkResult, error) {
+	start := time.Now()
+
+	result, err := store.store.UpdateItems(ctx, updates, ordered)
+	store.observe(OperationUpdateItems, start, err)
+
+	return result, err
+}
+
+// Description:
+//
+//	Deletes an item by its ID.
+//
+// Parameters:
+//
+//	ctx The context of the operation.
+//	id 	The ID of the document to delete.
+//
+// Returns:
+//
+//	The number of deleted documents.
+//	An error if the request fails.
+func (store *InstrumentedStore[T]) DeleteItem(ctx context.Context, id string) (int64, error) {
+	start := time.Now()
+
+	deleted, err := store.store.DeleteItem(ctx, id)
+	store.observe(OperationDeleteItem, start, err)
+
+	return deleted, err
+}
+
+// Description:
+//
+//	Deletes all items matching the given filter.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	filter 	The filter used for searching the documents to delete.
+//
+// Returns:
+//
+//	The number of deleted documents.
+//	An error if the request fails.
+func (store *InstrumentedStore[T]) DeleteItems(ctx context.Context, filter *query.Filter) (int64, error) {
+	start := time.Now()
+
+	deleted, err := store.store.DeleteItems(ctx, filter)
+	store.observe(OperationDeleteItems, start, err)
+
+	return deleted, err
+}
+
+// Description:
+//
+//	Creates the given indexes, unless they already exist.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	indexes The indexes to create.
+//
+// Returns:
+//
+//	An error if an index cannot be created.
+func (store *InstrumentedStore[T]) EnsureIndexes(ctx context.Context, indexes []Index) error {
+	start := time.Now()
+
+	err := store.store.EnsureIndexes(ctx, indexes)
+	store.observe(OperationEnsureIndexes, start, err)
+
+	return err
+}
+
+// Description:
+//
+//	Reports a finished operation to the observer.
+//
+// Parameters:
+//
+//	operation 	The name of the operation.
+//	start 		The start time of the operation.
+//	err 		The error of the operation. Nil if the operation succeeded.
+func (store *InstrumentedStore[T]) observe(operation string, start time.Time, err error) {
+	store.observer(store.collection, operation, time.Since(start), err)
+}
@@ -0,0 +1,690 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/gostream-official/tracks/pkg/store/query"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Description:
+//
+//	An in-memory database instance.
+//	The in-memory counterpart of the MongoDB instance.
+//	Holds all collections of all databases created through it.
+type MemoryInstance struct {
+
+	// Guards all collections of this instance.
+	// Held for the whole duration of a transaction.
+	mutex sync.Mutex
+
+	// The collections, keyed by their namespace ('database.collection').
+	collections map[string]*memoryCollection
+}
+
+// Description:
+//
+//	An in-memory store.
+//	Evaluates query filters and update operators itself,
+//	so it behaves like a MongoDB store without requiring a database.
+type MemoryStore[T interface{}] struct {
+
+	// The instance the store belongs to.
+	instance *MemoryInstance
+
+	// The namespace of the referenced collection.
+	namespace string
+}
+
+// Description:
+//
+//	The context key under which the instance of a running in-memory transaction is stored.
+type memoryTransactionKey struct{}
+
+// Description:
+//
+//	An in-memory collection.
+type memoryCollection struct {
+
+	// The stored documents, in insertion order.
+	documents []bson.M
+}
+
+// Description:
+//
+//	Creates a new in-memory instance.
+//
+// Returns:
+//
+//	The created in-memory instance.
+func NewMemoryInstance() *MemoryInstance {
+	return &MemoryInstance{
+		collections: make(map[string]*memoryCollection),
+	}
+}
+
+// Description:
+//
+//	Runs a function within a transaction.
+//	The instance is locked for the whole transaction, so transactions are serializable.
+//	If the function fails or panics, all collections are restored to their state
+//	at the start of the transaction.
+//
+// Parameters:
+//
+//	ctx The context of the transaction.
+//	fn 	The function to run. Store operations must use the given transaction context.
+//
+// Returns:
+//
+//	The error returned by the function.
+//	An error if the context is done or if a transaction is already running on the context.
+func (instance *MemoryInstance) WithTransaction(ctx context.Context, fn func(tx context.Context) error) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	if ctx.Value(memoryTransactionKey{}) != nil {
+		return fmt.Errorf("store: nested transactions are not supported")
+	}
+
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+
+	snapshot := instance.snapshot()
+	committed := false
+
+	defer func() {
+		if !committed {
+			instance.collections = snapshot
+		}
+	}()
+
+	err = fn(context.WithValue(ctx, memoryTransactionKey{}, instance))
+	if err != nil {
+		return err
+	}
+
+	committed = true
+	return nil
+}
+
+// Description:
+//
+//	Creates a new in-memory store.
+//	Stores referring to the same database and collection share their documents.
+//
+// Parameters:
+//
+//	instance 	The in-memory instance which is referred to.
+//	database 	The database name referring to.
+//	collection 	The collection name referring to.
+//
+// Type Parameters:
+//
+//	T The type of document stored in the in-memory store to create.
+//
+// Returns:
+//
+//	The created in-memory store.
+func NewMemoryStore[T interface{}](instance *MemoryInstance, database string, collection string) *MemoryStore[T] {
+	return &MemoryStore[T]{
+		instance:  instance,
+		namespace: fmt.Sprintf("%s.%s", database, collection),
+	}
+}
+
+// Description:
+//
+//	Creates a new item.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	item 	The item to create.
+//
+// Returns:
+//
+//	An error if the item cannot be encoded or if an item with the same ID already exists.
+func (store *MemoryStore[T]) CreateItem(ctx context.Context, item T) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	document, err := query.ToDocument(item)
+	if err != nil {
+		return err
+	}
+
+	unlock := store.instance.lock(ctx)
+	defer unlock()
+
+	return store.collection().insert(document)
+}
+
+// Description:
+//
+//	Creates multiple items in a single bulk write.
+//	The bulk write is applied under a single lock of the instance.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	items 	The items to create.
+//	ordered Whether the items are created in order, stopping at the first failed item.
+//			Otherwise, all items are attempted, regardless of failed items.
+//
+// Returns:
+//
+//	The bulk result, containing the errors of the failed items.
+//	An error if the bulk write as a whole fails.
+func (store *MemoryStore[T]) CreateItems(ctx context.Context, items []T, ordered bool) (*BulkResult, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BulkResult{
+		Errors: make([]BulkError, 0),
+	}
+
+	unlock := store.instance.lock(ctx)
+	defer unlock()
+
+	collection := store.collection()
+
+	for index, item := range items {
+		document, err := query.ToDocument(item)
+		if err == nil {
+			err = collection.insert(document)
+		}
+
+		if err != nil {
+			result.Errors = append(result.Errors, BulkError{
+				Index:   index,
+				Message: err.Error(),
+			})
+
+			if ordered {
+				break
+			}
+
+			continue
+		}
+
+		result.InsertedCount++
+	}
+
+	return result, nil
+}
+
+// Description:
+//
+//	Updates a single item.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	filter 	The filter used for searching the documents to update.
+//	update 	The update operator used for updating the filtered documents.
+//
+// Returns:
+//
+//	The number of modified documents.
+//	An error if the update fails.
+func (store *MemoryStore[T]) UpdateItem(ctx context.Context, filter *query.Filter, update *query.Update) (int64, error) {
+	err := ctx.Err()
+	if err != nil {
+		return 0, err
+	}
+
+	if update.Root == nil {
+		return 0, fmt.Errorf("store: update document must not be empty")
+	}
+
+	unlock := store.instance.lock(ctx)
+	defer unlock()
+
+	_, modified, err := store.collection().update(filter, update, false)
+	return modified, err
+}
+
+// Description:
+//
+//	Runs multiple updates in a single bulk write.
+//	The bulk write is applied under a single lock of the instance.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	updates The updates to run.
+//	ordered Whether the updates run in order, stopping at the first failed update.
+//			Otherwise, all updates are attempted, regardless of failed updates.
+//
+// Returns:
+//
+//	The bulk result, containing the errors of the failed updates.
+//	An error if the bulk write as a whole fails.
+func (store *MemoryStore[T]) UpdateItems(ctx context.Context, updates []BulkUpdate, ordered bool) (*BulkResult, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	for index, update := range updates {
+		if update.Update.Root == nil {
+			return nil, fmt.Errorf("store: update document at index %d must not be empty", index)
+		}
+	}
+
+	result := &BulkResult{
+		Errors: make([]BulkError, 0),
+	}
+
+	unlock := store.instance.lock(ctx)
+	defer unlock()
+
+	collection := store.collection()
+
+	for index, update := range updates {
+		matched, modified, err := collection.update(update.Filter, update.Update, update.Multi)
+
+		if err != nil {
+			result.Errors = append(result.Errors, BulkError{
+				Index:   index,
+				Message: err.Error(),
+			})
+
+			if ordered {
+				break
+			}
+
+			continue
+		}
+
+		result.MatchedCount += matched
+		result.ModifiedCount += modified
+	}
+
+	return result, nil
+}
+
+// Description:
+//
+//	Queries items in the store.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	filter 	The query filter to use.
+//
+// Returns:
+//
+//	An array of all items matching the given query filter.
+//	An error if the query fails.
+func (store *MemoryStore[T]) FindItems(ctx context.Context, filter *query.Filter) ([]T, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := store.instance.lock(ctx)
+	defer unlock()
+
+	documents := make([]bson.M, 0)
+
+	for _, document := range store.collection().documents {
+		if matches(filter, document) {
+			documents = append(documents, document)
+		}
+	}
+
+	query.SortDocuments(documents, filter.Sort)
+
+	if filter.Limit > 0 && len(documents) > int(filter.Limit) {
+		documents = documents[:filter.Limit]
+	}
+
+	items := make([]T, 0, len(documents))
+
+	for _, document := range documents {
+		var item T
+		err = query.FromDocument(query.ProjectDocument(document, filter.Projection), &item)
+
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// Description:
+//
+//	Counts items in the store.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	filter 	The query filter to use. The limit caps the count, if greater than zero.
+//
+// Returns:
+//
+//	The number of items matching the given query filter.
+//	An error if the query fails.
+func (store *MemoryStore[T]) CountItems(ctx context.Context, filter *query.Filter) (int64, error) {
+	err := ctx.Err()
+	if err != nil {
+		return 0, err
+	}
+
+	unlock := store.instance.lock(ctx)
+	defer unlock()
+
+	count := int64(0)
+
+	for _, document := range store.collection().documents {
+		if matches(filter, document) {
+			count++
+		}
+	}
+
+	if filter.Limit > 0 && count > int64(filter.Limit) {
+		count = int64(filter.Limit)
+	}
+
+	return count, nil
+}
+
+// Description:
+//
+//	Runs an aggregation pipeline on the items in the store.
+//
+// Parameters:
+//
+//	ctx 		The context of the operation.
+//	aggregation The aggregation pipeline to run.
+//
+// Returns:
+//
+//	The output documents of the pipeline.
+//	An error if the aggregation fails.
+func (store *MemoryStore[T]) Aggregate(ctx context.Context, aggregation *Aggregation) ([]bson.M, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := store.instance.lock(ctx)
+	documents := make([]bson.M, 0, len(store.collection().documents))
+
+	for _, document := range store.collection().documents {
+		documents = append(documents, query.CopyDocument(document))
+	}
+
+	unlock()
+
+	return aggregation.Apply(documents), nil
+}
+
+// Description:
+//
+//	Deletes an item by its ID.
+//
+// Parameters:
+//
+//	ctx The context of the operation.
+//	id 	The ID of the document to delete.
+//
+// Returns:
+//
+//	The number of deleted documents.
+//	An error if the request fails.
+func (store *MemoryStore[T]) DeleteItem(ctx context.Context, id string) (int64, error) {
+	err := ctx.Err()
+	if err != nil {
+		return 0, err
+	}
+
+	unlock := store.instance.lock(ctx)
+	defer unlock()
+
+	collection := store.collection()
+
+	for index, document := range collection.documents {
+		if query.CompareValues(document["_id"], id) != 0 {
+			continue
+		}
+
+		collection.documents = append(collection.documents[:index], collection.documents[index+1:]...)
+		return 1, nil
+	}
+
+	return 0, nil
+}
+
+// Description:
+//
+//	Deletes all items matching the given filter.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	filter 	The filter used for searching the documents to delete.
+//
+// Returns:
+//
+//	The number of deleted documents.
+//	An error if the request fails.
+func (store *MemoryStore[T]) DeleteItems(ctx context.Context, filter *query.Filter) (int64, error) {
+	err := ctx.Err()
+	if err != nil {
+		return 0, err
+	}
+
+	unlock := store.instance.lock(ctx)
+	defer unlock()
+
+	collection := store.collection()
+	remaining := make([]bson.M, 0, len(collection.documents))
+
+	for _, document := range collection.documents {
+		if !matches(filter, document) {
+			remaining = append(remaining, document)
+		}
+	}
+
+	deleted := int64(len(collection.documents) - len(remaining))
+	collection.documents = remaining
+
+	return deleted, nil
+}
+
+// Description:
+//
+//	Creates the given indexes, unless they already exist.
+//	The in-memory store scans all documents on each query, so indexes are not maintained.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	indexes The indexes to create.
+//
+// Returns:
+//
+//	An error if the context is done.
+func (store *MemoryStore[T]) EnsureIndexes(ctx context.Context, indexes []Index) error {
+	return ctx.Err()
+}
+
+// Description:
+//
+//	Locks the instance for a single store operation.
+//	Operations running within a transaction of this instance already hold the lock.
+//
+// Parameters:
+//
+//	ctx The context of the operation.
+//
+// Returns:
+//
+//	The function releasing the lock.
+func (instance *MemoryInstance) lock(ctx context.Context) func() {
+	if ctx.Value(memoryTransactionKey{}) == instance {
+		return func() {}
+	}
+
+	instance.mutex.Lock()
+	return instance.mutex.Unlock
+}
+
+// Description:
+//
+//	Copies the collections of the instance.
+//	Documents are shared, since operations replace documents instead of modifying them.
+//	The caller must hold the instance mutex.
+//
+// Returns:
+//
+//	The copied collections.
+func (instance *MemoryInstance) snapshot() map[string]*memoryCollection {
+	collections := make(map[string]*memoryCollection, len(instance.collections))
+
+	for namespace, collection := range instance.collections {
+		collections[namespace] = &memoryCollection{
+			documents: append(make([]bson.M, 0, len(collection.documents)), collection.documents...),
+		}
+	}
+
+	return collections
+}
+
+// Description:
+//
+//	Gets the collection referenced by this store.
+//	Creates the collection lazily, like MongoDB does.
+//	The caller must hold the instance mutex.
+//
+// Returns:
+//
+//	The referenced collection.
+func (store *MemoryStore[T]) collection() *memoryCollection {
+	collection, ok := store.instance.collections[store.namespace]
+
+	if !ok {
+		collection = &memoryCollection{
+			documents: make([]bson.M, 0),
+		}
+
+		store.instance.collections[store.namespace] = collection
+	}
+
+	return collection
+}
+
+// Description:
+//
+//	Inserts a document into the collection.
+//	The caller must hold the instance mutex.
+//
+// Parameters:
+//
+//	document The document to insert.
+//
+// Returns:
+//
+//	An error if a document with the same ID already exists.
+func (collection *memoryCollection) insert(document bson.M) error {
+	id, hasID := document["_id"]
+	if hasID {
+		for _, existing := range collection.documents {
+			if query.CompareValues(existing["_id"], id) == 0 {
+				return fmt.Errorf("store: duplicate key error: _id %v", id)
+			}
+		}
+	}
+
+	collection.documents = append(collection.documents, document)
+	return nil
+}
+
+// Description:
+//
+//	Updates the documents of the collection matching the given filter.
+//	The documents are only replaced once the update was applied to all of them.
+//	The caller must hold the instance mutex.
+//
+// Parameters:
+//
+//	filter 	The filter used for searching the documents to update.
+//	update 	The update operator used for updating the filtered documents.
+//	multi 	Whether all filtered documents are updated, instead of the first one only.
+//
+// Returns:
+//
+//	The number of matched and the number of modified documents.
+//	An error if the update fails.
+func (collection *memoryCollection) update(filter *query.Filter, update *query.Update, multi bool) (int64, int64, error) {
+	matched := int64(0)
+	updates := make(map[int]bson.M)
+
+	for index, document := range collection.documents {
+		if !matches(filter, document) {
+			continue
+		}
+
+		matched++
+		updated := query.CopyDocument(document)
+
+		err := update.Root.Apply(updated)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if query.CompareValues(updated["_id"], document["_id"]) != 0 {
+			return 0, 0, fmt.Errorf("store: the immutable field '_id' must not be modified")
+		}
+
+		if !reflect.DeepEqual(updated, document) {
+			updates[index] = updated
+		}
+
+		if !multi {
+			break
+		}
+	}
+
+	for index, updated := range updates {
+		collection.documents[index] = updated
+	}
+
+	return matched, int64(len(updates)), nil
+}
+
+// Description:
+//
+//	Checks whether a document matches the given filter.
+//	A filter without root matches every document.
+//
+// Parameters:
+//
+//	filter 		The filter to check.
+//	document 	The document to check.
+//
+// Returns:
+//
+//	True, if the document matches the filter.
+func matches(filter *query.Filter, document bson.M) bool {
+	if filter.Root == nil {
+		return true
+	}
+
+	return filter.Root.Evaluate(document)
+}
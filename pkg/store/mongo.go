@@ -2,6 +2,8 @@ package store
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/gostream-official/tracks/pkg/store/query"
 	"go.mongodb.org/mongo-driver/bson"
@@ -62,6 +64,83 @@ func NewMongoInstance(uri string) (*MongoInstance, error) {
 	}, nil
 }
 
+// Description:
+//
+//	Checks whether the mongo instance is reachable.
+//
+// Parameters:
+//
+//	ctx The context bounding the check.
+//
+// Returns:
+//
+//	An error if the instance does not respond.
+func (instance *MongoInstance) Ping(ctx context.Context) error {
+	err := instance.Client.Ping(ctx, nil)
+	if err != nil {
+		return wrapError(ctx, err)
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Disconnects the mongo instance.
+//	Waits for operations in progress to complete until the context is done.
+//
+// Parameters:
+//
+//	ctx The context bounding the disconnect.
+//
+// Returns:
+//
+//	An error if the client cannot be disconnected.
+func (instance *MongoInstance) Disconnect(ctx context.Context) error {
+	return instance.Client.Disconnect(ctx)
+}
+
+// Description:
+//
+//	Runs a function within a transaction.
+//	The transaction context passed to the function carries the session of the transaction,
+//	so all stores of this instance used with it are bound to the session.
+//	Reads within the transaction observe a snapshot of the data, but do not conflict with concurrent writes.
+//	Requires MongoDB to run as a replica set or a sharded cluster.
+//
+// Parameters:
+//
+//	ctx The context of the transaction.
+//	fn 	The function to run. Retried on transient transaction errors.
+//
+// Returns:
+//
+//	The error returned by the function.
+//	An error if the transaction cannot be started or committed,
+//	or if a transaction is already running on the context.
+func (instance *MongoInstance) WithTransaction(ctx context.Context, fn func(tx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fmt.Errorf("store: nested transactions are not supported")
+	}
+
+	session, err := instance.Client.StartSession()
+	if err != nil {
+		return wrapError(ctx, err)
+	}
+
+	defer session.EndSession(context.Background())
+
+	_, err = session.WithTransaction(ctx, func(sessionContext mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionContext)
+	})
+
+	if err != nil {
+		return wrapError(ctx, err)
+	}
+
+	return nil
+}
+
 // Description:
 //
 //	Creates a new mongo store.
@@ -94,90 +173,144 @@ func NewMongoStore[T interface{}](instance *MongoInstance, database string, coll
 //
 // Parameters:
 //
-//	item The item to create.
+//	ctx 	The context of the operation.
+//	item 	The item to create.
 //
 // Returns:
 //
 //	An error if creation fails.
-func (store *MongoStore[T]) CreateItem(item interface{}) error {
-	ctx := context.Background()
+func (store *MongoStore[T]) CreateItem(ctx context.Context, item T) error {
 	_, err := store.Collection.InsertOne(ctx, item)
 
 	if err != nil {
-		return err
+		return wrapError(ctx, err)
 	}
 
 	return nil
 }
 
+// Description:
+//
+//	Creates multiple items in a single bulk write.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	items 	The items to create.
+//	ordered Whether the items are created in order, stopping at the first failed item.
+//			Otherwise, all items are attempted, regardless of failed items.
+//
+// Returns:
+//
+//	The bulk result, containing the errors of the failed items.
+//	An error if the bulk write as a whole fails.
+func (store *MongoStore[T]) CreateItems(ctx context.Context, items []T, ordered bool) (*BulkResult, error) {
+	models := make([]mongo.WriteModel, len(items))
+
+	for index, item := range items {
+		models[index] = mongo.NewInsertOneModel().SetDocument(item)
+	}
+
+	return store.bulkWrite(ctx, models, ordered)
+}
+
 // Description:
 //
 //	Updates a single item.
 //
 // Parameters:
 //
-//	filter The filter used for searching the documents to update.
-//	update The update operator used for updating the filtered documents.
+//	ctx 	The context of the operation.
+//	filter 	The filter used for searching the documents to update.
+//	update 	The update operator used for updating the filtered documents.
 //
 // Returns:
 //
 //	The number of modified documents.
 //	An error if the update fails.
-func (store *MongoStore[T]) UpdateItem(filter *query.Filter, update *query.Update) (int64, error) {
-	var query bson.M
+func (store *MongoStore[T]) UpdateItem(ctx context.Context, filter *query.Filter, update *query.Update) (int64, error) {
 	var updateQuery bson.M
 
-	if filter.Root == nil {
-		query = bson.M{}
-	} else {
-		query = filter.Root.Compile()
-	}
-
 	if update.Root == nil {
 		updateQuery = bson.M{}
 	} else {
 		updateQuery = update.Root.Compile()
 	}
 
-	ctx := context.Background()
-	result, err := store.Collection.UpdateOne(ctx, query, updateQuery)
+	result, err := store.Collection.UpdateOne(ctx, compileFilter(filter), updateQuery)
 
 	if err != nil {
-		return 0, err
+		return 0, wrapError(ctx, err)
 	}
 
 	return result.ModifiedCount, nil
 }
 
+// Description:
+//
+//	Runs multiple updates in a single bulk write.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	updates The updates to run.
+//	ordered Whether the updates run in order, stopping at the first failed update.
+//			Otherwise, all updates are attempted, regardless of failed updates.
+//
+// Returns:
+//
+//	The bulk result, containing the errors of the failed updates.
+//	An error if the bulk write as a whole fails.
+func (store *MongoStore[T]) UpdateItems(ctx context.Context, updates []BulkUpdate, ordered bool) (*BulkResult, error) {
+	models := make([]mongo.WriteModel, len(updates))
+
+	for index, update := range updates {
+		if update.Update.Root == nil {
+			return nil, fmt.Errorf("store: update document at index %d must not be empty", index)
+		}
+
+		filter := compileFilter(update.Filter)
+		updateQuery := update.Update.Root.Compile()
+
+		if update.Multi {
+			models[index] = mongo.NewUpdateManyModel().SetFilter(filter).SetUpdate(updateQuery)
+		} else {
+			models[index] = mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(updateQuery)
+		}
+	}
+
+	return store.bulkWrite(ctx, models, ordered)
+}
+
 // Description:
 //
 //	Queries items in the store.
 //
 // Parameters:
 //
-//	The query filter to use.
+//	ctx 	The context of the operation.
+//	filter 	The query filter to use.
 //
 // Returns:
 //
 //	An array of all items matching the given query filter.
 //	An error if the query fails.
-func (store *MongoStore[T]) FindItems(filter *query.Filter) ([]T, error) {
+func (store *MongoStore[T]) FindItems(ctx context.Context, filter *query.Filter) ([]T, error) {
 	items := make([]T, 0)
 
-	var query bson.M
+	findOptions := options.Find().SetLimit(int64(filter.Limit))
 
-	if filter.Root == nil {
-		query = bson.M{}
-	} else {
-		query = filter.Root.Compile()
+	if len(filter.Sort) > 0 {
+		findOptions.SetSort(query.CompileSort(filter.Sort))
 	}
 
-	ctx := context.Background()
-	options := options.Find().SetLimit(int64(filter.Limit))
+	if len(filter.Projection) > 0 {
+		findOptions.SetProjection(query.CompileProjection(filter.Projection))
+	}
 
-	cursor, err := store.Collection.Find(ctx, query, options)
+	cursor, err := store.Collection.Find(ctx, compileFilter(filter), findOptions)
 	if err != nil {
-		return nil, err
+		return nil, wrapError(ctx, err)
 	}
 
 	defer cursor.Close(ctx)
@@ -193,31 +326,238 @@ func (store *MongoStore[T]) FindItems(filter *query.Filter) ([]T, error) {
 		items = append(items, item)
 	}
 
+	err = cursor.Err()
+	if err != nil {
+		return nil, wrapError(ctx, err)
+	}
+
 	return items, nil
 }
 
+// Description:
+//
+//	Counts items in the store.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	filter 	The query filter to use. The limit caps the count, if greater than zero.
+//
+// Returns:
+//
+//	The number of items matching the given query filter.
+//	An error if the query fails.
+func (store *MongoStore[T]) CountItems(ctx context.Context, filter *query.Filter) (int64, error) {
+	countOptions := options.Count()
+
+	if filter.Limit > 0 {
+		countOptions.SetLimit(int64(filter.Limit))
+	}
+
+	count, err := store.Collection.CountDocuments(ctx, compileFilter(filter), countOptions)
+	if err != nil {
+		return 0, wrapError(ctx, err)
+	}
+
+	return count, nil
+}
+
+// Description:
+//
+//	Runs an aggregation pipeline on the items in the store.
+//
+// Parameters:
+//
+//	ctx 		The context of the operation.
+//	aggregation The aggregation pipeline to run.
+//
+// Returns:
+//
+//	The output documents of the pipeline.
+//	An error if the aggregation fails.
+func (store *MongoStore[T]) Aggregate(ctx context.Context, aggregation *Aggregation) ([]bson.M, error) {
+	cursor, err := store.Collection.Aggregate(ctx, aggregation.Compile())
+	if err != nil {
+		return nil, wrapError(ctx, err)
+	}
+
+	documents := make([]bson.M, 0)
+
+	err = cursor.All(ctx, &documents)
+	if err != nil {
+		return nil, wrapError(ctx, err)
+	}
+
+	return documents, nil
+}
+
 // Description:
 //
 //	Deletes an item by its ID.
 //
 // Parameters:
 //
-//	The ID of the document to delete.
+//	ctx The context of the operation.
+//	id 	The ID of the document to delete.
 //
 // Returns:
 //
 //	The number of deleted documents.
 //	An error if the request fails.
-func (store MongoStore[T]) DeleteItem(id string) (int64, error) {
-	ctx := context.Background()
-
+func (store MongoStore[T]) DeleteItem(ctx context.Context, id string) (int64, error) {
 	result, err := store.Collection.DeleteOne(ctx, bson.M{
 		"_id": id,
 	})
 
 	if err != nil {
-		return 0, err
+		return 0, wrapError(ctx, err)
 	}
 
 	return result.DeletedCount, nil
 }
+
+// Description:
+//
+//	Deletes all items matching the given filter.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	filter 	The filter used for searching the documents to delete.
+//
+// Returns:
+//
+//	The number of deleted documents.
+//	An error if the request fails.
+func (store *MongoStore[T]) DeleteItems(ctx context.Context, filter *query.Filter) (int64, error) {
+	result, err := store.Collection.DeleteMany(ctx, compileFilter(filter))
+
+	if err != nil {
+		return 0, wrapError(ctx, err)
+	}
+
+	return result.DeletedCount, nil
+}
+
+// Description:
+//
+//	Creates the given indexes, unless they already exist.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	indexes The indexes to create.
+//
+// Returns:
+//
+//	An error if an index cannot be created.
+func (store *MongoStore[T]) EnsureIndexes(ctx context.Context, indexes []Index) error {
+	if len(indexes) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.IndexModel, len(indexes))
+
+	for index, definition := range indexes {
+		models[index] = mongo.IndexModel{
+			Keys:    query.CompileSort(definition.Keys),
+			Options: options.Index().SetName(definition.Name),
+		}
+	}
+
+	_, err := store.Collection.Indexes().CreateMany(ctx, models)
+	if err != nil {
+		return wrapError(ctx, err)
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Executes write models as a single bulk write.
+//	Write errors of single models are reported in the bulk result,
+//	all other errors fail the bulk write as a whole.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	models 	The write models to execute.
+//	ordered Whether the models are executed in order, stopping at the first failed model.
+//
+// Returns:
+//
+//	The bulk result, or an error if the bulk write as a whole fails.
+func (store *MongoStore[T]) bulkWrite(ctx context.Context, models []mongo.WriteModel, ordered bool) (*BulkResult, error) {
+	bulkResult := &BulkResult{
+		Errors: make([]BulkError, 0),
+	}
+
+	if len(models) == 0 {
+		return bulkResult, nil
+	}
+
+	result, err := store.Collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(ordered))
+
+	var exception mongo.BulkWriteException
+	if err != nil && (!errors.As(err, &exception) || exception.WriteConcernError != nil) {
+		return nil, wrapError(ctx, err)
+	}
+
+	if result != nil {
+		bulkResult.InsertedCount = result.InsertedCount
+		bulkResult.MatchedCount = result.MatchedCount
+		bulkResult.ModifiedCount = result.ModifiedCount
+	}
+
+	for _, writeError := range exception.WriteErrors {
+		bulkResult.Errors = append(bulkResult.Errors, BulkError{
+			Index:   writeError.Index,
+			Message: writeError.Message,
+		})
+	}
+
+	return bulkResult, nil
+}
+
+// Description:
+//
+//	Compiles a query filter into a MongoDB BSON document.
+//	A filter without root matches every document.
+//
+// Parameters:
+//
+//	filter The filter to compile.
+//
+// Returns:
+//
+//	The MongoDB bson document representing the filter.
+func compileFilter(filter *query.Filter) bson.M {
+	if filter.Root == nil {
+		return bson.M{}
+	}
+
+	return filter.Root.Compile()
+}
+
+// Description:
+//
+//	Wraps a driver error with the context error, if the context is done.
+//	This allows callers to detect timeouts and cancellations using errors.Is.
+//
+// Parameters:
+//
+//	ctx The context of the failed operation.
+//	err The driver error.
+//
+// Returns:
+//
+//	The wrapped error.
+func wrapError(ctx context.Context, err error) error {
+	ctxErr := ctx.Err()
+	if ctxErr == nil {
+		return err
+	}
+
+	return fmt.Errorf("store: %w: %s", ctxErr, err)
+}
@@ -0,0 +1,141 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gostream-official/tracks/pkg/store/query"
+)
+
+// Description:
+//
+//	Returned if a pagination cursor is malformed or does not match the requested sort order.
+var ErrInvalidCursor = errors.New("store: invalid cursor")
+
+// Description:
+//
+//	A single page of items.
+//
+// Type Parameters:
+//
+//	T The type of the items.
+type Page[T interface{}] struct {
+
+	// The items of the page.
+	Items []T
+
+	// Whether there are more items after this page.
+	HasMore bool
+
+	// The cursor pointing at the last item of this page.
+	// Empty if there are no more items.
+	NextCursor string
+}
+
+// Description:
+//
+//	Queries a single page of items using cursor-based (keyset) pagination.
+//	The sort specification of the filter is made stable by appending the primary key,
+//	so pages neither overlap nor skip items when items are inserted concurrently.
+//
+// Parameters:
+//
+//	ctx 	The context of the operation.
+//	store 	The store to query.
+//	filter 	The query filter. The limit of the filter is the page size.
+//	cursor 	The cursor returned with the previous page. Empty for the first page.
+//
+// Type Parameters:
+//
+//	T The type of the items.
+//
+// Returns:
+//
+//	The requested page.
+//	An error wrapping ErrInvalidCursor if the cursor is invalid, or an error if the query fails.
+//	The items contain the sort keys in addition to the projected fields, as the next cursor is built from them.
+func FindPage[T interface{}](ctx context.Context, store Store[T], filter *query.Filter, cursor string) (*Page[T], error) {
+	if filter.Limit == 0 {
+		return nil, fmt.Errorf("store: page size must be greater than zero")
+	}
+
+	sort := query.StableSort(filter.Sort)
+	root := filter.Root
+
+	if cursor != "" {
+		decoded, err := query.DecodeCursor(cursor)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCursor, err)
+		}
+
+		after, err := decoded.Filter(sort)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCursor, err)
+		}
+
+		if root == nil {
+			root = after
+		} else {
+			root = query.FilterOperatorAnd{
+				And: []query.IQuery{root, after},
+			}
+		}
+	}
+
+	pageFilter := query.Filter{
+		Root:       root,
+		Limit:      filter.Limit + 1,
+		Sort:       sort,
+		Projection: query.ExtendProjection(filter.Projection, sortKeys(sort)...),
+	}
+
+	items, err := store.FindItems(ctx, &pageFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page[T]{
+		Items: items,
+	}
+
+	if len(items) <= int(filter.Limit) {
+		return page, nil
+	}
+
+	page.Items = items[:filter.Limit]
+	page.HasMore = true
+
+	document, err := query.ToDocument(page.Items[len(page.Items)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	page.NextCursor, err = query.NewCursor(document, sort).Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// Description:
+//
+//	Gets the document keys of a sort specification.
+//
+// Parameters:
+//
+//	sort The sort specification.
+//
+// Returns:
+//
+//	The document keys.
+func sortKeys(sort []query.SortKey) []string {
+	keys := make([]string, len(sort))
+
+	for index, key := range sort {
+		keys[index] = key.Key
+	}
+
+	return keys
+}
@@ -0,0 +1,202 @@
+package query
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Description:
+//
+//	A pagination cursor.
+//	Marks the position of the last item of a page within a sort order,
+//	so the next page can be requested independent of concurrent inserts and deletes.
+type Cursor struct {
+
+	// The sort specification the cursor was created for.
+	Sort string `bson:"s"`
+
+	// The values of the sort keys of the last item, in sort key order.
+	Values bson.A `bson:"v"`
+}
+
+// Description:
+//
+//	Creates a cursor pointing at the given document.
+//
+// Parameters:
+//
+//	document 	The last document of a page.
+//	keys 		The stable sort specification of the page.
+//
+// Returns:
+//
+//	The created cursor.
+func NewCursor(document bson.M, keys []SortKey) Cursor {
+	values := make(bson.A, len(keys))
+
+	for index, key := range keys {
+		values[index], _ = GetPath(document, key.Key)
+	}
+
+	return Cursor{
+		Sort:   FormatSort(keys),
+		Values: values,
+	}
+}
+
+// Description:
+//
+//	Decodes an opaque cursor token.
+//	Tokens are client-controlled, so only scalar values are accepted: documents, arrays and regular
+//	expressions would be interpreted as query expressions when the values are compared.
+//
+// Parameters:
+//
+//	token The cursor token.
+//
+// Returns:
+//
+//	The decoded cursor, or an error if the token is malformed or contains a value which is not a scalar.
+func DecodeCursor(token string) (*Cursor, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("query: malformed cursor: %s", err)
+	}
+
+	cursor := &Cursor{}
+
+	err = bson.Unmarshal(bytes, cursor)
+	if err != nil {
+		return nil, fmt.Errorf("query: malformed cursor: %s", err)
+	}
+
+	for index, value := range cursor.Values {
+		if !isCursorValue(value) {
+			return nil, fmt.Errorf("query: malformed cursor: value %d has unsupported type %T", index, value)
+		}
+	}
+
+	return cursor, nil
+}
+
+// Description:
+//
+//	Checks whether a decoded value can be part of a cursor, i.e. whether it is a scalar sort key value.
+//
+// Parameters:
+//
+//	value The decoded value.
+//
+// Returns:
+//
+//	True, if the value is null, a string, a boolean, a number, a date or an object id.
+func isCursorValue(value interface{}) bool {
+	switch value.(type) {
+	case nil, string, bool, int32, int64, float64, primitive.Decimal128, primitive.DateTime, primitive.ObjectID:
+		return true
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Encodes the cursor into an opaque, URL-safe token.
+//
+// Returns:
+//
+//	The cursor token, or an error if encoding fails.
+func (cursor Cursor) Encode() (string, error) {
+	bytes, err := bson.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// Description:
+//
+//	Creates the filter selecting all documents after the cursor position.
+//	For sort keys k1..kn this is the keyset condition:
+//	(k1 > v1) or (k1 = v1 and k2 > v2) or ... with '<' for descending keys.
+//	Null and missing values sort before all other values, see positionAfter.
+//
+// Parameters:
+//
+//	keys The stable sort specification. Must match the cursor.
+//
+// Returns:
+//
+//	The created filter, or an error if the cursor does not match the sort specification.
+func (cursor Cursor) Filter(keys []SortKey) (IQuery, error) {
+	if cursor.Sort != FormatSort(keys) || len(cursor.Values) != len(keys) {
+		return nil, fmt.Errorf("query: cursor does not match sort order '%s'", FormatSort(keys))
+	}
+
+	or := FilterOperatorOr{
+		Or: make([]IQuery, 0, len(keys)),
+	}
+
+	for index, key := range keys {
+		after, ok := positionAfter(key, cursor.Values[index])
+		if !ok {
+			continue
+		}
+
+		and := FilterOperatorAnd{
+			And: make([]IQuery, 0, index+1),
+		}
+
+		for previous := 0; previous < index; previous++ {
+			and.And = append(and.And, FilterOperatorEq{
+				Key:   keys[previous].Key,
+				Value: cursor.Values[previous],
+			})
+		}
+
+		and.And = append(and.And, after)
+		or.Or = append(or.Or, and)
+	}
+
+	return or, nil
+}
+
+// Description:
+//
+//	Creates the condition selecting the values of a sort key strictly after the given value.
+//	Comparisons never match null or missing values, which sort before all other values.
+//	So null values are handled explicitly: in ascending order, all non-null values follow a null value,
+//	in descending order, null values follow all other values and nothing follows a null value.
+//
+// Parameters:
+//
+//	key 	The sort key.
+//	value 	The value of the sort key at the cursor position.
+//
+// Returns:
+//
+//	The condition, or false if no value follows the given value.
+func positionAfter(key SortKey, value interface{}) (IQuery, bool) {
+	if key.Order == SortDescending {
+		if value == nil {
+			return nil, false
+		}
+
+		return FilterOperatorOr{
+			Or: []IQuery{
+				FilterOperatorLt{Key: key.Key, Value: value},
+				FilterOperatorEq{Key: key.Key, Value: nil},
+			},
+		}, true
+	}
+
+	if value == nil {
+		return FilterOperatorNeq{Key: key.Key, Value: nil}, true
+	}
+
+	return FilterOperatorGt{Key: key.Key, Value: value}, true
+}
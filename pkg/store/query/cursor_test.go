@@ -0,0 +1,196 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCursorPaginatesAcrossNullValues(t *testing.T) {
+	documents := []bson.M{
+		{"_id": "a", "createdAt": int64(3)},
+		{"_id": "b"},
+		{"_id": "c", "createdAt": int64(1)},
+		{"_id": "d", "createdAt": nil},
+		{"_id": "e", "createdAt": int64(2)},
+		{"_id": "f"},
+	}
+
+	tests := []struct {
+		name     string
+		order    SortOrder
+		expected []string
+	}{
+		{name: "ascending", order: SortAscending, expected: []string{"b", "d", "f", "c", "e", "a"}},
+		{name: "descending", order: SortDescending, expected: []string{"a", "e", "c", "b", "d", "f"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keys := StableSort([]SortKey{{Key: "createdAt", Order: test.order}})
+
+			for pageSize := 1; pageSize <= len(documents); pageSize++ {
+				visited := paginate(t, documents, keys, pageSize)
+
+				if !reflect.DeepEqual(visited, test.expected) {
+					t.Errorf("page size %d: expected %v, received %v", pageSize, test.expected, visited)
+				}
+			}
+		})
+	}
+}
+
+func TestCursorFilterCompilesNullValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		order    SortOrder
+		expected bson.M
+	}{
+		{
+			name:  "ascending",
+			order: SortAscending,
+			expected: bson.M{"$or": []bson.M{
+				{"$and": []bson.M{{"createdAt": bson.M{"$ne": nil}}}},
+				{"$and": []bson.M{{"createdAt": nil}, {"_id": bson.M{"$gt": "b"}}}},
+			}},
+		},
+		{
+			name:  "descending",
+			order: SortDescending,
+			expected: bson.M{"$or": []bson.M{
+				{"$and": []bson.M{{"createdAt": nil}, {"_id": bson.M{"$gt": "b"}}}},
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keys := StableSort([]SortKey{{Key: "createdAt", Order: test.order}})
+			cursor := NewCursor(bson.M{"_id": "b"}, keys)
+
+			filter, err := cursor.Filter(keys)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			compiled := filter.Compile()
+			if !reflect.DeepEqual(compiled, test.expected) {
+				t.Errorf("expected %v, received %v", test.expected, compiled)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejectsNonScalarValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		valid bool
+	}{
+		{name: "null", value: nil, valid: true},
+		{name: "string", value: "Strobe", valid: true},
+		{name: "integer", value: int64(128), valid: true},
+		{name: "double", value: 128.5, valid: true},
+		{name: "date", value: primitive.NewDateTimeFromTime(time.Date(2009, 9, 22, 0, 0, 0, 0, time.UTC)), valid: true},
+		{name: "document", value: bson.M{"$gt": ""}},
+		{name: "array", value: bson.A{"a", "b"}},
+		{name: "regular expression", value: primitive.Regex{Pattern: ".*"}},
+		{name: "javascript", value: primitive.JavaScript("true")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token := encode(t, Cursor{Sort: "title,_id", Values: bson.A{test.value, "b"}})
+
+			_, err := DecodeCursor(token)
+			if test.valid && err != nil {
+				t.Errorf("expected the cursor to be accepted, received '%s'", err)
+			}
+
+			if !test.valid && err == nil {
+				t.Error("expected the cursor to be rejected")
+			}
+		})
+	}
+}
+
+// Description:
+//
+//	Pages through the documents in memory, following the cursor of each page, like a client would.
+//
+// Parameters:
+//
+//	t 			The test.
+//	documents 	The documents to page through.
+//	keys 		The stable sort specification.
+//	pageSize 	The number of documents per page.
+//
+// Returns:
+//
+//	The ids of all visited documents, in order.
+func paginate(t *testing.T, documents []bson.M, keys []SortKey, pageSize int) []string {
+	visited := make([]string, 0)
+
+	var after IQuery
+
+	for page := 0; page <= len(documents); page++ {
+		matching := make([]bson.M, 0)
+
+		for _, document := range documents {
+			if after == nil || after.Evaluate(document) {
+				matching = append(matching, document)
+			}
+		}
+
+		SortDocuments(matching, keys)
+
+		if len(matching) > pageSize {
+			matching = matching[:pageSize]
+		}
+
+		for _, document := range matching {
+			visited = append(visited, document["_id"].(string))
+		}
+
+		if len(matching) < pageSize {
+			return visited
+		}
+
+		cursor, err := DecodeCursor(encode(t, NewCursor(matching[len(matching)-1], keys)))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		after, err = cursor.Filter(keys)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	t.Fatal("pagination does not terminate")
+	return nil
+}
+
+// Description:
+//
+//	Encodes a cursor, failing the test if encoding fails.
+//
+// Parameters:
+//
+//	t 		The test.
+//	cursor 	The cursor to encode.
+//
+// Returns:
+//
+//	The cursor token.
+func encode(t *testing.T, cursor Cursor) string {
+	token, err := cursor.Encode()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	return token
+}
@@ -0,0 +1,780 @@
+package query
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Description:
+//
+//	Converts an arbitrary object into a bson document.
+//	The object is encoded using its bson struct tags, so the resulting document
+//	has the same shape as the document MongoDB would store.
+//
+// Parameters:
+//
+//	object The object to convert.
+//
+// Returns:
+//
+//	The bson document, or an error if the object cannot be encoded.
+func ToDocument(object interface{}) (bson.M, error) {
+	bytes, err := bson.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+
+	document := bson.M{}
+
+	err = bson.Unmarshal(bytes, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+// Description:
+//
+//	Decodes a bson document into the given target object.
+//
+// Parameters:
+//
+//	document 	The document to decode.
+//	target 		A pointer to the object to decode into.
+//
+// Returns:
+//
+//	An error if decoding fails.
+func FromDocument(document bson.M, target interface{}) error {
+	bytes, err := bson.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	return bson.Unmarshal(bytes, target)
+}
+
+// Description:
+//
+//	Creates a deep copy of a bson document.
+//
+// Parameters:
+//
+//	document The document to copy.
+//
+// Returns:
+//
+//	The copied document.
+func CopyDocument(document bson.M) bson.M {
+	return copyValue(document).(bson.M)
+}
+
+// Description:
+//
+//	Resolves a dotted path inside a document.
+//	Like MongoDB, arrays of embedded documents are traversed implicitly,
+//	so a single path can resolve to multiple values.
+//
+// Parameters:
+//
+//	document 	The document to search.
+//	path 		The dotted path, e.g. 'audioFeatures.tempo'.
+//
+// Returns:
+//
+//	All values found at the given path. Empty if the path does not exist.
+func LookupPath(document bson.M, path string) []interface{} {
+	return lookupSegments(document, strings.Split(path, "."))
+}
+
+// Description:
+//
+//	Sets the value at a dotted path inside a document.
+//	Missing intermediate documents are created.
+//
+// Parameters:
+//
+//	document 	The document to modify.
+//	path 		The dotted path.
+//	value 		The value to set.
+//
+// Returns:
+//
+//	An error if the path traverses a non-document value.
+func SetPath(document bson.M, path string, value interface{}) error {
+	parent, key, err := resolveParent(document, path, true)
+	if err != nil {
+		return err
+	}
+
+	parent[key] = value
+	return nil
+}
+
+// Description:
+//
+//	Removes the value at a dotted path inside a document.
+//	Does nothing if the path does not exist.
+//
+// Parameters:
+//
+//	document 	The document to modify.
+//	path 		The dotted path.
+func UnsetPath(document bson.M, path string) {
+	parent, key, err := resolveParent(document, path, false)
+	if err != nil || parent == nil {
+		return
+	}
+
+	delete(parent, key)
+}
+
+// Description:
+//
+//	Gets the single value at a dotted path, without implicit array traversal.
+//
+// Parameters:
+//
+//	document 	The document to search.
+//	path 		The dotted path.
+//
+// Returns:
+//
+//	The value, and whether the path exists.
+func GetPath(document bson.M, path string) (interface{}, bool) {
+	parent, key, err := resolveParent(document, path, false)
+	if err != nil || parent == nil {
+		return nil, false
+	}
+
+	value, ok := parent[key]
+	return value, ok
+}
+
+// Description:
+//
+//	Compares two values following the MongoDB sort order.
+//	Values of different types are ordered by their bson type bracket.
+//
+// Parameters:
+//
+//	left 	The left value.
+//	right 	The right value.
+//
+// Returns:
+//
+//	A negative number if left < right, zero if both are equal, a positive number otherwise.
+func CompareValues(left interface{}, right interface{}) int {
+	left = Canonical(left)
+	right = Canonical(right)
+
+	leftRank := typeRank(left)
+	rightRank := typeRank(right)
+
+	if leftRank != rightRank {
+		return leftRank - rightRank
+	}
+
+	return compareSameRank(left, right)
+}
+
+// Description:
+//
+//	Converts a value into its canonical bson representation.
+//	E.g. time.Time becomes primitive.DateTime, []string becomes bson.A and
+//	all numeric types are widened, so values can be compared consistently.
+//
+// Parameters:
+//
+//	value The value to convert.
+//
+// Returns:
+//
+//	The canonical value.
+func Canonical(value interface{}) interface{} {
+	switch value.(type) {
+	case nil, string, bool, int32, int64, float64, primitive.DateTime, primitive.Regex:
+		return value
+	}
+
+	bytes, err := bson.Marshal(bson.M{"v": value})
+	if err != nil {
+		return value
+	}
+
+	document := bson.M{}
+
+	err = bson.Unmarshal(bytes, &document)
+	if err != nil {
+		return value
+	}
+
+	return document["v"]
+}
+
+// Description:
+//
+//	Checks whether two values are equal in terms of MongoDB equality.
+//
+// Parameters:
+//
+//	left 	The left value.
+//	right 	The right value.
+//
+// Returns:
+//
+//	True, if both values are equal.
+func valuesEqual(left interface{}, right interface{}) bool {
+	left = Canonical(left)
+	right = Canonical(right)
+
+	if typeRank(left) != typeRank(right) {
+		return false
+	}
+
+	return compareSameRank(left, right) == 0
+}
+
+// Description:
+//
+//	Checks whether any value resolved from a document path matches the predicate.
+//	Arrays are matched as a whole as well as element-wise, like in MongoDB.
+//
+// Parameters:
+//
+//	values 		The resolved values.
+//	predicate 	The predicate to check.
+//
+// Returns:
+//
+//	True, if at least one value matches.
+func anyValue(values []interface{}, predicate func(value interface{}) bool) bool {
+	for _, value := range values {
+		if predicate(value) {
+			return true
+		}
+
+		array, ok := Canonical(value).(bson.A)
+		if !ok {
+			continue
+		}
+
+		for _, element := range array {
+			if predicate(element) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Checks whether the values at a document path match the given comparison.
+//	Only values within the same type bracket are compared, like in MongoDB.
+//
+// Parameters:
+//
+//	document 	The document to evaluate.
+//	key 		The document key.
+//	value 		The value to compare against.
+//	accept 		Decides whether a comparison result is accepted.
+//
+// Returns:
+//
+//	True, if at least one value matches the comparison.
+func compareAt(document bson.M, key string, value interface{}, accept func(result int) bool) bool {
+	value = Canonical(value)
+
+	return anyValue(LookupPath(document, key), func(candidate interface{}) bool {
+		candidate = Canonical(candidate)
+
+		if typeRank(candidate) != typeRank(value) {
+			return false
+		}
+
+		return accept(compareSameRank(candidate, value))
+	})
+}
+
+// Description:
+//
+//	Checks whether the value at a document path equals the given value.
+//	A nil value also matches missing fields, like in MongoDB.
+//
+// Parameters:
+//
+//	document 	The document to evaluate.
+//	key 		The document key.
+//	value 		The value to compare against.
+//
+// Returns:
+//
+//	True, if the document matches.
+func equalsAt(document bson.M, key string, value interface{}) bool {
+	values := LookupPath(document, key)
+
+	if value == nil && len(values) == 0 {
+		return true
+	}
+
+	return anyValue(values, func(candidate interface{}) bool {
+		return valuesEqual(candidate, value)
+	})
+}
+
+// Description:
+//
+//	Recursively resolves path segments.
+//
+// Parameters:
+//
+//	value 		The current value.
+//	segments 	The remaining path segments.
+//
+// Returns:
+//
+//	All values found.
+func lookupSegments(value interface{}, segments []string) []interface{} {
+	if len(segments) == 0 {
+		return []interface{}{value}
+	}
+
+	switch current := value.(type) {
+	case bson.M:
+		next, ok := current[segments[0]]
+		if !ok {
+			return nil
+		}
+
+		return lookupSegments(next, segments[1:])
+
+	case bson.A:
+		index, err := strconv.Atoi(segments[0])
+		if err == nil {
+			if index < 0 || index >= len(current) {
+				return nil
+			}
+
+			return lookupSegments(current[index], segments[1:])
+		}
+
+		results := make([]interface{}, 0)
+
+		for _, element := range current {
+			if _, ok := element.(bson.M); ok {
+				results = append(results, lookupSegments(element, segments)...)
+			}
+		}
+
+		return results
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Resolves the parent document of a dotted path.
+//
+// Parameters:
+//
+//	document 	The root document.
+//	path 		The dotted path.
+//	create 		Whether missing intermediate documents should be created.
+//
+// Returns:
+//
+//	The parent document and the last path segment.
+//	The parent is nil if it does not exist and create is false.
+//	An error if the path traverses a non-document value.
+func resolveParent(document bson.M, path string, create bool) (bson.M, string, error) {
+	segments := strings.Split(path, ".")
+	current := document
+
+	for _, segment := range segments[:len(segments)-1] {
+		next, ok := current[segment]
+
+		if !ok || next == nil {
+			if !create {
+				return nil, "", nil
+			}
+
+			created := bson.M{}
+			current[segment] = created
+			current = created
+			continue
+		}
+
+		nextDocument, ok := next.(bson.M)
+		if !ok {
+			return nil, "", fmt.Errorf("query: cannot traverse non-document field '%s' in path '%s'", segment, path)
+		}
+
+		current = nextDocument
+	}
+
+	return current, segments[len(segments)-1], nil
+}
+
+// Description:
+//
+//	Gets the MongoDB sort order rank of a canonical value's type.
+//
+// Parameters:
+//
+//	value The canonical value.
+//
+// Returns:
+//
+//	The type rank.
+func typeRank(value interface{}) int {
+	switch value.(type) {
+	case nil, primitive.Null, primitive.Undefined:
+		return 1
+	case int32, int64, float64, primitive.Decimal128:
+		return 2
+	case string, primitive.Symbol:
+		return 3
+	case bson.M:
+		return 4
+	case bson.A:
+		return 5
+	case primitive.Binary:
+		return 6
+	case primitive.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case primitive.DateTime:
+		return 9
+	case primitive.Timestamp:
+		return 10
+	case primitive.Regex:
+		return 11
+	}
+
+	return 12
+}
+
+// Description:
+//
+//	Compares two canonical values with the same type rank.
+//
+// Parameters:
+//
+//	left 	The left value.
+//	right 	The right value.
+//
+// Returns:
+//
+//	A negative number if left < right, zero if both are equal, a positive number otherwise.
+func compareSameRank(left interface{}, right interface{}) int {
+	switch typeRank(left) {
+	case 1:
+		return 0
+
+	case 2:
+		return compareFloats(ToFloat(left), ToFloat(right))
+
+	case 3:
+		return strings.Compare(toString(left), toString(right))
+
+	case 4:
+		return compareDocuments(left.(bson.M), right.(bson.M))
+
+	case 5:
+		return compareArrays(left.(bson.A), right.(bson.A))
+
+	case 6:
+		return bytes.Compare(left.(primitive.Binary).Data, right.(primitive.Binary).Data)
+
+	case 7:
+		leftID := left.(primitive.ObjectID)
+		rightID := right.(primitive.ObjectID)
+		return bytes.Compare(leftID[:], rightID[:])
+
+	case 8:
+		return compareBools(left.(bool), right.(bool))
+
+	case 9:
+		return compareInts(int64(left.(primitive.DateTime)), int64(right.(primitive.DateTime)))
+
+	case 10:
+		leftTimestamp := left.(primitive.Timestamp)
+		rightTimestamp := right.(primitive.Timestamp)
+
+		if leftTimestamp.T != rightTimestamp.T {
+			return compareInts(int64(leftTimestamp.T), int64(rightTimestamp.T))
+		}
+
+		return compareInts(int64(leftTimestamp.I), int64(rightTimestamp.I))
+	}
+
+	if reflect.DeepEqual(left, right) {
+		return 0
+	}
+
+	return strings.Compare(fmt.Sprint(left), fmt.Sprint(right))
+}
+
+// Description:
+//
+//	Compares two documents key by key, using sorted keys.
+//
+// Parameters:
+//
+//	left 	The left document.
+//	right 	The right document.
+//
+// Returns:
+//
+//	The comparison result.
+func compareDocuments(left bson.M, right bson.M) int {
+	leftKeys := sortedKeys(left)
+	rightKeys := sortedKeys(right)
+
+	for index := 0; index < len(leftKeys) && index < len(rightKeys); index++ {
+		result := strings.Compare(leftKeys[index], rightKeys[index])
+		if result != 0 {
+			return result
+		}
+
+		result = CompareValues(left[leftKeys[index]], right[rightKeys[index]])
+		if result != 0 {
+			return result
+		}
+	}
+
+	return len(leftKeys) - len(rightKeys)
+}
+
+// Description:
+//
+//	Compares two arrays element by element.
+//
+// Parameters:
+//
+//	left 	The left array.
+//	right 	The right array.
+//
+// Returns:
+//
+//	The comparison result.
+func compareArrays(left bson.A, right bson.A) int {
+	for index := 0; index < len(left) && index < len(right); index++ {
+		result := CompareValues(left[index], right[index])
+		if result != 0 {
+			return result
+		}
+	}
+
+	return len(left) - len(right)
+}
+
+// Description:
+//
+//	Gets the keys of a document in sorted order.
+//
+// Parameters:
+//
+//	document The document.
+//
+// Returns:
+//
+//	The sorted keys.
+func sortedKeys(document bson.M) []string {
+	keys := make([]string, 0, len(document))
+
+	for key := range document {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// Description:
+//
+//	Creates a deep copy of a canonical value.
+//
+// Parameters:
+//
+//	value The value to copy.
+//
+// Returns:
+//
+//	The copied value.
+func copyValue(value interface{}) interface{} {
+	switch current := value.(type) {
+	case bson.M:
+		result := make(bson.M, len(current))
+
+		for key, element := range current {
+			result[key] = copyValue(element)
+		}
+
+		return result
+
+	case bson.A:
+		result := make(bson.A, len(current))
+
+		for index, element := range current {
+			result[index] = copyValue(element)
+		}
+
+		return result
+	}
+
+	return value
+}
+
+// Description:
+//
+//	Converts a canonical numeric value to float64.
+//
+// Parameters:
+//
+//	value The numeric value.
+//
+// Returns:
+//
+//	The value as float64.
+func ToFloat(value interface{}) float64 {
+	switch number := value.(type) {
+	case int32:
+		return float64(number)
+	case int64:
+		return float64(number)
+	case float64:
+		return number
+	case primitive.Decimal128:
+		parsed, err := strconv.ParseFloat(number.String(), 64)
+		if err != nil {
+			return math.NaN()
+		}
+
+		return parsed
+	}
+
+	return math.NaN()
+}
+
+// Description:
+//
+//	Converts a canonical string-like value to string.
+//
+// Parameters:
+//
+//	value The string-like value.
+//
+// Returns:
+//
+//	The value as string.
+func toString(value interface{}) string {
+	if symbol, ok := value.(primitive.Symbol); ok {
+		return string(symbol)
+	}
+
+	return value.(string)
+}
+
+// Description:
+//
+//	Compares two float values. NaN is ordered before all other numbers.
+//
+// Parameters:
+//
+//	left 	The left value.
+//	right 	The right value.
+//
+// Returns:
+//
+//	The comparison result.
+func compareFloats(left float64, right float64) int {
+	switch {
+	case math.IsNaN(left) && math.IsNaN(right):
+		return 0
+	case math.IsNaN(left):
+		return -1
+	case math.IsNaN(right):
+		return 1
+	case left < right:
+		return -1
+	case left > right:
+		return 1
+	}
+
+	return 0
+}
+
+// Description:
+//
+//	Compares two integer values.
+//
+// Parameters:
+//
+//	left 	The left value.
+//	right 	The right value.
+//
+// Returns:
+//
+//	The comparison result.
+func compareInts(left int64, right int64) int {
+	switch {
+	case left < right:
+		return -1
+	case left > right:
+		return 1
+	}
+
+	return 0
+}
+
+// Description:
+//
+//	Compares two boolean values. False is ordered before true.
+//
+// Parameters:
+//
+//	left 	The left value.
+//	right 	The right value.
+//
+// Returns:
+//
+//	The comparison result.
+func compareBools(left bool, right bool) int {
+	switch {
+	case left == right:
+		return 0
+	case !left:
+		return -1
+	}
+
+	return 1
+}
+
+// Description:
+//
+//	Checks whether a value is numeric.
+//
+// Parameters:
+//
+//	value The value to check.
+//
+// Returns:
+//
+//	True, if the value is an integer, a floating point number or a decimal.
+func IsNumber(value interface{}) bool {
+	return typeRank(Canonical(value)) == typeRank(int32(0))
+}
@@ -0,0 +1,1169 @@
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+
+	// The maximum nesting depth of operators used if no limit is given.
+	DefaultMaxDepth = 16
+
+	// The maximum number of operators and values used if no limit is given.
+	DefaultMaxNodes = 512
+)
+
+// Description:
+//
+//	The options for decoding JSON encoded filters and updates.
+type DecodeOptions struct {
+
+	// The maximum nesting depth of operators. Falls back to the default if zero.
+	MaxDepth int
+
+	// The maximum number of operators and values. Falls back to the default if zero.
+	MaxNodes int
+
+	// The schema used to validate keys. Date fields also accept dates formatted as yyyy-MM-dd or RFC 3339.
+	// A nil schema accepts all keys.
+	Schema *Schema
+}
+
+// Description:
+//
+//	Describes an error in a JSON encoded filter or update.
+type DecodeError struct {
+
+	// The JSON path of the invalid operator, e.g. '$.args[1]'.
+	Path string
+
+	// The error message.
+	Message string
+}
+
+// Description:
+//
+//	The JSON wire form of a single filter or update operator.
+//	Operators are tagged by 'op', e.g. '{"op":"and","args":[...]}' or '{"op":"eq","key":"title","value":"x"}'.
+//	Values use the relaxed MongoDB extended JSON format, e.g. '{"$date":"2020-01-01T00:00:00Z"}'.
+type jsonOperator struct {
+
+	// The operator name.
+	Op string `json:"op"`
+
+	// The document key of field operators.
+	Key string `json:"key,omitempty"`
+
+	// The value of comparison operators.
+	Value json.RawMessage `json:"value,omitempty"`
+
+	// The values of the 'in', 'nin' and 'all' operators.
+	Values *[]json.RawMessage `json:"values,omitempty"`
+
+	// The flag of the 'exists' operator.
+	Exists *bool `json:"exists,omitempty"`
+
+	// The pattern of the 'regex' operator.
+	Pattern string `json:"pattern,omitempty"`
+
+	// The options of the 'regex' operator.
+	Options string `json:"options,omitempty"`
+
+	// The operands of logical operators and combined updates.
+	Args []json.RawMessage `json:"args,omitempty"`
+
+	// The key-value mappings of update operators.
+	Fields map[string]json.RawMessage `json:"fields,omitempty"`
+
+	// The keys of the 'unset' and 'currentDate' update operators.
+	Keys []string `json:"keys,omitempty"`
+}
+
+// Description:
+//
+//	The JSON wire form of a filter.
+type jsonFilter struct {
+
+	// The root operator, omitted if the filter matches all documents.
+	Root json.RawMessage `json:"root,omitempty"`
+
+	// The query result limit.
+	Limit uint32 `json:"limit,omitempty"`
+
+	// The sort specification, e.g. '-trackStats.streams,title'.
+	Sort string `json:"sort,omitempty"`
+}
+
+// Description:
+//
+//	Tracks the limits while decoding a single filter or update.
+type decoder struct {
+
+	// The decode options.
+	options DecodeOptions
+
+	// The number of decoded operators and values.
+	nodes int
+}
+
+// Description:
+//
+//	The JSON properties each operator accepts, besides 'op'.
+var operatorProperties = map[string][]string{
+	"and":         {"args"},
+	"or":          {"args"},
+	"nor":         {"args"},
+	"not":         {"args"},
+	"eq":          {"key", "value"},
+	"ne":          {"key", "value"},
+	"lt":          {"key", "value"},
+	"lte":         {"key", "value"},
+	"gt":          {"key", "value"},
+	"gte":         {"key", "value"},
+	"in":          {"key", "values"},
+	"nin":         {"key", "values"},
+	"all":         {"key", "values"},
+	"exists":      {"key", "exists"},
+	"regex":       {"key", "pattern", "options"},
+	"elemMatch":   {"key", "args"},
+	"set":         {"fields"},
+	"inc":         {"fields"},
+	"unset":       {"keys"},
+	"push":        {"fields"},
+	"addToSet":    {"fields"},
+	"pull":        {"fields"},
+	"min":         {"fields"},
+	"max":         {"fields"},
+	"currentDate": {"keys"},
+	"combine":     {"args"},
+}
+
+// Description:
+//
+//	Formats the decode error.
+//
+// Returns:
+//
+//	The error message, including the JSON path.
+func (err *DecodeError) Error() string {
+	return fmt.Sprintf("query: invalid operator at '%s': %s", err.Path, err.Message)
+}
+
+// Description:
+//
+//	Encodes a filter as JSON.
+//
+// Returns:
+//
+//	The JSON encoded filter, or an error if the filter contains an unknown operator.
+func (filter Filter) MarshalJSON() ([]byte, error) {
+	result := jsonFilter{
+		Limit: filter.Limit,
+		Sort:  FormatSort(filter.Sort),
+	}
+
+	if filter.Root != nil {
+		root, err := MarshalQuery(filter.Root)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Root = root
+	}
+
+	return json.Marshal(result)
+}
+
+// Description:
+//
+//	Decodes a JSON encoded filter, using the default decode options.
+//
+// Parameters:
+//
+//	data The JSON encoded filter.
+//
+// Returns:
+//
+//	An error if the filter is malformed or exceeds the default limits.
+func (filter *Filter) UnmarshalJSON(data []byte) error {
+	var result jsonFilter
+
+	err := decodeStrict(data, &result)
+	if err != nil {
+		return err
+	}
+
+	decoded := Filter{
+		Limit: result.Limit,
+	}
+
+	if len(result.Root) > 0 {
+		decoded.Root, err = UnmarshalQuery(result.Root, DecodeOptions{})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(result.Sort) > 0 {
+		decoded.Sort, err = ParseSort(result.Sort)
+		if err != nil {
+			return err
+		}
+	}
+
+	*filter = decoded
+	return nil
+}
+
+// Description:
+//
+//	Encodes an update as JSON.
+//
+// Returns:
+//
+//	The JSON encoded root update operator, or an error if the update contains an unknown operator.
+func (update Update) MarshalJSON() ([]byte, error) {
+	if update.Root == nil {
+		return []byte("null"), nil
+	}
+
+	return MarshalUpdate(update.Root)
+}
+
+// Description:
+//
+//	Decodes a JSON encoded update, using the default decode options.
+//
+// Parameters:
+//
+//	data The JSON encoded root update operator.
+//
+// Returns:
+//
+//	An error if the update is malformed or exceeds the default limits.
+func (update *Update) UnmarshalJSON(data []byte) error {
+	if string(bytes.TrimSpace(data)) == "null" {
+		*update = Update{}
+		return nil
+	}
+
+	root, err := UnmarshalUpdate(data, DecodeOptions{})
+	if err != nil {
+		return err
+	}
+
+	*update = Update{Root: root}
+	return nil
+}
+
+// Description:
+//
+//	Encodes a filter operator tree as JSON.
+//
+// Parameters:
+//
+//	filter The root filter operator.
+//
+// Returns:
+//
+//	The JSON encoded operator tree, or an error if the tree contains an unknown operator.
+func MarshalQuery(filter IQuery) ([]byte, error) {
+	node, err := encodeQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(node)
+}
+
+// Description:
+//
+//	Decodes a JSON encoded filter operator tree.
+//	Decoding is strict: unknown operators, unknown or superfluous properties and malformed values are rejected.
+//
+// Parameters:
+//
+//	data 	The JSON encoded operator tree.
+//	options The decode options.
+//
+// Returns:
+//
+//	The root filter operator, or a decode error.
+func UnmarshalQuery(data []byte, options DecodeOptions) (IQuery, error) {
+	state := &decoder{options: options}
+	return state.decodeQuery(data, "$", 1)
+}
+
+// Description:
+//
+//	Encodes an update operator tree as JSON.
+//
+// Parameters:
+//
+//	update The root update operator.
+//
+// Returns:
+//
+//	The JSON encoded operator tree, or an error if the tree contains an unknown operator.
+func MarshalUpdate(update IUpdate) ([]byte, error) {
+	node, err := encodeUpdate(update)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(node)
+}
+
+// Description:
+//
+//	Decodes a JSON encoded update operator tree.
+//	Decoding is strict: unknown operators, unknown or superfluous properties and malformed values are rejected.
+//
+// Parameters:
+//
+//	data 	The JSON encoded operator tree.
+//	options The decode options.
+//
+// Returns:
+//
+//	The root update operator, or a decode error.
+func UnmarshalUpdate(data []byte, options DecodeOptions) (IUpdate, error) {
+	state := &decoder{options: options}
+	return state.decodeUpdate(data, "$", 1)
+}
+
+// Description:
+//
+//	Converts a filter operator into its wire form.
+//
+// Parameters:
+//
+//	filter The filter operator.
+//
+// Returns:
+//
+//	The wire form, or an error if the operator is unknown.
+func encodeQuery(filter IQuery) (*jsonOperator, error) {
+	switch typed := filter.(type) {
+	case FilterOperatorAnd:
+		return encodeQueryArgs("and", typed.And)
+	case FilterOperatorOr:
+		return encodeQueryArgs("or", typed.Or)
+	case FilterOperatorNor:
+		return encodeQueryArgs("nor", typed.Nor)
+	case FilterOperatorNot:
+		return encodeQueryArgs("not", []IQuery{typed.Not})
+	case FilterOperatorEq:
+		return encodeComparison("eq", typed.Key, typed.Value)
+	case FilterOperatorNeq:
+		return encodeComparison("ne", typed.Key, typed.Value)
+	case FilterOperatorLt:
+		return encodeComparison("lt", typed.Key, typed.Value)
+	case FilterOperatorLte:
+		return encodeComparison("lte", typed.Key, typed.Value)
+	case FilterOperatorGt:
+		return encodeComparison("gt", typed.Key, typed.Value)
+	case FilterOperatorGte:
+		return encodeComparison("gte", typed.Key, typed.Value)
+	case FilterOperatorIn:
+		return encodeValues("in", typed.Key, typed.Values)
+	case FilterOperatorNin:
+		return encodeValues("nin", typed.Key, typed.Values)
+	case FilterOperatorAll:
+		return encodeValues("all", typed.Key, typed.Values)
+	case FilterOperatorExists:
+		exists := typed.Exists
+		return &jsonOperator{Op: "exists", Key: typed.Key, Exists: &exists}, nil
+	case FilterOperatorRegex:
+		return &jsonOperator{Op: "regex", Key: typed.Key, Pattern: typed.Pattern, Options: typed.Options}, nil
+	case FilterOperatorElemMatch:
+		node, err := encodeQueryArgs("elemMatch", []IQuery{typed.Match})
+		if err != nil {
+			return nil, err
+		}
+
+		node.Key = typed.Key
+		return node, nil
+	}
+
+	return nil, fmt.Errorf("query: cannot encode unknown filter operator %T", filter)
+}
+
+// Description:
+//
+//	Converts an update operator into its wire form.
+//
+// Parameters:
+//
+//	update The update operator.
+//
+// Returns:
+//
+//	The wire form, or an error if the operator is unknown.
+func encodeUpdate(update IUpdate) (*jsonOperator, error) {
+	switch typed := update.(type) {
+	case UpdateOperatorSet:
+		return encodeFields("set", typed.Set)
+	case UpdateOperatorInc:
+		return encodeFields("inc", typed.Inc)
+	case UpdateOperatorMin:
+		return encodeFields("min", typed.Min)
+	case UpdateOperatorMax:
+		return encodeFields("max", typed.Max)
+	case UpdateOperatorUnset:
+		return &jsonOperator{Op: "unset", Keys: typed.Unset}, nil
+	case UpdateOperatorCurrentDate:
+		return &jsonOperator{Op: "currentDate", Keys: typed.CurrentDate}, nil
+	case UpdateOperatorPush:
+		return encodeArrayFields("push", typed.Push)
+	case UpdateOperatorAddToSet:
+		return encodeArrayFields("addToSet", typed.AddToSet)
+	case UpdateOperatorPull:
+		return encodeArrayFields("pull", typed.Pull)
+	case UpdateOperatorCombine:
+		args := make([]json.RawMessage, len(typed.Operators))
+
+		for index, operator := range typed.Operators {
+			arg, err := MarshalUpdate(operator)
+			if err != nil {
+				return nil, err
+			}
+
+			args[index] = arg
+		}
+
+		return &jsonOperator{Op: "combine", Args: args}, nil
+	}
+
+	return nil, fmt.Errorf("query: cannot encode unknown update operator %T", update)
+}
+
+// Description:
+//
+//	Converts a logical operator into its wire form.
+//
+// Parameters:
+//
+//	op 			The operator name.
+//	operands 	The operands.
+//
+// Returns:
+//
+//	The wire form, or an error if an operand is unknown.
+func encodeQueryArgs(op string, operands []IQuery) (*jsonOperator, error) {
+	args := make([]json.RawMessage, len(operands))
+
+	for index, operand := range operands {
+		arg, err := MarshalQuery(operand)
+		if err != nil {
+			return nil, err
+		}
+
+		args[index] = arg
+	}
+
+	return &jsonOperator{Op: op, Args: args}, nil
+}
+
+// Description:
+//
+//	Converts a comparison operator into its wire form.
+//
+// Parameters:
+//
+//	op 		The operator name.
+//	key 	The document key.
+//	value 	The value to compare with.
+//
+// Returns:
+//
+//	The wire form, or an error if the value cannot be encoded.
+func encodeComparison(op string, key string, value interface{}) (*jsonOperator, error) {
+	encoded, err := encodeValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonOperator{Op: op, Key: key, Value: encoded}, nil
+}
+
+// Description:
+//
+//	Converts an operator with a list of values into its wire form.
+//
+// Parameters:
+//
+//	op 		The operator name.
+//	key 	The document key.
+//	values 	The values.
+//
+// Returns:
+//
+//	The wire form, or an error if a value cannot be encoded.
+func encodeValues(op string, key string, values []interface{}) (*jsonOperator, error) {
+	encoded := make([]json.RawMessage, len(values))
+
+	for index, value := range values {
+		raw, err := encodeValue(value)
+		if err != nil {
+			return nil, err
+		}
+
+		encoded[index] = raw
+	}
+
+	return &jsonOperator{Op: op, Key: key, Values: &encoded}, nil
+}
+
+// Description:
+//
+//	Converts an update operator with key-value mappings into its wire form.
+//
+// Parameters:
+//
+//	op 		The operator name.
+//	fields 	The key-value mappings.
+//
+// Returns:
+//
+//	The wire form, or an error if a value cannot be encoded.
+func encodeFields(op string, fields map[string]interface{}) (*jsonOperator, error) {
+	encoded := make(map[string]json.RawMessage, len(fields))
+
+	for key, value := range fields {
+		raw, err := encodeValue(value)
+		if err != nil {
+			return nil, err
+		}
+
+		encoded[key] = raw
+	}
+
+	return &jsonOperator{Op: op, Fields: encoded}, nil
+}
+
+// Description:
+//
+//	Converts an update operator with key-values mappings into its wire form.
+//
+// Parameters:
+//
+//	op 		The operator name.
+//	fields 	The key-values mappings.
+//
+// Returns:
+//
+//	The wire form, or an error if a value cannot be encoded.
+func encodeArrayFields(op string, fields map[string][]interface{}) (*jsonOperator, error) {
+	encoded := make(map[string]json.RawMessage, len(fields))
+
+	for key, values := range fields {
+		raw, err := encodeValue(valuesArray(values))
+		if err != nil {
+			return nil, err
+		}
+
+		encoded[key] = raw
+	}
+
+	return &jsonOperator{Op: op, Fields: encoded}, nil
+}
+
+// Description:
+//
+//	Encodes a single value as relaxed MongoDB extended JSON.
+//
+// Parameters:
+//
+//	value The value to encode.
+//
+// Returns:
+//
+//	The encoded value, or an error if the value cannot be encoded.
+func encodeValue(value interface{}) (json.RawMessage, error) {
+	data, err := bson.MarshalExtJSON(bson.D{{Key: "v", Value: value}}, false, false)
+	if err != nil {
+		return nil, fmt.Errorf("query: cannot encode value: %w", err)
+	}
+
+	var wrapper struct {
+		V json.RawMessage `json:"v"`
+	}
+
+	err = json.Unmarshal(data, &wrapper)
+	if err != nil {
+		return nil, fmt.Errorf("query: cannot encode value: %w", err)
+	}
+
+	return wrapper.V, nil
+}
+
+// Description:
+//
+//	Decodes a filter operator and its operands.
+//
+// Parameters:
+//
+//	data 	The JSON encoded operator.
+//	path 	The JSON path of the operator.
+//	depth 	The nesting depth of the operator, starting at 1.
+//
+// Returns:
+//
+//	The filter operator, or a decode error.
+func (state *decoder) decodeQuery(data []byte, path string, depth int) (IQuery, error) {
+	node, err := state.decodeOperator(data, path, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node.Op {
+	case "and", "or", "nor":
+		operands, err := state.decodeQueryArgs(node, path, depth, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		switch node.Op {
+		case "and":
+			return FilterOperatorAnd{And: operands}, nil
+		case "or":
+			return FilterOperatorOr{Or: operands}, nil
+		}
+
+		return FilterOperatorNor{Nor: operands}, nil
+
+	case "not":
+		operands, err := state.decodeQueryArgs(node, path, depth, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(operands) != 1 {
+			return nil, &DecodeError{Path: path, Message: "operator 'not' requires exactly one argument"}
+		}
+
+		return FilterOperatorNot{Not: operands[0]}, nil
+
+	case "elemMatch":
+		field, err := state.lookupKey(node, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(node.Args) != 1 {
+			return nil, &DecodeError{Path: path, Message: "operator 'elemMatch' requires exactly one argument"}
+		}
+
+		sub := *state
+		sub.options.Schema = state.options.Schema.Sub(field.Key)
+
+		match, err := sub.decodeQuery(node.Args[0], path+".args[0]", depth+1)
+		state.nodes = sub.nodes
+
+		if err != nil {
+			return nil, err
+		}
+
+		return FilterOperatorElemMatch{Key: field.Key, Match: match}, nil
+
+	case "exists":
+		field, err := state.lookupKey(node, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if node.Exists == nil {
+			return nil, &DecodeError{Path: path, Message: "property 'exists' is required"}
+		}
+
+		return FilterOperatorExists{Key: field.Key, Exists: *node.Exists}, nil
+
+	case "regex":
+		field, err := state.lookupKey(node, path)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = CompileRegex(node.Pattern, node.Options)
+		if err != nil {
+			return nil, &DecodeError{Path: path, Message: fmt.Sprintf("invalid regular expression: %s", err)}
+		}
+
+		return FilterOperatorRegex{Key: field.Key, Pattern: node.Pattern, Options: node.Options}, nil
+
+	case "in", "nin", "all":
+		field, err := state.lookupKey(node, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if node.Values == nil {
+			return nil, &DecodeError{Path: path, Message: "property 'values' is required"}
+		}
+
+		values := make([]interface{}, len(*node.Values))
+
+		for index, raw := range *node.Values {
+			values[index], err = state.decodeValue(raw, field, fmt.Sprintf("%s.values[%d]", path, index))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		switch node.Op {
+		case "in":
+			return FilterOperatorIn{Key: field.Key, Values: values}, nil
+		case "nin":
+			return FilterOperatorNin{Key: field.Key, Values: values}, nil
+		}
+
+		return FilterOperatorAll{Key: field.Key, Values: values}, nil
+
+	case "eq", "ne", "lt", "lte", "gt", "gte":
+		field, err := state.lookupKey(node, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if node.Value == nil {
+			return nil, &DecodeError{Path: path, Message: "property 'value' is required"}
+		}
+
+		value, err := state.decodeValue(node.Value, field, path+".value")
+		if err != nil {
+			return nil, err
+		}
+
+		switch node.Op {
+		case "eq":
+			return FilterOperatorEq{Key: field.Key, Value: value}, nil
+		case "ne":
+			return FilterOperatorNeq{Key: field.Key, Value: value}, nil
+		case "lt":
+			return FilterOperatorLt{Key: field.Key, Value: value}, nil
+		case "lte":
+			return FilterOperatorLte{Key: field.Key, Value: value}, nil
+		case "gt":
+			return FilterOperatorGt{Key: field.Key, Value: value}, nil
+		}
+
+		return FilterOperatorGte{Key: field.Key, Value: value}, nil
+	}
+
+	return nil, &DecodeError{Path: path, Message: fmt.Sprintf("unknown filter operator '%s'", node.Op)}
+}
+
+// Description:
+//
+//	Decodes an update operator and its operands.
+//
+// Parameters:
+//
+//	data 	The JSON encoded operator.
+//	path 	The JSON path of the operator.
+//	depth 	The nesting depth of the operator, starting at 1.
+//
+// Returns:
+//
+//	The update operator, or a decode error.
+func (state *decoder) decodeUpdate(data []byte, path string, depth int) (IUpdate, error) {
+	node, err := state.decodeOperator(data, path, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node.Op {
+	case "combine":
+		if len(node.Args) == 0 {
+			return nil, &DecodeError{Path: path, Message: "operator 'combine' requires at least one argument"}
+		}
+
+		operators := make([]IUpdate, len(node.Args))
+
+		for index, arg := range node.Args {
+			operators[index], err = state.decodeUpdate(arg, fmt.Sprintf("%s.args[%d]", path, index), depth+1)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return UpdateOperatorCombine{Operators: operators}, nil
+
+	case "unset", "currentDate":
+		if len(node.Keys) == 0 {
+			return nil, &DecodeError{Path: path, Message: "property 'keys' must not be empty"}
+		}
+
+		keys := make([]string, len(node.Keys))
+
+		for index, key := range node.Keys {
+			field, err := state.lookupField(key, fmt.Sprintf("%s.keys[%d]", path, index))
+			if err != nil {
+				return nil, err
+			}
+
+			keys[index] = field.Key
+		}
+
+		if node.Op == "unset" {
+			return UpdateOperatorUnset{Unset: keys}, nil
+		}
+
+		return UpdateOperatorCurrentDate{CurrentDate: keys}, nil
+
+	case "set", "inc", "min", "max":
+		fields, err := state.decodeFields(node, path)
+		if err != nil {
+			return nil, err
+		}
+
+		switch node.Op {
+		case "set":
+			return UpdateOperatorSet{Set: fields}, nil
+		case "inc":
+			return UpdateOperatorInc{Inc: fields}, nil
+		case "min":
+			return UpdateOperatorMin{Min: fields}, nil
+		}
+
+		return UpdateOperatorMax{Max: fields}, nil
+
+	case "push", "addToSet", "pull":
+		fields, err := state.decodeFields(node, path)
+		if err != nil {
+			return nil, err
+		}
+
+		arrays := make(map[string][]interface{}, len(fields))
+
+		for key, value := range fields {
+			array, ok := value.(bson.A)
+			if !ok {
+				return nil, &DecodeError{Path: fmt.Sprintf("%s.fields.%s", path, key), Message: "value must be an array"}
+			}
+
+			arrays[key] = []interface{}(array)
+		}
+
+		switch node.Op {
+		case "push":
+			return UpdateOperatorPush{Push: arrays}, nil
+		case "addToSet":
+			return UpdateOperatorAddToSet{AddToSet: arrays}, nil
+		}
+
+		return UpdateOperatorPull{Pull: arrays}, nil
+	}
+
+	return nil, &DecodeError{Path: path, Message: fmt.Sprintf("unknown update operator '%s'", node.Op)}
+}
+
+// Description:
+//
+//	Decodes the wire form of an operator and enforces the limits.
+//	Rejects properties the operator does not accept.
+//
+// Parameters:
+//
+//	data 	The JSON encoded operator.
+//	path 	The JSON path of the operator.
+//	depth 	The nesting depth of the operator, starting at 1.
+//
+// Returns:
+//
+//	The wire form, or a decode error.
+func (state *decoder) decodeOperator(data []byte, path string, depth int) (*jsonOperator, error) {
+	maxDepth := state.options.MaxDepth
+	if maxDepth == 0 {
+		maxDepth = DefaultMaxDepth
+	}
+
+	if depth > maxDepth {
+		return nil, &DecodeError{Path: path, Message: fmt.Sprintf("maximum depth of %d exceeded", maxDepth)}
+	}
+
+	err := state.count(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var properties map[string]json.RawMessage
+
+	err = json.Unmarshal(data, &properties)
+	if err != nil || properties == nil {
+		return nil, &DecodeError{Path: path, Message: "operator must be a JSON object"}
+	}
+
+	var node jsonOperator
+
+	err = decodeStrict(data, &node)
+	if err != nil {
+		return nil, &DecodeError{Path: path, Message: err.Error()}
+	}
+
+	accepted, ok := operatorProperties[node.Op]
+	if !ok {
+		return nil, &DecodeError{Path: path, Message: fmt.Sprintf("unknown operator '%s'", node.Op)}
+	}
+
+	for property := range properties {
+		if property == "op" {
+			continue
+		}
+
+		if !containsString(accepted, property) {
+			return nil, &DecodeError{Path: path, Message: fmt.Sprintf("operator '%s' does not accept property '%s'", node.Op, property)}
+		}
+	}
+
+	return &node, nil
+}
+
+// Description:
+//
+//	Decodes the operands of a logical filter operator.
+//
+// Parameters:
+//
+//	node 	The wire form of the operator.
+//	path 	The JSON path of the operator.
+//	depth 	The nesting depth of the operator.
+//	minimum The minimum number of operands.
+//
+// Returns:
+//
+//	The operands, or a decode error.
+func (state *decoder) decodeQueryArgs(node *jsonOperator, path string, depth int, minimum int) ([]IQuery, error) {
+	if len(node.Args) < minimum {
+		return nil, &DecodeError{Path: path, Message: fmt.Sprintf("operator '%s' requires at least %d argument(s)", node.Op, minimum)}
+	}
+
+	operands := make([]IQuery, len(node.Args))
+
+	for index, arg := range node.Args {
+		operand, err := state.decodeQuery(arg, fmt.Sprintf("%s.args[%d]", path, index), depth+1)
+		if err != nil {
+			return nil, err
+		}
+
+		operands[index] = operand
+	}
+
+	return operands, nil
+}
+
+// Description:
+//
+//	Decodes the key-value mappings of an update operator.
+//
+// Parameters:
+//
+//	node The wire form of the operator.
+//	path The JSON path of the operator.
+//
+// Returns:
+//
+//	The key-value mappings, keyed by document key, or a decode error.
+func (state *decoder) decodeFields(node *jsonOperator, path string) (map[string]interface{}, error) {
+	if len(node.Fields) == 0 {
+		return nil, &DecodeError{Path: path, Message: "property 'fields' must not be empty"}
+	}
+
+	keys := make([]string, 0, len(node.Fields))
+	for key := range node.Fields {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	fields := make(map[string]interface{}, len(keys))
+
+	for _, key := range keys {
+		fieldPath := fmt.Sprintf("%s.fields.%s", path, key)
+
+		field, err := state.lookupField(key, fieldPath)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := state.decodeValue(node.Fields[key], field, fieldPath)
+		if err != nil {
+			return nil, err
+		}
+
+		fields[field.Key] = value
+	}
+
+	return fields, nil
+}
+
+// Description:
+//
+//	Looks up the key of a field operator in the schema.
+//
+// Parameters:
+//
+//	node The wire form of the operator.
+//	path The JSON path of the operator.
+//
+// Returns:
+//
+//	The schema field, or a decode error if the key is missing or unknown.
+func (state *decoder) lookupKey(node *jsonOperator, path string) (SchemaField, error) {
+	if len(node.Key) == 0 {
+		return SchemaField{}, &DecodeError{Path: path, Message: "property 'key' is required"}
+	}
+
+	return state.lookupField(node.Key, path+".key")
+}
+
+// Description:
+//
+//	Looks up a field in the schema.
+//
+// Parameters:
+//
+//	key 	The field name or document key.
+//	path 	The JSON path referencing the field.
+//
+// Returns:
+//
+//	The schema field, or a decode error if the field is unknown.
+func (state *decoder) lookupField(key string, path string) (SchemaField, error) {
+	field, ok := state.options.Schema.Lookup(key)
+	if !ok || len(key) == 0 {
+		return SchemaField{}, &DecodeError{Path: path, Message: fmt.Sprintf("unknown field '%s'", key)}
+	}
+
+	return field, nil
+}
+
+// Description:
+//
+//	Decodes a value in relaxed MongoDB extended JSON format.
+//	Strings compared with date fields are parsed as dates.
+//
+// Parameters:
+//
+//	data 	The JSON encoded value.
+//	field 	The field the value refers to.
+//	path 	The JSON path of the value.
+//
+// Returns:
+//
+//	The decoded value, or a decode error.
+func (state *decoder) decodeValue(data json.RawMessage, field SchemaField, path string) (interface{}, error) {
+	err := state.count(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var wrapper bson.M
+
+	document := append(append([]byte(`{"v":`), data...), '}')
+	err = bson.UnmarshalExtJSON(document, false, &wrapper)
+
+	if err != nil {
+		return nil, &DecodeError{Path: path, Message: "value is not valid extended JSON"}
+	}
+
+	value := wrapper["v"]
+
+	text, isString := value.(string)
+	if isString && field.Type == SchemaTypeDate {
+		date, err := time.Parse("2006-01-02", text)
+		if err != nil {
+			date, err = time.Parse(time.RFC3339, text)
+		}
+
+		if err != nil {
+			return nil, &DecodeError{Path: path, Message: "expected following format: yyyy-MM-dd or RFC 3339"}
+		}
+
+		return date, nil
+	}
+
+	return value, nil
+}
+
+// Description:
+//
+//	Counts a decoded operator or value and enforces the node limit.
+//
+// Parameters:
+//
+//	path The JSON path of the node.
+//
+// Returns:
+//
+//	A decode error if the node limit is exceeded.
+func (state *decoder) count(path string) error {
+	maxNodes := state.options.MaxNodes
+	if maxNodes == 0 {
+		maxNodes = DefaultMaxNodes
+	}
+
+	state.nodes++
+
+	if state.nodes > maxNodes {
+		return &DecodeError{Path: path, Message: fmt.Sprintf("maximum number of %d operators and values exceeded", maxNodes)}
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Decodes JSON, rejecting unknown properties and trailing data.
+//
+// Parameters:
+//
+//	data 	The JSON data.
+//	target 	The target to decode into.
+//
+// Returns:
+//
+//	An error if the data is malformed.
+func decodeStrict(data []byte, target interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(target)
+	if err != nil {
+		return err
+	}
+
+	if decoder.More() {
+		return fmt.Errorf("unexpected data after JSON value")
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Checks whether a list of strings contains a string.
+//
+// Parameters:
+//
+//	values 	The list to search.
+//	value 	The string to search for.
+//
+// Returns:
+//
+//	True, if the list contains the string.
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
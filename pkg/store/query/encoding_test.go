@@ -0,0 +1,80 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalQueryLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		options DecodeOptions
+		valid   bool
+	}{
+		{name: "depth at the limit", filter: nestedTestFilter(4), options: DecodeOptions{MaxDepth: 4}, valid: true},
+		{name: "depth over the limit", filter: nestedTestFilter(5), options: DecodeOptions{MaxDepth: 4}},
+		{name: "depth at the default limit", filter: nestedTestFilter(DefaultMaxDepth), valid: true},
+		{name: "depth over the default limit", filter: nestedTestFilter(DefaultMaxDepth + 1)},
+		{name: "nodes at the limit", filter: wideTestFilter(9), options: DecodeOptions{MaxNodes: 10}, valid: true},
+		{name: "nodes over the limit", filter: wideTestFilter(10), options: DecodeOptions{MaxNodes: 10}},
+		{name: "nodes at the default limit", filter: wideTestFilter(DefaultMaxNodes - 1), valid: true},
+		{name: "nodes over the default limit", filter: wideTestFilter(DefaultMaxNodes)},
+		{name: "element match counts towards the limits", filter: `{"op":"elemMatch","key":"tags","args":[` + nestedTestFilter(2) + `]}`, options: DecodeOptions{MaxDepth: 2}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := UnmarshalQuery([]byte(test.filter), test.options)
+
+			if test.valid && err != nil {
+				t.Errorf("expected the filter to be accepted, received '%s'", err)
+			}
+
+			var decodeError *DecodeError
+			if !test.valid && !errors.As(err, &decodeError) {
+				t.Errorf("expected a decode error, received '%v'", err)
+			}
+		})
+	}
+}
+
+// Description:
+//
+//	Creates a JSON encoded filter of a given nesting depth,
+//	a comparison wrapped into negations.
+//
+// Parameters:
+//
+//	depth The nesting depth, at least 1.
+//
+// Returns:
+//
+//	The JSON encoded filter.
+func nestedTestFilter(depth int) string {
+	return strings.Repeat(`{"op":"not","args":[`, depth-1) + `{"op":"eq","key":"title","value":"x"}` + strings.Repeat(`]}`, depth-1)
+}
+
+// Description:
+//
+//	Creates a JSON encoded filter with a given number of values,
+//	counting one more node for the operator.
+//
+// Parameters:
+//
+//	values The number of values.
+//
+// Returns:
+//
+//	The JSON encoded filter.
+func wideTestFilter(values int) string {
+	encoded := make([]string, values)
+
+	for index := range encoded {
+		encoded[index] = fmt.Sprintf(`"%d"`, index)
+	}
+
+	return `{"op":"in","key":"title","values":[` + strings.Join(encoded, ",") + `]}`
+}
@@ -1,6 +1,10 @@
 package query
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
+
 	"go.mongodb.org/mongo-driver/bson"
 )
 
@@ -15,6 +19,12 @@ type Filter struct {
 
 	// The query result limit.
 	Limit uint32
+
+	// The sort specification. Results are sorted by the keys in order.
+	Sort []SortKey
+
+	// The projected document keys. All fields are returned if empty.
+	Projection []string
 }
 
 // Description:
@@ -137,6 +147,136 @@ type FilterOperatorGte struct {
 	Value interface{}
 }
 
+// Description:
+//
+//	The 'nor' filter.
+//	Allows to filter documents which match none of the given filter conditions.
+type FilterOperatorNor struct {
+
+	// The filter interface implementation.
+	IQuery
+
+	// All filter conditions which must not be matched.
+	Nor []IQuery
+}
+
+// Description:
+//
+//	The 'not' filter.
+//	Allows to filter documents which do not match the given filter condition.
+type FilterOperatorNot struct {
+
+	// The filter interface implementation.
+	IQuery
+
+	// The filter condition to negate.
+	Not IQuery
+}
+
+// Description:
+//
+//	The 'in' filter.
+//	Allows to filter documents for fields matching any of the given values.
+//	For array fields, any element must match any of the given values.
+type FilterOperatorIn struct {
+
+	// The filter interface implementation.
+	IQuery
+
+	// The document key to refer to.
+	Key string
+
+	// The document values of which at least one should be matched.
+	Values []interface{}
+}
+
+// Description:
+//
+//	The 'not in' filter.
+//	Allows to filter documents for fields matching none of the given values.
+//	Also matches documents which do not contain the field.
+type FilterOperatorNin struct {
+
+	// The filter interface implementation.
+	IQuery
+
+	// The document key to refer to.
+	Key string
+
+	// The document values which should not be matched.
+	Values []interface{}
+}
+
+// Description:
+//
+//	The 'all' filter.
+//	Allows to filter documents for array fields containing all of the given values.
+type FilterOperatorAll struct {
+
+	// The filter interface implementation.
+	IQuery
+
+	// The document key to refer to.
+	Key string
+
+	// The document values which should all be contained.
+	Values []interface{}
+}
+
+// Description:
+//
+//	The 'exists' filter.
+//	Allows to filter documents which do or do not contain a specific field.
+//	Fields with a null value exist.
+type FilterOperatorExists struct {
+
+	// The filter interface implementation.
+	IQuery
+
+	// The document key to refer to.
+	Key string
+
+	// Whether the field should exist.
+	Exists bool
+}
+
+// Description:
+//
+//	The 'regex' filter.
+//	Allows to filter documents for string fields matching a regular expression.
+//	Only the subset of the MongoDB regular expression syntax shared with Go is supported.
+type FilterOperatorRegex struct {
+
+	// The filter interface implementation.
+	IQuery
+
+	// The document key to refer to.
+	Key string
+
+	// The regular expression pattern.
+	Pattern string
+
+	// The regular expression options: 'i' (case insensitive), 'm' (multi line) and 's' (dot matches new lines).
+	Options string
+}
+
+// Description:
+//
+//	The 'element match' filter.
+//	Allows to filter documents for array fields containing at least one embedded document
+//	which matches all given filter conditions. Keys of the filter conditions are relative to the array element.
+type FilterOperatorElemMatch struct {
+
+	// The filter interface implementation.
+	IQuery
+
+	// The document key to refer to.
+	Key string
+
+	// The filter condition at least one array element should match.
+	Match IQuery
+}
+
 // Description:
 //
 //	Compiles the filter and potential sub filters into a MongoDB BSON document.
@@ -236,3 +376,457 @@ func (filter FilterOperatorGt) Compile() bson.M {
 func (filter FilterOperatorGte) Compile() bson.M {
 	return bson.M{filter.Key: bson.M{"$gte": filter.Value}}
 }
+
+// Description:
+//
+//	Evaluates the filter and potential sub filters against an in-memory document.
+//
+// Parameters:
+//
+//	document The document to evaluate.
+//
+// Returns:
+//
+//	True, if the document matches all sub filters.
+func (filter FilterOperatorAnd) Evaluate(document bson.M) bool {
+	for _, and := range filter.And {
+		if !and.Evaluate(document) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Description:
+//
+//	Evaluates the filter and potential sub filters against an in-memory document.
+//
+// Parameters:
+//
+//	document The document to evaluate.
+//
+// Returns:
+//
+//	True, if the document matches at least one sub filter.
+func (filter FilterOperatorOr) Evaluate(document bson.M) bool {
+	for _, or := range filter.Or {
+		if or.Evaluate(document) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Evaluates the filter against an in-memory document.
+//
+// Parameters:
+//
+//	document The document to evaluate.
+//
+// Returns:
+//
+//	True, if the document field equals the filter value.
+func (filter FilterOperatorEq) Evaluate(document bson.M) bool {
+	return equalsAt(document, filter.Key, filter.Value)
+}
+
+// Description:
+//
+//	Evaluates the filter against an in-memory document.
+//
+// Parameters:
+//
+//	document The document to evaluate.
+//
+// Returns:
+//
+//	True, if the document field does not equal the filter value.
+func (filter FilterOperatorNeq) Evaluate(document bson.M) bool {
+	return !equalsAt(document, filter.Key, filter.Value)
+}
+
+// Description:
+//
+//	Evaluates the filter against an in-memory document.
+//
+// Parameters:
+//
+//	document The document to evaluate.
+//
+// Returns:
+//
+//	True, if the document field is less than the filter value.
+func (filter FilterOperatorLt) Evaluate(document bson.M) bool {
+	return compareAt(document, filter.Key, filter.Value, func(result int) bool {
+		return result < 0
+	})
+}
+
+// Description:
+//
+//	Evaluates the filter against an in-memory document.
+//
+// Parameters:
+//
+//	document The document to evaluate.
+//
+// Returns:
+//
+//	True, if the document field is less than or equal the filter value.
+func (filter FilterOperatorLte) Evaluate(document bson.M) bool {
+	return compareAt(document, filter.Key, filter.Value, func(result int) bool {
+		return result <= 0
+	})
+}
+
+// Description:
+//
+//	Evaluates the filter against an in-memory document.
+//
+// Parameters:
+//
+//	document The document to evaluate.
+//
+// Returns:
+//
+//	True, if the document field is greater than the filter value.
+func (filter FilterOperatorGt) Evaluate(document bson.M) bool {
+	return compareAt(document, filter.Key, filter.Value, func(result int) bool {
+		return result > 0
+	})
+}
+
+// Description:
+//
+//	Evaluates the filter against an in-memory document.
+//
+// Parameters:
+//
+//	document The document to evaluate.
+//
+// Returns:
+//
+//	True, if the document field is greater than or equal the filter value.
+func (filter FilterOperatorGte) Evaluate(document bson.M) bool {
+	return compareAt(document, filter.Key, filter.Value, func(result int) bool {
+		return result >= 0
+	})
+}
+
+// Description:
+//
+//	Compiles the filter and potential sub filters into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this filter.
+func (filter FilterOperatorNor) Compile() bson.M {
+	norArray := make([]bson.M, 0)
+
+	for _, nor := range filter.Nor {
+		norArray = append(norArray, nor.Compile())
+	}
+
+	return bson.M{"$nor": norArray}
+}
+
+// Description:
+//
+//	Compiles the filter and potential sub filters into a MongoDB BSON document.
+//	MongoDB only supports '$not' on field level, so the negation is expressed as '$nor'
+//	with a single condition, which negates arbitrary filters.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this filter.
+func (filter FilterOperatorNot) Compile() bson.M {
+	return bson.M{"$nor": []bson.M{filter.Not.Compile()}}
+}
+
+// Description:
+//
+//	Compiles the filter and potential sub filters into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this filter.
+func (filter FilterOperatorIn) Compile() bson.M {
+	return bson.M{filter.Key: bson.M{"$in": valuesArray(filter.Values)}}
+}
+
+// Description:
+//
+//	Compiles the filter and potential sub filters into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this filter.
+func (filter FilterOperatorNin) Compile() bson.M {
+	return bson.M{filter.Key: bson.M{"$nin": valuesArray(filter.Values)}}
+}
+
+// Description:
+//
+//	Compiles the filter and potential sub filters into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this filter.
+func (filter FilterOperatorAll) Compile() bson.M {
+	return bson.M{filter.Key: bson.M{"$all": valuesArray(filter.Values)}}
+}
+
+// Description:
+//
+//	Compiles the filter and potential sub filters into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this filter.
+func (filter FilterOperatorExists) Compile() bson.M {
+	return bson.M{filter.Key: bson.M{"$exists": filter.Exists}}
+}
+
+// Description:
+//
+//	Compiles the filter and potential sub filters into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this filter.
+func (filter FilterOperatorRegex) Compile() bson.M {
+	return bson.M{filter.Key: bson.M{"$regex": filter.Pattern, "$options": filter.Options}}
+}
+
+// Description:
+//
+//	Compiles the filter and potential sub filters into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this filter.
+func (filter FilterOperatorElemMatch) Compile() bson.M {
+	return bson.M{filter.Key: bson.M{"$elemMatch": filter.Match.Compile()}}
+}
+
+// Description:
+//
+//	Evaluates the filter and potential sub filters against an in-memory document.
+//
+// Parameters:
+//
+//	document The document to evaluate.
+//
+// Returns:
+//
+//	True, if the document matches none of the sub filters.
+func (filter FilterOperatorNor) Evaluate(document bson.M) bool {
+	for _, nor := range filter.Nor {
+		if nor.Evaluate(document) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Description:
+//
+//	Evaluates the filter and potential sub filters against an in-memory document.
+//
+// Parameters:
+//
+//	document The document to evaluate.
+//
+// Returns:
+//
+//	True, if the document does not match the negated filter.
+func (filter FilterOperatorNot) Evaluate(document bson.M) bool {
+	return !filter.Not.Evaluate(document)
+}
+
+// Description:
+//
+//	Evaluates the filter against an in-memory document.
+//
+// Parameters:
+//
+//	document The document to evaluate.
+//
+// Returns:
+//
+//	True, if the document field equals any of the filter values.
+func (filter FilterOperatorIn) Evaluate(document bson.M) bool {
+	for _, value := range filter.Values {
+		if equalsAt(document, filter.Key, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Evaluates the filter against an in-memory document.
+//
+// Parameters:
+//
+//	document The document to evaluate.
+//
+// Returns:
+//
+//	True, if the document field equals none of the filter values.
+func (filter FilterOperatorNin) Evaluate(document bson.M) bool {
+	return !FilterOperatorIn{Key: filter.Key, Values: filter.Values}.Evaluate(document)
+}
+
+// Description:
+//
+//	Evaluates the filter against an in-memory document.
+//
+// Parameters:
+//
+//	document The document to evaluate.
+//
+// Returns:
+//
+//	True, if the document field contains all filter values. False if there are no filter values.
+func (filter FilterOperatorAll) Evaluate(document bson.M) bool {
+	if len(filter.Values) == 0 {
+		return false
+	}
+
+	for _, value := range filter.Values {
+		if !equalsAt(document, filter.Key, value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Description:
+//
+//	Evaluates the filter against an in-memory document.
+//
+// Parameters:
+//
+//	document The document to evaluate.
+//
+// Returns:
+//
+//	True, if the existence of the document field matches the filter.
+func (filter FilterOperatorExists) Evaluate(document bson.M) bool {
+	return (len(LookupPath(document, filter.Key)) > 0) == filter.Exists
+}
+
+// Description:
+//
+//	Evaluates the filter against an in-memory document.
+//	Invalid patterns never match.
+//
+// Parameters:
+//
+//	document The document to evaluate.
+//
+// Returns:
+//
+//	True, if the document field is a string matching the regular expression.
+func (filter FilterOperatorRegex) Evaluate(document bson.M) bool {
+	expression, err := CompileRegex(filter.Pattern, filter.Options)
+	if err != nil {
+		return false
+	}
+
+	return anyValue(LookupPath(document, filter.Key), func(value interface{}) bool {
+		text, ok := value.(string)
+		return ok && expression.MatchString(text)
+	})
+}
+
+// Description:
+//
+//	Evaluates the filter and potential sub filters against an in-memory document.
+//
+// Parameters:
+//
+//	document The document to evaluate.
+//
+// Returns:
+//
+//	True, if the document field is an array containing an embedded document matching the sub filter.
+func (filter FilterOperatorElemMatch) Evaluate(document bson.M) bool {
+	for _, value := range LookupPath(document, filter.Key) {
+		array, ok := Canonical(value).(bson.A)
+		if !ok {
+			continue
+		}
+
+		for _, element := range array {
+			embedded, ok := element.(bson.M)
+			if ok && filter.Match.Evaluate(embedded) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Compiles a MongoDB regular expression into a Go regular expression.
+//
+// Parameters:
+//
+//	pattern The regular expression pattern.
+//	options The MongoDB regular expression options.
+//
+// Returns:
+//
+//	The compiled regular expression, or an error if the pattern or an option is not supported.
+func CompileRegex(pattern string, options string) (*regexp.Regexp, error) {
+	flags := ""
+
+	for _, option := range options {
+		switch option {
+		case 'i', 'm', 's':
+			if !strings.ContainsRune(flags, option) {
+				flags += string(option)
+			}
+		default:
+			return nil, fmt.Errorf("query: unsupported regex option '%c'", option)
+		}
+	}
+
+	if len(flags) > 0 {
+		pattern = fmt.Sprintf("(?%s)%s", flags, pattern)
+	}
+
+	return regexp.Compile(pattern)
+}
+
+// Description:
+//
+//	Converts filter values into a bson array.
+//	A nil slice is converted into an empty array, as MongoDB rejects null operands.
+//
+// Parameters:
+//
+//	values The filter values.
+//
+// Returns:
+//
+//	The bson array.
+func valuesArray(values []interface{}) bson.A {
+	if values == nil {
+		return bson.A{}
+	}
+
+	return bson.A(values)
+}
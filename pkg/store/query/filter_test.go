@@ -0,0 +1,202 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Description:
+//
+//	A filter test case.
+//	The expected matches follow the documented MongoDB semantics of the compiled query,
+//	so the in-memory evaluation is checked against what MongoDB would return.
+type filterTest struct {
+
+	// The name of the test case.
+	name string
+
+	// The filter under test.
+	filter IQuery
+
+	// The expected compiled MongoDB query.
+	compiled bson.M
+
+	// The documents the compiled query matches in MongoDB.
+	matches []bson.M
+
+	// The documents the compiled query does not match in MongoDB.
+	misses []bson.M
+}
+
+func TestFilterOperators(t *testing.T) {
+	tests := []filterTest{
+		{
+			name:     "in matches scalars and array elements",
+			filter:   FilterOperatorIn{Key: "genre", Values: []interface{}{"house", "techno"}},
+			compiled: bson.M{"genre": bson.M{"$in": bson.A{"house", "techno"}}},
+			matches:  []bson.M{{"genre": "house"}, {"genre": bson.A{"trance", "techno"}}},
+			misses:   []bson.M{{"genre": "trance"}, {"genre": bson.A{}}, {}, {"genre": nil}},
+		},
+		{
+			name:     "in with null matches missing fields",
+			filter:   FilterOperatorIn{Key: "label", Values: []interface{}{nil}},
+			compiled: bson.M{"label": bson.M{"$in": bson.A{nil}}},
+			matches:  []bson.M{{}, {"label": nil}},
+			misses:   []bson.M{{"label": "Armada"}},
+		},
+		{
+			name:     "in with empty values matches nothing",
+			filter:   FilterOperatorIn{Key: "genre"},
+			compiled: bson.M{"genre": bson.M{"$in": bson.A{}}},
+			misses:   []bson.M{{"genre": "house"}, {"genre": bson.A{}}, {}},
+		},
+		{
+			name:     "in compares numbers across types",
+			filter:   FilterOperatorIn{Key: "tempo", Values: []interface{}{int32(128)}},
+			compiled: bson.M{"tempo": bson.M{"$in": bson.A{int32(128)}}},
+			matches:  []bson.M{{"tempo": int64(128)}, {"tempo": 128.0}},
+			misses:   []bson.M{{"tempo": "128"}},
+		},
+		{
+			name:     "nin matches missing fields",
+			filter:   FilterOperatorNin{Key: "genre", Values: []interface{}{"house"}},
+			compiled: bson.M{"genre": bson.M{"$nin": bson.A{"house"}}},
+			matches:  []bson.M{{"genre": "techno"}, {}, {"genre": nil}, {"genre": bson.A{}}},
+			misses:   []bson.M{{"genre": "house"}, {"genre": bson.A{"techno", "house"}}},
+		},
+		{
+			name:     "nin with empty values matches everything",
+			filter:   FilterOperatorNin{Key: "genre", Values: []interface{}{}},
+			compiled: bson.M{"genre": bson.M{"$nin": bson.A{}}},
+			matches:  []bson.M{{"genre": "house"}, {}},
+		},
+		{
+			name:     "all requires every value",
+			filter:   FilterOperatorAll{Key: "tags", Values: []interface{}{"a", "b"}},
+			compiled: bson.M{"tags": bson.M{"$all": bson.A{"a", "b"}}},
+			matches:  []bson.M{{"tags": bson.A{"b", "c", "a"}}},
+			misses:   []bson.M{{"tags": bson.A{"a", "c"}}, {"tags": "a"}, {"tags": bson.A{}}, {}},
+		},
+		{
+			name:     "all matches scalars",
+			filter:   FilterOperatorAll{Key: "tags", Values: []interface{}{"a"}},
+			compiled: bson.M{"tags": bson.M{"$all": bson.A{"a"}}},
+			matches:  []bson.M{{"tags": "a"}, {"tags": bson.A{"a"}}},
+			misses:   []bson.M{{"tags": "b"}, {}},
+		},
+		{
+			name:     "all with empty values matches nothing",
+			filter:   FilterOperatorAll{Key: "tags", Values: []interface{}{}},
+			compiled: bson.M{"tags": bson.M{"$all": bson.A{}}},
+			misses:   []bson.M{{"tags": bson.A{}}, {"tags": bson.A{"a"}}, {}},
+		},
+		{
+			name:     "exists matches explicit null",
+			filter:   FilterOperatorExists{Key: "deletedAt", Exists: true},
+			compiled: bson.M{"deletedAt": bson.M{"$exists": true}},
+			matches:  []bson.M{{"deletedAt": nil}, {"deletedAt": int64(1)}},
+			misses:   []bson.M{{}, {"other": nil}},
+		},
+		{
+			name:     "not exists excludes explicit null",
+			filter:   FilterOperatorExists{Key: "deletedAt", Exists: false},
+			compiled: bson.M{"deletedAt": bson.M{"$exists": false}},
+			matches:  []bson.M{{}},
+			misses:   []bson.M{{"deletedAt": nil}},
+		},
+		{
+			name:     "exists resolves nested paths",
+			filter:   FilterOperatorExists{Key: "audioFeatures.key", Exists: true},
+			compiled: bson.M{"audioFeatures.key": bson.M{"$exists": true}},
+			matches:  []bson.M{{"audioFeatures": bson.M{"key": nil}}},
+			misses:   []bson.M{{"audioFeatures": bson.M{}}, {"audioFeatures": nil}},
+		},
+		{
+			name:     "regex is case sensitive by default",
+			filter:   FilterOperatorRegex{Key: "title", Pattern: "^summer"},
+			compiled: bson.M{"title": bson.M{"$regex": "^summer", "$options": ""}},
+			matches:  []bson.M{{"title": "summer nights"}, {"title": bson.A{"winter", "summer"}}},
+			misses:   []bson.M{{"title": "Summer Nights"}, {"title": int32(1)}, {}},
+		},
+		{
+			name:     "regex with i option ignores case",
+			filter:   FilterOperatorRegex{Key: "title", Pattern: "^summer", Options: "i"},
+			compiled: bson.M{"title": bson.M{"$regex": "^summer", "$options": "i"}},
+			matches:  []bson.M{{"title": "Summer Nights"}, {"title": "SUMMER"}},
+			misses:   []bson.M{{"title": "Endless Summer"}},
+		},
+		{
+			name:     "regex without m option anchors at the text",
+			filter:   FilterOperatorRegex{Key: "title", Pattern: "^nights$"},
+			compiled: bson.M{"title": bson.M{"$regex": "^nights$", "$options": ""}},
+			misses:   []bson.M{{"title": "summer\nnights"}},
+		},
+		{
+			name:     "regex with m option anchors at lines",
+			filter:   FilterOperatorRegex{Key: "title", Pattern: "^nights$", Options: "m"},
+			compiled: bson.M{"title": bson.M{"$regex": "^nights$", "$options": "m"}},
+			matches:  []bson.M{{"title": "summer\nnights"}, {"title": "nights\nsummer"}},
+			misses:   []bson.M{{"title": "summer nights"}},
+		},
+		{
+			name:     "not negates arbitrary filters and matches missing fields",
+			filter:   FilterOperatorNot{Not: FilterOperatorGt{Key: "tempo", Value: int32(120)}},
+			compiled: bson.M{"$nor": []bson.M{{"tempo": bson.M{"$gt": int32(120)}}}},
+			matches:  []bson.M{{"tempo": int32(100)}, {}, {"tempo": "fast"}},
+			misses:   []bson.M{{"tempo": int32(128)}},
+		},
+		{
+			name: "nor matches documents matching no condition",
+			filter: FilterOperatorNor{Nor: []IQuery{
+				FilterOperatorEq{Key: "label", Value: "Armada"},
+				FilterOperatorEq{Key: "label", Value: "Spinnin"},
+			}},
+			compiled: bson.M{"$nor": []bson.M{{"label": "Armada"}, {"label": "Spinnin"}}},
+			matches:  []bson.M{{"label": "Revealed"}, {}},
+			misses:   []bson.M{{"label": "Armada"}, {"label": bson.A{"Revealed", "Spinnin"}}},
+		},
+		{
+			name: "elem match requires a single element to match all conditions",
+			filter: FilterOperatorElemMatch{Key: "credits", Match: FilterOperatorAnd{And: []IQuery{
+				FilterOperatorEq{Key: "role", Value: "producer"},
+				FilterOperatorGte{Key: "share", Value: int32(50)},
+			}}},
+			compiled: bson.M{"credits": bson.M{"$elemMatch": bson.M{"$and": []bson.M{
+				{"role": "producer"},
+				{"share": bson.M{"$gte": int32(50)}},
+			}}}},
+			matches: []bson.M{
+				{"credits": bson.A{bson.M{"role": "writer", "share": int32(10)}, bson.M{"role": "producer", "share": int32(50)}}},
+			},
+			misses: []bson.M{
+				{"credits": bson.A{bson.M{"role": "producer", "share": int32(10)}, bson.M{"role": "writer", "share": int32(90)}}},
+				{"credits": bson.M{"role": "producer", "share": int32(50)}},
+				{"credits": bson.A{}},
+				{},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			compiled := test.filter.Compile()
+			if !reflect.DeepEqual(compiled, test.compiled) {
+				t.Errorf("expected compiled query %v, received %v", test.compiled, compiled)
+			}
+
+			for _, document := range test.matches {
+				if !test.filter.Evaluate(document) {
+					t.Errorf("expected %v to match", document)
+				}
+			}
+
+			for _, document := range test.misses {
+				if test.filter.Evaluate(document) {
+					t.Errorf("expected %v not to match", document)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,352 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Description:
+//
+//	Formats the filter in the textual query language.
+//	An empty conjunction is formatted as an empty string.
+//
+// Returns:
+//
+//	The textual query, which parses back into an equivalent filter.
+func (filter FilterOperatorAnd) String() string {
+	parts := make([]string, len(filter.And))
+
+	for index, operand := range filter.And {
+		parts[index] = formatOperand(operand, true)
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Description:
+//
+//	Formats the filter in the textual query language.
+//	An empty disjunction is formatted as an empty string.
+//
+// Returns:
+//
+//	The textual query, which parses back into an equivalent filter.
+func (filter FilterOperatorOr) String() string {
+	parts := make([]string, len(filter.Or))
+
+	for index, operand := range filter.Or {
+		parts[index] = formatOperand(operand, false)
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// Description:
+//
+//	Formats the filter in the textual query language.
+//
+// Returns:
+//
+//	The textual query, which parses back into an equivalent filter.
+func (filter FilterOperatorEq) String() string {
+	return filter.Key + "==" + formatValue(filter.Value)
+}
+
+// Description:
+//
+//	Formats the filter in the textual query language.
+//
+// Returns:
+//
+//	The textual query, which parses back into an equivalent filter.
+func (filter FilterOperatorNeq) String() string {
+	return filter.Key + "!=" + formatValue(filter.Value)
+}
+
+// Description:
+//
+//	Formats the filter in the textual query language.
+//
+// Returns:
+//
+//	The textual query, which parses back into an equivalent filter.
+func (filter FilterOperatorLt) String() string {
+	return filter.Key + "=lt=" + formatValue(filter.Value)
+}
+
+// Description:
+//
+//	Formats the filter in the textual query language.
+//
+// Returns:
+//
+//	The textual query, which parses back into an equivalent filter.
+func (filter FilterOperatorLte) String() string {
+	return filter.Key + "=le=" + formatValue(filter.Value)
+}
+
+// Description:
+//
+//	Formats the filter in the textual query language.
+//
+// Returns:
+//
+//	The textual query, which parses back into an equivalent filter.
+func (filter FilterOperatorGt) String() string {
+	return filter.Key + "=gt=" + formatValue(filter.Value)
+}
+
+// Description:
+//
+//	Formats the filter in the textual query language.
+//
+// Returns:
+//
+//	The textual query, which parses back into an equivalent filter.
+func (filter FilterOperatorGte) String() string {
+	return filter.Key + "=ge=" + formatValue(filter.Value)
+}
+
+// Description:
+//
+//	Formats the filter in the textual query language, as negated disjunction.
+//
+// Returns:
+//
+//	The textual query, which parses back into an equivalent filter.
+func (filter FilterOperatorNor) String() string {
+	return "!(" + FilterOperatorOr{Or: filter.Nor}.String() + ")"
+}
+
+// Description:
+//
+//	Formats the filter in the textual query language.
+//
+// Returns:
+//
+//	The textual query, which parses back into an equivalent filter.
+func (filter FilterOperatorNot) String() string {
+	return "!(" + formatQuery(filter.Not) + ")"
+}
+
+// Description:
+//
+//	Formats the filter in the textual query language.
+//
+// Returns:
+//
+//	The textual query, which parses back into an equivalent filter.
+func (filter FilterOperatorIn) String() string {
+	return filter.Key + "=in=" + formatValues(filter.Values)
+}
+
+// Description:
+//
+//	Formats the filter in the textual query language.
+//
+// Returns:
+//
+//	The textual query, which parses back into an equivalent filter.
+func (filter FilterOperatorNin) String() string {
+	return filter.Key + "=out=" + formatValues(filter.Values)
+}
+
+// Description:
+//
+//	Formats the filter in the textual query language.
+//
+// Returns:
+//
+//	The textual query, which parses back into an equivalent filter.
+func (filter FilterOperatorAll) String() string {
+	return filter.Key + "=all=" + formatValues(filter.Values)
+}
+
+// Description:
+//
+//	Formats the filter in the textual query language.
+//
+// Returns:
+//
+//	The textual query, which parses back into an equivalent filter.
+func (filter FilterOperatorExists) String() string {
+	return filter.Key + "=exists=" + strconv.FormatBool(filter.Exists)
+}
+
+// Description:
+//
+//	Formats the filter in the textual query language.
+//
+// Returns:
+//
+//	The textual query, which parses back into an equivalent filter.
+func (filter FilterOperatorRegex) String() string {
+	if len(filter.Options) == 0 {
+		return filter.Key + "=regex=" + quoteString(filter.Pattern)
+	}
+
+	return fmt.Sprintf("%s=regex=(%s,%s)", filter.Key, quoteString(filter.Pattern), quoteString(filter.Options))
+}
+
+// Description:
+//
+//	Formats the filter in the textual query language.
+//
+// Returns:
+//
+//	The textual query, which parses back into an equivalent filter.
+func (filter FilterOperatorElemMatch) String() string {
+	return filter.Key + "=elemMatch=(" + formatQuery(filter.Match) + ")"
+}
+
+// Description:
+//
+//	Formats a filter in the textual query language.
+//
+// Parameters:
+//
+//	filter The filter to format.
+//
+// Returns:
+//
+//	The textual query.
+func formatQuery(filter IQuery) string {
+	stringer, ok := filter.(fmt.Stringer)
+	if !ok {
+		return fmt.Sprintf("%v", filter)
+	}
+
+	return stringer.String()
+}
+
+// Description:
+//
+//	Formats the operand of a conjunction or a disjunction.
+//	Adds parentheses where the precedence of ';' over ',' requires them.
+//
+// Parameters:
+//
+//	operand 		The operand to format.
+//	conjunction 	Whether the operand belongs to a conjunction.
+//
+// Returns:
+//
+//	The formatted operand.
+func formatOperand(operand IQuery, conjunction bool) string {
+	switch typed := operand.(type) {
+	case FilterOperatorOr:
+		if conjunction && len(typed.Or) > 1 {
+			return "(" + typed.String() + ")"
+		}
+	case FilterOperatorAnd:
+		if len(typed.And) == 1 {
+			return formatOperand(typed.And[0], conjunction)
+		}
+	}
+
+	return formatQuery(operand)
+}
+
+// Description:
+//
+//	Formats a parenthesized list of values.
+//
+// Parameters:
+//
+//	values The values to format.
+//
+// Returns:
+//
+//	The formatted values.
+func formatValues(values []interface{}) string {
+	parts := make([]string, len(values))
+
+	for index, value := range values {
+		parts[index] = formatValue(value)
+	}
+
+	return "(" + strings.Join(parts, ",") + ")"
+}
+
+// Description:
+//
+//	Formats a single value. Strings are quoted if required.
+//	Dates at midnight UTC are formatted as yyyy-MM-dd, all other dates as RFC 3339.
+//
+// Parameters:
+//
+//	value The value to format.
+//
+// Returns:
+//
+//	The formatted value.
+func formatValue(value interface{}) string {
+	switch typed := Canonical(value).(type) {
+	case nil:
+		return "null"
+	case string:
+		if requiresQuotes(typed) {
+			return quoteString(typed)
+		}
+
+		return typed
+	case bool:
+		return strconv.FormatBool(typed)
+	case int32:
+		return strconv.FormatInt(int64(typed), 10)
+	case int64:
+		return strconv.FormatInt(typed, 10)
+	case float64:
+		return strconv.FormatFloat(typed, 'g', -1, 64)
+	case primitive.DateTime:
+		date := typed.Time().UTC()
+
+		if date.Equal(date.Truncate(24 * time.Hour)) {
+			return date.Format("2006-01-02")
+		}
+
+		return date.Format(time.RFC3339Nano)
+	}
+
+	return quoteString(fmt.Sprintf("%v", value))
+}
+
+// Description:
+//
+//	Checks whether a string must be quoted to parse back into the same string.
+//
+// Parameters:
+//
+//	text The string to check.
+//
+// Returns:
+//
+//	True, if the string must be quoted.
+func requiresQuotes(text string) bool {
+	if len(text) == 0 || text == "null" || strings.IndexFunc(text, isReserved) >= 0 {
+		return true
+	}
+
+	_, isString := inferValue(text).(string)
+	return !isString
+}
+
+// Description:
+//
+//	Quotes a string with single quotes, escaping quotes and backslashes.
+//
+// Parameters:
+//
+//	text The string to quote.
+//
+// Returns:
+//
+//	The quoted string.
+func quoteString(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + replacer.Replace(text) + "'"
+}
@@ -0,0 +1,97 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Description:
+//
+//	The data model of the format tests.
+//	The id is exposed as 'id' and stored as '_id', like the ids of the service models.
+type formatTestModel struct {
+
+	// The id.
+	ID string `json:"id" bson:"_id"`
+
+	// A string field.
+	Title string `json:"title" bson:"title"`
+
+	// A string array field.
+	Tags []string `json:"tags" bson:"tags"`
+
+	// A date field.
+	ReleaseDate time.Time `json:"releaseDate" bson:"releaseDate"`
+
+	// An embedded document field.
+	Stats formatTestStats `json:"stats" bson:"trackStats"`
+}
+
+// Description:
+//
+//	The embedded document of the format test model.
+type formatTestStats struct {
+
+	// An integer field.
+	Likes int64 `json:"likes" bson:"likes"`
+
+	// A floating point field.
+	Tempo float64 `json:"tempo" bson:"tempo"`
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		formatted string
+	}{
+		{name: "id is formatted as document key", text: "id==abc", formatted: "_id==abc"},
+		{name: "document key parses like the field name", text: "_id==abc", formatted: "_id==abc"},
+		{name: "nested field", text: "stats.likes=ge=10", formatted: "trackStats.likes=ge=10"},
+		{name: "float", text: "stats.tempo<128.5", formatted: "trackStats.tempo=lt=128.5"},
+		{name: "date", text: "releaseDate=gt=2020-01-01", formatted: "releaseDate=gt=2020-01-01"},
+		{name: "timestamp", text: "releaseDate<2020-01-01T12:30:00Z", formatted: "releaseDate=lt=2020-01-01T12:30:00Z"},
+		{name: "plain string", text: "title==Strobe", formatted: "title==Strobe"},
+		{name: "string with reserved characters", text: `title=="a;b,c"`, formatted: `title=='a;b,c'`},
+		{name: "string with quotes", text: `title=="it's \"quoted\""`, formatted: `title=='it\'s "quoted"'`},
+		{name: "string with backslash", text: `title=='C:\\tracks'`, formatted: `title==C:\tracks`},
+		{name: "string looking like a number", text: "title=='128'", formatted: "title=='128'"},
+		{name: "string null", text: "title=='null'", formatted: "title=='null'"},
+		{name: "null", text: "title==null", formatted: "title==null"},
+		{name: "empty string", text: "title==''", formatted: "title==''"},
+		{name: "in", text: "tags=in=(house,'deep house')", formatted: "tags=in=(house,'deep house')"},
+		{name: "all", text: "tags=all=(a,b)", formatted: "tags=all=(a,b)"},
+		{name: "out", text: "id=out=(a,b)", formatted: "_id=out=(a,b)"},
+		{name: "exists", text: "title=exists=false", formatted: "title=exists=false"},
+		{name: "regex with options", text: "title=regex=('^the',i)", formatted: "title=regex=('^the','i')"},
+		{name: "precedence", text: "title==a;(id==b,id==c)", formatted: "title==a;(_id==b,_id==c)"},
+		{name: "disjunction of conjunctions", text: "title==a;id==b,title==c", formatted: "title==a;_id==b,title==c"},
+		{name: "negation", text: "!(title==a,title==b)", formatted: "!(title==a,title==b)"},
+	}
+
+	schema := NewSchema(formatTestModel{})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parsed, err := Parse(test.text, schema)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			formatted := formatQuery(parsed)
+			if formatted != test.formatted {
+				t.Errorf("expected '%s', received '%s'", test.formatted, formatted)
+			}
+
+			reparsed, err := Parse(formatted, schema)
+			if err != nil {
+				t.Fatalf("failed to parse formatted query '%s': %s", formatted, err)
+			}
+
+			if !reflect.DeepEqual(parsed, reparsed) {
+				t.Errorf("expected '%s' to parse back into %#v, received %#v", formatted, parsed, reparsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Description:
+//
+//	The kind of a token of the textual query language.
+type TokenKind int
+
+const (
+
+	// The end of the input.
+	TokenEOF TokenKind = iota
+
+	// An unquoted selector or value, e.g. 'audioFeatures.tempo' or '120'.
+	TokenWord
+
+	// A quoted value, e.g. 'Spinnin Records' in single or double quotes.
+	TokenString
+
+	// A comparison operator, e.g. '==', '!=', '=ge=' or '<='.
+	TokenComparator
+
+	// The 'and' operator ';'.
+	TokenAnd
+
+	// The 'or' operator ','.
+	TokenOr
+
+	// The opening parenthesis '('.
+	TokenOpen
+
+	// The closing parenthesis ')'.
+	TokenClose
+
+	// The negation operator '!'.
+	TokenNot
+)
+
+// Description:
+//
+//	A token of the textual query language.
+type Token struct {
+
+	// The kind of the token.
+	Kind TokenKind
+
+	// The token text. The unescaped content for quoted values.
+	Text string
+
+	// The byte offset of the token in the input.
+	Offset int
+}
+
+// Description:
+//
+//	Describes an error in a textual query.
+type SyntaxError struct {
+
+	// The byte offset in the input the error refers to.
+	Offset int
+
+	// The error message.
+	Message string
+}
+
+// Description:
+//
+//	Splits a textual query into tokens.
+type Lexer struct {
+
+	// The input to tokenize.
+	input string
+
+	// The byte offset of the next token.
+	offset int
+}
+
+// Description:
+//
+//	Formats the syntax error.
+//
+// Returns:
+//
+//	The error message, including the offset.
+func (err *SyntaxError) Error() string {
+	return fmt.Sprintf("query: syntax error at offset %d: %s", err.Offset, err.Message)
+}
+
+// Description:
+//
+//	Creates a new lexer.
+//
+// Parameters:
+//
+//	input The textual query to tokenize.
+//
+// Returns:
+//
+//	The created lexer.
+func NewLexer(input string) *Lexer {
+	return &Lexer{
+		input: input,
+	}
+}
+
+// Description:
+//
+//	Reads the next token. Whitespace between tokens is skipped.
+//
+// Returns:
+//
+//	The next token, a token of kind TokenEOF at the end of the input.
+//	A syntax error if the input contains a malformed token.
+func (lexer *Lexer) Next() (Token, error) {
+	lexer.skipWhitespace()
+
+	start := lexer.offset
+	if start >= len(lexer.input) {
+		return Token{Kind: TokenEOF, Offset: start}, nil
+	}
+
+	switch lexer.input[start] {
+	case ';':
+		lexer.offset++
+		return Token{Kind: TokenAnd, Text: ";", Offset: start}, nil
+	case ',':
+		lexer.offset++
+		return Token{Kind: TokenOr, Text: ",", Offset: start}, nil
+	case '(':
+		lexer.offset++
+		return Token{Kind: TokenOpen, Text: "(", Offset: start}, nil
+	case ')':
+		lexer.offset++
+		return Token{Kind: TokenClose, Text: ")", Offset: start}, nil
+	case '!':
+		if strings.HasPrefix(lexer.input[start:], "!=") {
+			lexer.offset += 2
+			return Token{Kind: TokenComparator, Text: "!=", Offset: start}, nil
+		}
+
+		lexer.offset++
+		return Token{Kind: TokenNot, Text: "!", Offset: start}, nil
+	case '<', '>':
+		lexer.offset++
+
+		if strings.HasPrefix(lexer.input[lexer.offset:], "=") {
+			lexer.offset++
+		}
+
+		return Token{Kind: TokenComparator, Text: lexer.input[start:lexer.offset], Offset: start}, nil
+	case '=':
+		return lexer.readComparator()
+	case '\'', '"':
+		return lexer.readString()
+	}
+
+	return lexer.readWord()
+}
+
+// Description:
+//
+//	Reads a comparison operator starting with '=', i.e. '==' or '=name='.
+//
+// Returns:
+//
+//	The comparator token, or a syntax error if the operator is malformed.
+func (lexer *Lexer) readComparator() (Token, error) {
+	start := lexer.offset
+	lexer.offset++
+
+	for lexer.offset < len(lexer.input) && isLetter(lexer.input[lexer.offset]) {
+		lexer.offset++
+	}
+
+	if lexer.offset >= len(lexer.input) || lexer.input[lexer.offset] != '=' {
+		return Token{}, &SyntaxError{
+			Offset:  start,
+			Message: "malformed comparison operator, expected: '==' or '=name='",
+		}
+	}
+
+	lexer.offset++
+	return Token{Kind: TokenComparator, Text: lexer.input[start:lexer.offset], Offset: start}, nil
+}
+
+// Description:
+//
+//	Reads a quoted value. A backslash escapes the following character.
+//
+// Returns:
+//
+//	The string token with the unescaped content, or a syntax error if the quote is not terminated.
+func (lexer *Lexer) readString() (Token, error) {
+	start := lexer.offset
+	quote := lexer.input[start]
+	lexer.offset++
+
+	var builder strings.Builder
+
+	for lexer.offset < len(lexer.input) {
+		character := lexer.input[lexer.offset]
+
+		switch character {
+		case quote:
+			lexer.offset++
+			return Token{Kind: TokenString, Text: builder.String(), Offset: start}, nil
+		case '\\':
+			if lexer.offset+1 >= len(lexer.input) {
+				return Token{}, &SyntaxError{
+					Offset:  lexer.offset,
+					Message: "unterminated escape sequence",
+				}
+			}
+
+			lexer.offset++
+		}
+
+		builder.WriteByte(lexer.input[lexer.offset])
+		lexer.offset++
+	}
+
+	return Token{}, &SyntaxError{
+		Offset:  start,
+		Message: "unterminated quoted value",
+	}
+}
+
+// Description:
+//
+//	Reads an unquoted selector or value.
+//
+// Returns:
+//
+//	The word token, or a syntax error if the input contains an unexpected character.
+func (lexer *Lexer) readWord() (Token, error) {
+	start := lexer.offset
+
+	for lexer.offset < len(lexer.input) {
+		character, size := utf8.DecodeRuneInString(lexer.input[lexer.offset:])
+		if isReserved(character) {
+			break
+		}
+
+		lexer.offset += size
+	}
+
+	if lexer.offset == start {
+		character, _ := utf8.DecodeRuneInString(lexer.input[start:])
+		return Token{}, &SyntaxError{
+			Offset:  start,
+			Message: fmt.Sprintf("unexpected character '%c'", character),
+		}
+	}
+
+	return Token{Kind: TokenWord, Text: lexer.input[start:lexer.offset], Offset: start}, nil
+}
+
+// Description:
+//
+//	Skips whitespace.
+func (lexer *Lexer) skipWhitespace() {
+	for lexer.offset < len(lexer.input) {
+		character, size := utf8.DecodeRuneInString(lexer.input[lexer.offset:])
+		if !unicode.IsSpace(character) {
+			return
+		}
+
+		lexer.offset += size
+	}
+}
+
+// Description:
+//
+//	Checks whether a character cannot be part of an unquoted selector or value.
+//
+// Parameters:
+//
+//	character The character to check.
+//
+// Returns:
+//
+//	True, if the character is reserved.
+func isReserved(character rune) bool {
+	return unicode.IsSpace(character) || strings.ContainsRune("\"'();,=!<>~", character)
+}
+
+// Description:
+//
+//	Checks whether a byte is an ASCII letter.
+//
+// Parameters:
+//
+//	character The byte to check.
+//
+// Returns:
+//
+//	True, if the byte is an ASCII letter.
+func isLetter(character byte) bool {
+	return (character >= 'a' && character <= 'z') || (character >= 'A' && character <= 'Z')
+}
@@ -0,0 +1,618 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Description:
+//
+//	Parses textual queries into filter trees.
+//
+//	The grammar follows RSQL/FIQL:
+//
+//	or 			= and { ',' and }
+//	and 		= term { ';' term }
+//	term 		= '(' or ')' | '!' '(' or ')' | comparison
+//	comparison 	= selector comparator ( value | '(' value { ',' value } ')' )
+//
+//	Supported comparators are '==', '!=', '=lt=', '=le=', '=gt=', '=ge=', '<', '<=', '>', '>=',
+//	'=in=', '=out=', '=all=', '=exists=', '=regex=' and '=elemMatch='.
+//	Values may be quoted with single or double quotes. The unquoted value 'null' matches null or missing fields.
+//	For example: 'audioFeatures.tempo=ge=120;(label==Spinnin,label==Armada)'.
+type Parser struct {
+
+	// The lexer providing the tokens.
+	lexer *Lexer
+
+	// The current token.
+	token Token
+}
+
+// Description:
+//
+//	Parses a textual query into a filter tree.
+//
+// Parameters:
+//
+//	text 	The textual query.
+//	schema 	The schema used to validate selectors and to parse values. A nil schema accepts all selectors.
+//
+// Returns:
+//
+//	The filter tree.
+//	A syntax error describing the position of the first error.
+func Parse(text string, schema *Schema) (IQuery, error) {
+	parser := &Parser{
+		lexer: NewLexer(text),
+	}
+
+	err := parser.advance()
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := parser.parseOr(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.token.Kind != TokenEOF {
+		return nil, parser.unexpected("';', ',' or end of input")
+	}
+
+	return filter, nil
+}
+
+// Description:
+//
+//	Parses a disjunction of conjunctions.
+//
+// Parameters:
+//
+//	schema The schema of the current document level.
+//
+// Returns:
+//
+//	The parsed filter, or a syntax error.
+func (parser *Parser) parseOr(schema *Schema) (IQuery, error) {
+	operands := make([]IQuery, 0)
+
+	for {
+		operand, err := parser.parseAnd(schema)
+		if err != nil {
+			return nil, err
+		}
+
+		operands = append(operands, operand)
+
+		if parser.token.Kind != TokenOr {
+			break
+		}
+
+		err = parser.advance()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+
+	return FilterOperatorOr{Or: operands}, nil
+}
+
+// Description:
+//
+//	Parses a conjunction of terms.
+//
+// Parameters:
+//
+//	schema The schema of the current document level.
+//
+// Returns:
+//
+//	The parsed filter, or a syntax error.
+func (parser *Parser) parseAnd(schema *Schema) (IQuery, error) {
+	operands := make([]IQuery, 0)
+
+	for {
+		operand, err := parser.parseTerm(schema)
+		if err != nil {
+			return nil, err
+		}
+
+		operands = append(operands, operand)
+
+		if parser.token.Kind != TokenAnd {
+			break
+		}
+
+		err = parser.advance()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+
+	return FilterOperatorAnd{And: operands}, nil
+}
+
+// Description:
+//
+//	Parses a group, a negated group or a comparison.
+//
+// Parameters:
+//
+//	schema The schema of the current document level.
+//
+// Returns:
+//
+//	The parsed filter, or a syntax error.
+func (parser *Parser) parseTerm(schema *Schema) (IQuery, error) {
+	switch parser.token.Kind {
+	case TokenOpen:
+		return parser.parseGroup(schema)
+
+	case TokenNot:
+		err := parser.advance()
+		if err != nil {
+			return nil, err
+		}
+
+		if parser.token.Kind != TokenOpen {
+			return nil, parser.unexpected("'('")
+		}
+
+		group, err := parser.parseGroup(schema)
+		if err != nil {
+			return nil, err
+		}
+
+		return FilterOperatorNot{Not: group}, nil
+
+	case TokenWord:
+		return parser.parseComparison(schema)
+	}
+
+	return nil, parser.unexpected("selector, '(' or '!'")
+}
+
+// Description:
+//
+//	Parses a parenthesized expression. The current token must be the opening parenthesis.
+//
+// Parameters:
+//
+//	schema The schema of the current document level.
+//
+// Returns:
+//
+//	The parsed filter, or a syntax error.
+func (parser *Parser) parseGroup(schema *Schema) (IQuery, error) {
+	err := parser.advance()
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := parser.parseOr(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.token.Kind != TokenClose {
+		return nil, parser.unexpected("')'")
+	}
+
+	err = parser.advance()
+	if err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+// Description:
+//
+//	Parses a comparison. The current token must be the selector.
+//
+// Parameters:
+//
+//	schema The schema of the current document level.
+//
+// Returns:
+//
+//	The parsed filter, or a syntax error.
+func (parser *Parser) parseComparison(schema *Schema) (IQuery, error) {
+	selector := parser.token
+
+	field, ok := schema.Lookup(selector.Text)
+	if !ok {
+		return nil, &SyntaxError{
+			Offset:  selector.Offset,
+			Message: fmt.Sprintf("unknown field '%s'", selector.Text),
+		}
+	}
+
+	err := parser.advance()
+	if err != nil {
+		return nil, err
+	}
+
+	comparator := parser.token
+	if comparator.Kind != TokenComparator {
+		return nil, parser.unexpected("comparison operator")
+	}
+
+	err = parser.advance()
+	if err != nil {
+		return nil, err
+	}
+
+	switch comparator.Text {
+	case "=elemMatch=":
+		if !field.Array {
+			return nil, &SyntaxError{
+				Offset:  comparator.Offset,
+				Message: fmt.Sprintf("operator '%s' requires an array field", comparator.Text),
+			}
+		}
+
+		if parser.token.Kind != TokenOpen {
+			return nil, parser.unexpected("'('")
+		}
+
+		match, err := parser.parseGroup(schema.Sub(selector.Text))
+		if err != nil {
+			return nil, err
+		}
+
+		return FilterOperatorElemMatch{Key: field.Key, Match: match}, nil
+
+	case "=exists=":
+		value, err := parser.parseValue(SchemaField{Type: SchemaTypeBool})
+		if err != nil {
+			return nil, err
+		}
+
+		exists, ok := value.(bool)
+		if !ok {
+			return nil, &SyntaxError{
+				Offset:  comparator.Offset,
+				Message: "operator '=exists=' requires the value true or false",
+			}
+		}
+
+		return FilterOperatorExists{Key: field.Key, Exists: exists}, nil
+
+	case "=regex=":
+		return parser.parseRegex(field, comparator)
+
+	case "=in=", "=out=", "=all=":
+		values, err := parser.parseValues(field)
+		if err != nil {
+			return nil, err
+		}
+
+		switch comparator.Text {
+		case "=in=":
+			return FilterOperatorIn{Key: field.Key, Values: values}, nil
+		case "=out=":
+			return FilterOperatorNin{Key: field.Key, Values: values}, nil
+		}
+
+		return FilterOperatorAll{Key: field.Key, Values: values}, nil
+	}
+
+	value, err := parser.parseValue(field)
+	if err != nil {
+		return nil, err
+	}
+
+	switch comparator.Text {
+	case "==":
+		return FilterOperatorEq{Key: field.Key, Value: value}, nil
+	case "!=":
+		return FilterOperatorNeq{Key: field.Key, Value: value}, nil
+	case "=lt=", "<":
+		return FilterOperatorLt{Key: field.Key, Value: value}, nil
+	case "=le=", "<=":
+		return FilterOperatorLte{Key: field.Key, Value: value}, nil
+	case "=gt=", ">":
+		return FilterOperatorGt{Key: field.Key, Value: value}, nil
+	case "=ge=", ">=":
+		return FilterOperatorGte{Key: field.Key, Value: value}, nil
+	}
+
+	return nil, &SyntaxError{
+		Offset:  comparator.Offset,
+		Message: fmt.Sprintf("unknown comparison operator '%s'", comparator.Text),
+	}
+}
+
+// Description:
+//
+//	Parses the arguments of the '=regex=' operator: a pattern, optionally followed by options,
+//	e.g. "title=regex=('^the', i)".
+//
+// Parameters:
+//
+//	field 		The field to match.
+//	comparator 	The comparator token.
+//
+// Returns:
+//
+//	The parsed filter, or a syntax error.
+func (parser *Parser) parseRegex(field SchemaField, comparator Token) (IQuery, error) {
+	arguments, err := parser.parseValues(SchemaField{Type: SchemaTypeString})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(arguments) > 2 {
+		return nil, &SyntaxError{
+			Offset:  comparator.Offset,
+			Message: "operator '=regex=' expects a pattern and optional options",
+		}
+	}
+
+	filter := FilterOperatorRegex{
+		Key:     field.Key,
+		Pattern: arguments[0].(string),
+	}
+
+	if len(arguments) == 2 {
+		filter.Options = arguments[1].(string)
+	}
+
+	_, err = CompileRegex(filter.Pattern, filter.Options)
+	if err != nil {
+		return nil, &SyntaxError{
+			Offset:  comparator.Offset,
+			Message: fmt.Sprintf("invalid regular expression: %s", err),
+		}
+	}
+
+	return filter, nil
+}
+
+// Description:
+//
+//	Parses a single value or a parenthesized list of values.
+//
+// Parameters:
+//
+//	field The field the values are compared with.
+//
+// Returns:
+//
+//	The parsed values, or a syntax error.
+func (parser *Parser) parseValues(field SchemaField) ([]interface{}, error) {
+	if parser.token.Kind != TokenOpen {
+		value, err := parser.parseValue(field)
+		if err != nil {
+			return nil, err
+		}
+
+		return []interface{}{value}, nil
+	}
+
+	values := make([]interface{}, 0)
+
+	for {
+		err := parser.advance()
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := parser.parseValue(field)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+
+		if parser.token.Kind == TokenClose {
+			break
+		}
+
+		if parser.token.Kind != TokenOr {
+			return nil, parser.unexpected("',' or ')'")
+		}
+	}
+
+	err := parser.advance()
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// Description:
+//
+//	Parses a single value into the type of the field.
+//
+// Parameters:
+//
+//	field The field the value is compared with.
+//
+// Returns:
+//
+//	The parsed value, or a syntax error.
+func (parser *Parser) parseValue(field SchemaField) (interface{}, error) {
+	token := parser.token
+
+	if token.Kind != TokenWord && token.Kind != TokenString {
+		return nil, parser.unexpected("value")
+	}
+
+	value, err := convertValue(token, field.Type)
+	if err != nil {
+		return nil, &SyntaxError{
+			Offset:  token.Offset,
+			Message: fmt.Sprintf("invalid value for field '%s': %s", field.Name, err),
+		}
+	}
+
+	err = parser.advance()
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// Description:
+//
+//	Reads the next token.
+//
+// Returns:
+//
+//	A syntax error if the next token is malformed.
+func (parser *Parser) advance() error {
+	token, err := parser.lexer.Next()
+	if err != nil {
+		return err
+	}
+
+	parser.token = token
+	return nil
+}
+
+// Description:
+//
+//	Creates a syntax error for an unexpected token.
+//
+// Parameters:
+//
+//	expected Describes the expected tokens.
+//
+// Returns:
+//
+//	The syntax error.
+func (parser *Parser) unexpected(expected string) error {
+	found := fmt.Sprintf("'%s'", parser.token.Text)
+
+	switch parser.token.Kind {
+	case TokenEOF:
+		found = "end of input"
+	case TokenString:
+		found = "quoted value"
+	}
+
+	return &SyntaxError{
+		Offset:  parser.token.Offset,
+		Message: fmt.Sprintf("expected %s, found %s", expected, found),
+	}
+}
+
+// Description:
+//
+//	Converts a value token into the given type.
+//	Unquoted values of fields with unknown type are inferred as null, boolean, number or string.
+//
+// Parameters:
+//
+//	token 		The value token.
+//	valueType 	The type to convert to.
+//
+// Returns:
+//
+//	The converted value, or an error describing the expected format.
+func convertValue(token Token, valueType SchemaType) (interface{}, error) {
+	text := token.Text
+
+	if token.Kind == TokenWord && text == "null" {
+		return nil, nil
+	}
+
+	switch valueType {
+	case SchemaTypeString:
+		return text, nil
+
+	case SchemaTypeInt:
+		number, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("value is not a valid integer")
+		}
+
+		return number, nil
+
+	case SchemaTypeFloat:
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, fmt.Errorf("value is not a valid number")
+		}
+
+		return number, nil
+
+	case SchemaTypeBool:
+		boolean, err := strconv.ParseBool(text)
+		if err != nil || (text != "true" && text != "false") {
+			return nil, fmt.Errorf("value must be true or false")
+		}
+
+		return boolean, nil
+
+	case SchemaTypeDate:
+		date, err := time.Parse("2006-01-02", text)
+		if err == nil {
+			return date, nil
+		}
+
+		date, err = time.Parse(time.RFC3339, text)
+		if err != nil {
+			return nil, fmt.Errorf("expected following format: yyyy-MM-dd or RFC 3339")
+		}
+
+		return date, nil
+
+	case SchemaTypeDocument:
+		return nil, fmt.Errorf("embedded documents can only be compared with null")
+	}
+
+	if token.Kind == TokenString {
+		return text, nil
+	}
+
+	return inferValue(text), nil
+}
+
+// Description:
+//
+//	Infers the type of an unquoted value of unknown type.
+//
+// Parameters:
+//
+//	text The unquoted value.
+//
+// Returns:
+//
+//	A boolean, an integer, a floating point number or the text itself.
+func inferValue(text string) interface{} {
+	if text == "true" || text == "false" {
+		return text == "true"
+	}
+
+	integer, err := strconv.ParseInt(text, 10, 64)
+	if err == nil {
+		return integer
+	}
+
+	if strings.ContainsAny(text, "0123456789") {
+		number, err := strconv.ParseFloat(text, 64)
+		if err == nil && !math.IsNaN(number) && !math.IsInf(number, 0) {
+			return number
+		}
+	}
+
+	return text
+}
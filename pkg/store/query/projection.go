@@ -0,0 +1,164 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Description:
+//
+//	Parses a textual projection, e.g. 'id,title,audioFeatures.tempo'.
+//
+// Parameters:
+//
+//	text 	The comma separated field paths.
+//	schema 	The schema used to validate the field paths. A nil schema accepts all field paths.
+//
+// Returns:
+//
+//	The projected fields.
+//	An error if a field path is empty, unknown, duplicated or collides with another field path.
+func ParseProjection(text string, schema *Schema) ([]SchemaField, error) {
+	fields := make([]SchemaField, 0)
+
+	for _, part := range strings.Split(text, ",") {
+		name := strings.TrimSpace(part)
+		if len(name) == 0 {
+			return nil, fmt.Errorf("query: projection field must not be empty")
+		}
+
+		field, ok := schema.Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("query: unknown projection field '%s'", name)
+		}
+
+		for _, existing := range fields {
+			if pathsCollide(existing.Key, field.Key) {
+				return nil, fmt.Errorf("query: projection field '%s' collides with '%s'", name, existing.Name)
+			}
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// Description:
+//
+//	Compiles a projection into a MongoDB BSON document.
+//	The primary key is excluded unless it is projected explicitly.
+//
+// Parameters:
+//
+//	keys The projected document keys.
+//
+// Returns:
+//
+//	A MongoDB bson document representing the projection.
+func CompileProjection(keys []string) bson.M {
+	result := bson.M{"_id": 0}
+
+	for _, key := range keys {
+		result[key] = 1
+	}
+
+	return result
+}
+
+// Description:
+//
+//	Adds keys to a projection, unless they are already covered by a projected key.
+//	An empty projection includes all fields and is returned unchanged.
+//
+// Parameters:
+//
+//	keys 	The projected document keys.
+//	extra 	The document keys to add.
+//
+// Returns:
+//
+//	The extended projection.
+func ExtendProjection(keys []string, extra ...string) []string {
+	if len(keys) == 0 {
+		return keys
+	}
+
+	result := append([]string{}, keys...)
+
+	for _, key := range extra {
+		covered := false
+
+		for index, existing := range result {
+			if existing == key || strings.HasPrefix(key, existing+".") {
+				covered = true
+				break
+			}
+
+			if strings.HasPrefix(existing, key+".") {
+				result[index] = key
+				covered = true
+				break
+			}
+		}
+
+		if !covered {
+			result = append(result, key)
+		}
+	}
+
+	return result
+}
+
+// Description:
+//
+//	Applies a projection to an in-memory document.
+//	An empty projection includes all fields.
+//
+// Parameters:
+//
+//	document 	The document to project.
+//	keys 		The projected document keys.
+//
+// Returns:
+//
+//	A new document containing only the projected fields.
+func ProjectDocument(document bson.M, keys []string) bson.M {
+	if len(keys) == 0 {
+		return document
+	}
+
+	result := bson.M{}
+
+	for _, key := range keys {
+		value, ok := GetPath(document, key)
+		if !ok {
+			continue
+		}
+
+		err := SetPath(result, key, copyValue(value))
+		if err != nil {
+			continue
+		}
+	}
+
+	return result
+}
+
+// Description:
+//
+//	Checks whether two dotted paths are equal or one contains the other.
+//
+// Parameters:
+//
+//	left 	The left path.
+//	right 	The right path.
+//
+// Returns:
+//
+//	True, if the paths collide.
+func pathsCollide(left string, right string) bool {
+	return left == right || strings.HasPrefix(left, right+".") || strings.HasPrefix(right, left+".")
+}
@@ -15,4 +15,18 @@ type IQuery interface {
 	//
 	//	The filter represented as a MongoDB bson document.
 	Compile() bson.M
+
+	// Description:
+	//
+	//	Evaluates the filter against an in-memory document.
+	//	Mirrors the semantics of the compiled MongoDB query.
+	//
+	// Parameters:
+	//
+	//	document The document to evaluate.
+	//
+	// Returns:
+	//
+	//	True, if the document matches the filter, false otherwise.
+	Evaluate(document bson.M) bool
 }
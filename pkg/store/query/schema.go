@@ -0,0 +1,268 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Description:
+//
+//	The type of a schema field.
+type SchemaType int
+
+const (
+
+	// A field of unknown type. Values are inferred from their textual form.
+	SchemaTypeAny SchemaType = iota
+
+	// A string field.
+	SchemaTypeString
+
+	// A signed or unsigned integer field.
+	SchemaTypeInt
+
+	// A floating point field.
+	SchemaTypeFloat
+
+	// A boolean field.
+	SchemaTypeBool
+
+	// A date field.
+	SchemaTypeDate
+
+	// An embedded document field. Cannot be compared with scalar values.
+	SchemaTypeDocument
+)
+
+// Description:
+//
+//	A single field of a schema.
+type SchemaField struct {
+
+	// The field path as exposed to clients, built from the json struct tags.
+	Name string
+
+	// The document key, built from the bson struct tags.
+	Key string
+
+	// The type of the field. The element type for array fields.
+	Type SchemaType
+
+	// Whether the field is an array.
+	Array bool
+}
+
+// Description:
+//
+//	Describes the fields which can be referenced by textual queries.
+//	Acts as a field whitelist and determines how values are parsed.
+type Schema struct {
+
+	// The fields, keyed by their name and their document key.
+	fields map[string]SchemaField
+}
+
+// Description:
+//
+//	Creates a schema from the struct tags of a data model.
+//	Embedded structs are flattened into dotted paths, e.g. 'audioFeatures.tempo'.
+//	Fields without json or bson tag, or tagged with '-', are not part of the schema.
+//
+// Parameters:
+//
+//	model An instance of the data model.
+//
+// Returns:
+//
+//	The created schema.
+func NewSchema(model interface{}) *Schema {
+	schema := &Schema{
+		fields: make(map[string]SchemaField),
+	}
+
+	schema.addStruct(reflect.TypeOf(model), "", "")
+	return schema
+}
+
+// Description:
+//
+//	Looks up a field by its name or its document key.
+//	Document keys are accepted on purpose: formatted filters reference document keys,
+//	e.g. 'id' is formatted as '_id', and must parse back into the same filter.
+//	A nil schema accepts all fields with an unknown type.
+//
+// Parameters:
+//
+//	name The field name or document key.
+//
+// Returns:
+//
+//	The field and whether it exists.
+func (schema *Schema) Lookup(name string) (SchemaField, bool) {
+	if schema == nil {
+		return SchemaField{Name: name, Key: name, Type: SchemaTypeAny}, true
+	}
+
+	field, ok := schema.fields[name]
+	return field, ok
+}
+
+// Description:
+//
+//	Gets the schema of the elements of an array field.
+//	Field names and document keys of the sub schema are relative to the element.
+//
+// Parameters:
+//
+//	name The field name or document key of the array field.
+//
+// Returns:
+//
+//	The element schema.
+func (schema *Schema) Sub(name string) *Schema {
+	if schema == nil {
+		return nil
+	}
+
+	parent, ok := schema.fields[name]
+	sub := &Schema{
+		fields: make(map[string]SchemaField),
+	}
+
+	if !ok {
+		return sub
+	}
+
+	for _, field := range schema.fields {
+		if !strings.HasPrefix(field.Key, parent.Key+".") {
+			continue
+		}
+
+		relative := field
+		relative.Name = strings.TrimPrefix(field.Name, parent.Name+".")
+		relative.Key = strings.TrimPrefix(field.Key, parent.Key+".")
+
+		sub.fields[relative.Name] = relative
+		sub.fields[relative.Key] = relative
+	}
+
+	return sub
+}
+
+// Description:
+//
+//	Adds the fields of a struct type to the schema.
+//
+// Parameters:
+//
+//	structType 	The struct type.
+//	namePrefix 	The name prefix of the fields, empty on top level.
+//	keyPrefix 	The document key prefix of the fields, empty on top level.
+func (schema *Schema) addStruct(structType reflect.Type, namePrefix string, keyPrefix string) {
+	for structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+
+	if structType.Kind() != reflect.Struct {
+		return
+	}
+
+	for index := 0; index < structType.NumField(); index++ {
+		structField := structType.Field(index)
+		if !structField.IsExported() {
+			continue
+		}
+
+		name := tagName(structField.Tag.Get("json"))
+		key := tagName(structField.Tag.Get("bson"))
+
+		if name == "-" || key == "-" || (name == "" && key == "") {
+			continue
+		}
+
+		if name == "" {
+			name = key
+		}
+
+		if key == "" {
+			key = name
+		}
+
+		field := SchemaField{
+			Name: namePrefix + name,
+			Key:  keyPrefix + key,
+		}
+
+		fieldType := structField.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		if fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array {
+			field.Array = true
+			fieldType = fieldType.Elem()
+
+			for fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+		}
+
+		field.Type = schemaType(fieldType)
+		schema.fields[field.Name] = field
+		schema.fields[field.Key] = field
+
+		if field.Type == SchemaTypeDocument {
+			schema.addStruct(fieldType, field.Name+".", field.Key+".")
+		}
+	}
+}
+
+// Description:
+//
+//	Extracts the name of a json or bson struct tag.
+//
+// Parameters:
+//
+//	tag The struct tag value, e.g. 'title,omitempty'.
+//
+// Returns:
+//
+//	The name, e.g. 'title'.
+func tagName(tag string) string {
+	name, _, _ := strings.Cut(tag, ",")
+	return name
+}
+
+// Description:
+//
+//	Maps a Go type to a schema type.
+//
+// Parameters:
+//
+//	goType The Go type.
+//
+// Returns:
+//
+//	The schema type.
+func schemaType(goType reflect.Type) SchemaType {
+	if goType == reflect.TypeOf(time.Time{}) {
+		return SchemaTypeDate
+	}
+
+	switch goType.Kind() {
+	case reflect.String:
+		return SchemaTypeString
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return SchemaTypeInt
+	case reflect.Float32, reflect.Float64:
+		return SchemaTypeFloat
+	case reflect.Bool:
+		return SchemaTypeBool
+	case reflect.Struct, reflect.Map:
+		return SchemaTypeDocument
+	}
+
+	return SchemaTypeAny
+}
@@ -0,0 +1,187 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Description:
+//
+//	The order of a sort key.
+type SortOrder int
+
+const (
+
+	// Sorts values in ascending order.
+	SortAscending SortOrder = 1
+
+	// Sorts values in descending order.
+	SortDescending SortOrder = -1
+)
+
+// Description:
+//
+//	A single key of a sort specification.
+type SortKey struct {
+
+	// The document key to sort by.
+	Key string
+
+	// The sort order.
+	Order SortOrder
+}
+
+// Description:
+//
+//	Makes a sort specification stable by appending the primary key,
+//	unless the specification already contains it.
+//	A stable sort order is required for cursor-based pagination.
+//
+// Parameters:
+//
+//	keys The sort specification.
+//
+// Returns:
+//
+//	The stable sort specification.
+func StableSort(keys []SortKey) []SortKey {
+	result := make([]SortKey, 0, len(keys)+1)
+
+	for _, key := range keys {
+		result = append(result, key)
+
+		if key.Key == "_id" {
+			return result
+		}
+	}
+
+	return append(result, SortKey{
+		Key:   "_id",
+		Order: SortAscending,
+	})
+}
+
+// Description:
+//
+//	Compiles a sort specification into a MongoDB BSON document.
+//
+// Parameters:
+//
+//	keys The sort specification.
+//
+// Returns:
+//
+//	A MongoDB bson document representing the sort specification.
+func CompileSort(keys []SortKey) bson.D {
+	result := bson.D{}
+
+	for _, key := range keys {
+		result = append(result, bson.E{Key: key.Key, Value: int(key.Order)})
+	}
+
+	return result
+}
+
+// Description:
+//
+//	Formats a sort specification as text, e.g. '-trackStats.streams,title'.
+//	Descending keys are prefixed with a minus sign.
+//
+// Parameters:
+//
+//	keys The sort specification.
+//
+// Returns:
+//
+//	The formatted sort specification.
+func FormatSort(keys []SortKey) string {
+	parts := make([]string, len(keys))
+
+	for index, key := range keys {
+		if key.Order == SortDescending {
+			parts[index] = "-" + key.Key
+		} else {
+			parts[index] = key.Key
+		}
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// Description:
+//
+//	Parses a textual sort specification, e.g. '-trackStats.streams,title'.
+//	Keys prefixed with a minus sign are sorted in descending order,
+//	all other keys (optionally prefixed with a plus sign) in ascending order.
+//
+// Parameters:
+//
+//	text The sort specification to parse.
+//
+// Returns:
+//
+//	The parsed sort specification, or an error if a key is empty or duplicated.
+func ParseSort(text string) ([]SortKey, error) {
+	keys := make([]SortKey, 0)
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSpace(part)
+		key := SortKey{
+			Key:   part,
+			Order: SortAscending,
+		}
+
+		if strings.HasPrefix(part, "-") {
+			key.Key = strings.TrimPrefix(part, "-")
+			key.Order = SortDescending
+		} else if strings.HasPrefix(part, "+") {
+			key.Key = strings.TrimPrefix(part, "+")
+		}
+
+		if len(key.Key) == 0 {
+			return nil, fmt.Errorf("query: sort key must not be empty")
+		}
+
+		if seen[key.Key] {
+			return nil, fmt.Errorf("query: duplicate sort key '%s'", key.Key)
+		}
+
+		seen[key.Key] = true
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// Description:
+//
+//	Sorts in-memory documents by the given sort specification.
+//	The sort is stable, so documents with equal keys keep their order.
+//
+// Parameters:
+//
+//	documents 	The documents to sort.
+//	keys 		The sort specification.
+func SortDocuments(documents []bson.M, keys []SortKey) {
+	if len(keys) == 0 {
+		return
+	}
+
+	sort.SliceStable(documents, func(left int, right int) bool {
+		for _, key := range keys {
+			leftValue, _ := GetPath(documents[left], key.Key)
+			rightValue, _ := GetPath(documents[right], key.Key)
+
+			result := CompareValues(leftValue, rightValue) * int(key.Order)
+			if result != 0 {
+				return result < 0
+			}
+		}
+
+		return false
+	})
+}
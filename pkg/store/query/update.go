@@ -1,14 +1,50 @@
 package query
 
-import "go.mongodb.org/mongo-driver/bson"
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Description:
+//
+//	The update operator interface.
+type IUpdate interface {
+
+	// Description:
+	//
+	//	Compiles the update operator into a bson document for MongoDB.
+	//
+	// Returns:
+	//
+	//	The update operator represented as a MongoDB bson document.
+	Compile() bson.M
+
+	// Description:
+	//
+	//	Applies the update operator to an in-memory document.
+	//	Mirrors the semantics of the compiled MongoDB update.
+	//
+	// Parameters:
+	//
+	//	document The document to modify.
+	//
+	// Returns:
+	//
+	//	An error if the update cannot be applied.
+	Apply(document bson.M) error
+}
 
 // Description:
 //
 //	Used to update documents in a store.
 type Update struct {
 
-	// The root query.
-	Root IQuery
+	// The root update operator.
+	Root IUpdate
 }
 
 // Description:
@@ -16,8 +52,8 @@ type Update struct {
 //	Updates a specific field by updating a specific field.
 type UpdateOperatorSet struct {
 
-	// The query interface implementation.
-	IQuery
+	// The update interface implementation.
+	IUpdate
 
 	// The key-value mappings to set.
 	Set map[string]interface{}
@@ -33,3 +69,720 @@ type UpdateOperatorSet struct {
 func (update UpdateOperatorSet) Compile() bson.M {
 	return bson.M{"$set": update.Set}
 }
+
+// Description:
+//
+//	Applies the update operator to an in-memory document.
+//	Dotted keys refer to fields of embedded documents.
+//
+// Parameters:
+//
+//	document The document to modify.
+//
+// Returns:
+//
+//	An error if a key traverses a non-document field.
+func (update UpdateOperatorSet) Apply(document bson.M) error {
+	for key, value := range update.Set {
+		err := SetPath(document, key, Canonical(value))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Increments numeric fields by the given amounts.
+//	Missing fields are created with the given amount.
+type UpdateOperatorInc struct {
+
+	// The update interface implementation.
+	IUpdate
+
+	// The key-amount mappings to increment by. Negative amounts decrement.
+	Inc map[string]interface{}
+}
+
+// Description:
+//
+//	Removes fields from the document.
+type UpdateOperatorUnset struct {
+
+	// The update interface implementation.
+	IUpdate
+
+	// The keys to remove.
+	Unset []string
+}
+
+// Description:
+//
+//	Appends values to array fields.
+//	Missing fields are created as arrays.
+type UpdateOperatorPush struct {
+
+	// The update interface implementation.
+	IUpdate
+
+	// The key-values mappings to append.
+	Push map[string][]interface{}
+}
+
+// Description:
+//
+//	Appends values to array fields, unless the array already contains them.
+//	Missing fields are created as arrays.
+type UpdateOperatorAddToSet struct {
+
+	// The update interface implementation.
+	IUpdate
+
+	// The key-values mappings to add.
+	AddToSet map[string][]interface{}
+}
+
+// Description:
+//
+//	Removes all occurrences of values from array fields.
+type UpdateOperatorPull struct {
+
+	// The update interface implementation.
+	IUpdate
+
+	// The key-values mappings to remove.
+	Pull map[string][]interface{}
+}
+
+// Description:
+//
+//	Updates fields to the given values, if the given values are less than the current values.
+//	Missing fields are set to the given values.
+type UpdateOperatorMin struct {
+
+	// The update interface implementation.
+	IUpdate
+
+	// The key-value mappings to compare with.
+	Min map[string]interface{}
+}
+
+// Description:
+//
+//	Updates fields to the given values, if the given values are greater than the current values.
+//	Missing fields are set to the given values.
+type UpdateOperatorMax struct {
+
+	// The update interface implementation.
+	IUpdate
+
+	// The key-value mappings to compare with.
+	Max map[string]interface{}
+}
+
+// Description:
+//
+//	Sets fields to the current date.
+type UpdateOperatorCurrentDate struct {
+
+	// The update interface implementation.
+	IUpdate
+
+	// The keys to set to the current date.
+	CurrentDate []string
+}
+
+// Description:
+//
+//	Combines multiple update operators into a single update.
+//	Like in MongoDB, the operators must not update conflicting fields.
+type UpdateOperatorCombine struct {
+
+	// The update interface implementation.
+	IUpdate
+
+	// The update operators to combine.
+	Operators []IUpdate
+}
+
+// Description:
+//
+//	Compiles the update operator and potential sub operators into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this update operator.
+func (update UpdateOperatorInc) Compile() bson.M {
+	return bson.M{"$inc": update.Inc}
+}
+
+// Description:
+//
+//	Compiles the update operator and potential sub operators into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this update operator.
+func (update UpdateOperatorUnset) Compile() bson.M {
+	fields := bson.M{}
+
+	for _, key := range update.Unset {
+		fields[key] = ""
+	}
+
+	return bson.M{"$unset": fields}
+}
+
+// Description:
+//
+//	Compiles the update operator and potential sub operators into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this update operator.
+func (update UpdateOperatorPush) Compile() bson.M {
+	return bson.M{"$push": compileEach(update.Push)}
+}
+
+// Description:
+//
+//	Compiles the update operator and potential sub operators into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this update operator.
+func (update UpdateOperatorAddToSet) Compile() bson.M {
+	return bson.M{"$addToSet": compileEach(update.AddToSet)}
+}
+
+// Description:
+//
+//	Compiles the update operator and potential sub operators into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this update operator.
+func (update UpdateOperatorPull) Compile() bson.M {
+	fields := bson.M{}
+
+	for key, values := range update.Pull {
+		fields[key] = bson.M{"$in": valuesArray(values)}
+	}
+
+	return bson.M{"$pull": fields}
+}
+
+// Description:
+//
+//	Compiles the update operator and potential sub operators into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this update operator.
+func (update UpdateOperatorMin) Compile() bson.M {
+	return bson.M{"$min": update.Min}
+}
+
+// Description:
+//
+//	Compiles the update operator and potential sub operators into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this update operator.
+func (update UpdateOperatorMax) Compile() bson.M {
+	return bson.M{"$max": update.Max}
+}
+
+// Description:
+//
+//	Compiles the update operator and potential sub operators into a MongoDB BSON document.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this update operator.
+func (update UpdateOperatorCurrentDate) Compile() bson.M {
+	fields := bson.M{}
+
+	for _, key := range update.CurrentDate {
+		fields[key] = true
+	}
+
+	return bson.M{"$currentDate": fields}
+}
+
+// Description:
+//
+//	Compiles the update operator and potential sub operators into a MongoDB BSON document.
+//	Fields of operators with the same name are merged.
+//
+// Returns:
+//
+//	A MongoDB bson document representing this update operator.
+func (update UpdateOperatorCombine) Compile() bson.M {
+	result := bson.M{}
+
+	for _, operator := range update.Operators {
+		for name, fields := range operator.Compile() {
+			merged, ok := result[name].(bson.M)
+			if !ok {
+				merged = bson.M{}
+				result[name] = merged
+			}
+
+			for key, value := range toDocument(fields) {
+				merged[key] = value
+			}
+		}
+	}
+
+	return result
+}
+
+// Description:
+//
+//	Applies the update operator to an in-memory document.
+//
+// Parameters:
+//
+//	document The document to modify.
+//
+// Returns:
+//
+//	An error if a field or an amount is not numeric.
+func (update UpdateOperatorInc) Apply(document bson.M) error {
+	for key, amount := range update.Inc {
+		amount = Canonical(amount)
+
+		if typeRank(amount) != typeRank(int32(0)) {
+			return fmt.Errorf("query: cannot increment with non-numeric argument for '%s'", key)
+		}
+
+		current, ok := GetPath(document, key)
+		if !ok {
+			current = int32(0)
+		}
+
+		current = Canonical(current)
+
+		if typeRank(current) != typeRank(int32(0)) {
+			return fmt.Errorf("query: cannot apply $inc to non-numeric field '%s'", key)
+		}
+
+		err := SetPath(document, key, AddNumbers(current, amount))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Applies the update operator to an in-memory document.
+//
+// Parameters:
+//
+//	document The document to modify.
+//
+// Returns:
+//
+//	Always nil. Missing fields are ignored.
+func (update UpdateOperatorUnset) Apply(document bson.M) error {
+	for _, key := range update.Unset {
+		UnsetPath(document, key)
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Applies the update operator to an in-memory document.
+//
+// Parameters:
+//
+//	document The document to modify.
+//
+// Returns:
+//
+//	An error if a field is not an array.
+func (update UpdateOperatorPush) Apply(document bson.M) error {
+	for key, values := range update.Push {
+		array, err := getArray(document, key)
+		if err != nil {
+			return err
+		}
+
+		for _, value := range values {
+			array = append(array, Canonical(value))
+		}
+
+		err = SetPath(document, key, array)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Applies the update operator to an in-memory document.
+//
+// Parameters:
+//
+//	document The document to modify.
+//
+// Returns:
+//
+//	An error if a field is not an array.
+func (update UpdateOperatorAddToSet) Apply(document bson.M) error {
+	for key, values := range update.AddToSet {
+		array, err := getArray(document, key)
+		if err != nil {
+			return err
+		}
+
+		for _, value := range values {
+			if !containsValue(array, value) {
+				array = append(array, Canonical(value))
+			}
+		}
+
+		err = SetPath(document, key, array)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Applies the update operator to an in-memory document.
+//
+// Parameters:
+//
+//	document The document to modify.
+//
+// Returns:
+//
+//	An error if a field is not an array.
+func (update UpdateOperatorPull) Apply(document bson.M) error {
+	for key, values := range update.Pull {
+		current, ok := GetPath(document, key)
+		if !ok {
+			continue
+		}
+
+		array, ok := Canonical(current).(bson.A)
+		if !ok {
+			return fmt.Errorf("query: cannot apply $pull to non-array field '%s'", key)
+		}
+
+		result := make(bson.A, 0, len(array))
+
+		for _, element := range array {
+			if !containsValue(values, element) {
+				result = append(result, element)
+			}
+		}
+
+		err := SetPath(document, key, result)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Applies the update operator to an in-memory document.
+//
+// Parameters:
+//
+//	document The document to modify.
+//
+// Returns:
+//
+//	An error if a key traverses a non-document field.
+func (update UpdateOperatorMin) Apply(document bson.M) error {
+	return applyComparison(document, update.Min, func(result int) bool {
+		return result < 0
+	})
+}
+
+// Description:
+//
+//	Applies the update operator to an in-memory document.
+//
+// Parameters:
+//
+//	document The document to modify.
+//
+// Returns:
+//
+//	An error if a key traverses a non-document field.
+func (update UpdateOperatorMax) Apply(document bson.M) error {
+	return applyComparison(document, update.Max, func(result int) bool {
+		return result > 0
+	})
+}
+
+// Description:
+//
+//	Applies the update operator to an in-memory document.
+//	Uses millisecond precision, like MongoDB dates.
+//
+// Parameters:
+//
+//	document The document to modify.
+//
+// Returns:
+//
+//	An error if a key traverses a non-document field.
+func (update UpdateOperatorCurrentDate) Apply(document bson.M) error {
+	now := primitive.NewDateTimeFromTime(time.Now())
+
+	for _, key := range update.CurrentDate {
+		err := SetPath(document, key, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Applies all combined update operators to an in-memory document.
+//	The document is only modified if all operators can be applied.
+//
+// Parameters:
+//
+//	document The document to modify.
+//
+// Returns:
+//
+//	An error if operators update conflicting fields or if an operator cannot be applied.
+func (update UpdateOperatorCombine) Apply(document bson.M) error {
+	paths := make([]string, 0)
+
+	for _, operator := range update.Operators {
+		for _, fields := range operator.Compile() {
+			for key := range toDocument(fields) {
+				for _, path := range paths {
+					if pathsCollide(path, key) {
+						return fmt.Errorf("query: updating the path '%s' would create a conflict at '%s'", key, path)
+					}
+				}
+
+				paths = append(paths, key)
+			}
+		}
+	}
+
+	updated := CopyDocument(document)
+
+	for _, operator := range update.Operators {
+		err := operator.Apply(updated)
+		if err != nil {
+			return err
+		}
+	}
+
+	for key := range document {
+		delete(document, key)
+	}
+
+	for key, value := range updated {
+		document[key] = value
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Compiles key-values mappings into '$each' modifiers.
+//
+// Parameters:
+//
+//	fields The key-values mappings.
+//
+// Returns:
+//
+//	The compiled fields.
+func compileEach(fields map[string][]interface{}) bson.M {
+	result := bson.M{}
+
+	for key, values := range fields {
+		result[key] = bson.M{"$each": valuesArray(values)}
+	}
+
+	return result
+}
+
+// Description:
+//
+//	Converts the fields of a compiled update operator into a document.
+//
+// Parameters:
+//
+//	fields The compiled fields, a bson document or a map.
+//
+// Returns:
+//
+//	The fields as a bson document.
+func toDocument(fields interface{}) bson.M {
+	switch typed := fields.(type) {
+	case bson.M:
+		return typed
+	case map[string]interface{}:
+		return bson.M(typed)
+	}
+
+	return bson.M{}
+}
+
+// Description:
+//
+//	Gets the array at a dotted path.
+//
+// Parameters:
+//
+//	document 	The document to search.
+//	key 		The dotted path.
+//
+// Returns:
+//
+//	A copy of the array, or an empty array if the field is missing or null.
+//	An error if the field is not an array.
+func getArray(document bson.M, key string) (bson.A, error) {
+	current, ok := GetPath(document, key)
+	if !ok || current == nil {
+		return bson.A{}, nil
+	}
+
+	array, ok := Canonical(current).(bson.A)
+	if !ok {
+		return nil, fmt.Errorf("query: field '%s' is not an array", key)
+	}
+
+	return append(bson.A{}, array...), nil
+}
+
+// Description:
+//
+//	Checks whether an array contains a value.
+//
+// Parameters:
+//
+//	array The array to search.
+//	value The value to search for.
+//
+// Returns:
+//
+//	True, if the array contains an equal value.
+func containsValue(array []interface{}, value interface{}) bool {
+	for _, element := range array {
+		if valuesEqual(element, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Description:
+//
+//	Replaces field values if the comparison with the given values is accepted.
+//
+// Parameters:
+//
+//	document 	The document to modify.
+//	fields 		The key-value mappings to compare with.
+//	accept 		Decides whether a comparison result of given and current value is accepted.
+//
+// Returns:
+//
+//	An error if a key traverses a non-document field.
+func applyComparison(document bson.M, fields map[string]interface{}, accept func(result int) bool) error {
+	for key, value := range fields {
+		current, ok := GetPath(document, key)
+
+		if ok && !accept(CompareValues(value, current)) {
+			continue
+		}
+
+		err := SetPath(document, key, Canonical(value))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Description:
+//
+//	Adds two canonical numbers, following the MongoDB type promotion rules.
+//	Integers are widened to 64 bit on overflow, mixed operands result in a double.
+//
+// Parameters:
+//
+//	left 	The left number.
+//	right 	The right number.
+//
+// Returns:
+//
+//	The sum.
+func AddNumbers(left interface{}, right interface{}) interface{} {
+	leftInt, leftIsInt := toInt(left)
+	rightInt, rightIsInt := toInt(right)
+
+	if !leftIsInt || !rightIsInt {
+		return ToFloat(left) + ToFloat(right)
+	}
+
+	sum := leftInt + rightInt
+	_, leftIs32 := left.(int32)
+	_, rightIs32 := right.(int32)
+
+	if leftIs32 && rightIs32 && sum >= math.MinInt32 && sum <= math.MaxInt32 {
+		return int32(sum)
+	}
+
+	return sum
+}
+
+// Description:
+//
+//	Converts a canonical integer value to int64.
+//
+// Parameters:
+//
+//	value The canonical value.
+//
+// Returns:
+//
+//	The integer and whether the value is an integer.
+func toInt(value interface{}) (int64, bool) {
+	switch number := value.(type) {
+	case int32:
+		return int64(number), true
+	case int64:
+		return number, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,201 @@
+package store
+
+import (
+	"context"
+
+	"github.com/gostream-official/tracks/pkg/store/query"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Description:
+//
+//	The store interface.
+//	Abstracts the storage backend, so that handlers can operate
+//	on MongoDB as well as on the in-memory implementation.
+//
+//	All operations abort once the given context is cancelled or its deadline is exceeded.
+//
+// Type Parameters:
+//
+//	T The type of document stored in the store.
+type Store[T interface{}] interface {
+
+	// Description:
+	//
+	//	Creates a new item.
+	//
+	// Parameters:
+	//
+	//	ctx 	The context of the operation.
+	//	item 	The item to create.
+	//
+	// Returns:
+	//
+	//	An error if creation fails.
+	CreateItem(ctx context.Context, item T) error
+
+	// Description:
+	//
+	//	Creates multiple items in a single bulk write.
+	//
+	// Parameters:
+	//
+	//	ctx 	The context of the operation.
+	//	items 	The items to create.
+	//	ordered Whether the items are created in order, stopping at the first failed item.
+	//			Otherwise, all items are attempted, regardless of failed items.
+	//
+	// Returns:
+	//
+	//	The bulk result, containing the errors of the failed items.
+	//	An error if the bulk write as a whole fails.
+	CreateItems(ctx context.Context, items []T, ordered bool) (*BulkResult, error)
+
+	// Description:
+	//
+	//	Queries items in the store.
+	//
+	// Parameters:
+	//
+	//	ctx 	The context of the operation.
+	//	filter 	The query filter to use.
+	//
+	// Returns:
+	//
+	//	An array of all items matching the given query filter.
+	//	An error if the query fails.
+	FindItems(ctx context.Context, filter *query.Filter) ([]T, error)
+
+	// Description:
+	//
+	//	Counts items in the store.
+	//
+	// Parameters:
+	//
+	//	ctx 	The context of the operation.
+	//	filter 	The query filter to use. The limit caps the count, if greater than zero.
+	//
+	// Returns:
+	//
+	//	The number of items matching the given query filter.
+	//	An error if the query fails.
+	CountItems(ctx context.Context, filter *query.Filter) (int64, error)
+
+	// Description:
+	//
+	//	Runs an aggregation pipeline on the items in the store.
+	//
+	// Parameters:
+	//
+	//	ctx 		The context of the operation.
+	//	aggregation The aggregation pipeline to run.
+	//
+	// Returns:
+	//
+	//	The output documents of the pipeline.
+	//	An error if the aggregation fails.
+	Aggregate(ctx context.Context, aggregation *Aggregation) ([]bson.M, error)
+
+	// Description:
+	//
+	//	Updates a single item.
+	//
+	// Parameters:
+	//
+	//	ctx 	The context of the operation.
+	//	filter 	The filter used for searching the documents to update.
+	//	update 	The update operator used for updating the filtered documents.
+	//
+	// Returns:
+	//
+	//	The number of modified documents.
+	//	An error if the update fails.
+	UpdateItem(ctx context.Context, filter *query.Filter, update *query.Update) (int64, error)
+
+	// Description:
+	//
+	//	Runs multiple updates in a single bulk write.
+	//
+	// Parameters:
+	//
+	//	ctx 	The context of the operation.
+	//	updates The updates to run.
+	//	ordered Whether the updates run in order, stopping at the first failed update.
+	//			Otherwise, all updates are attempted, regardless of failed updates.
+	//
+	// Returns:
+	//
+	//	The bulk result, containing the errors of the failed updates.
+	//	An error if the bulk write as a whole fails.
+	UpdateItems(ctx context.Context, updates []BulkUpdate, ordered bool) (*BulkResult, error)
+
+	// Description:
+	//
+	//	Deletes an item by its ID.
+	//
+	// Parameters:
+	//
+	//	ctx The context of the operation.
+	//	id 	The ID of the document to delete.
+	//
+	// Returns:
+	//
+	//	The number of deleted documents.
+	//	An error if the request fails.
+	DeleteItem(ctx context.Context, id string) (int64, error)
+
+	// Description:
+	//
+	//	Deletes all items matching the given filter.
+	//
+	// Parameters:
+	//
+	//	ctx 	The context of the operation.
+	//	filter 	The filter used for searching the documents to delete.
+	//
+	// Returns:
+	//
+	//	The number of deleted documents.
+	//	An error if the request fails.
+	DeleteItems(ctx context.Context, filter *query.Filter) (int64, error)
+
+	// Description:
+	//
+	//	Creates the given indexes, unless they already exist.
+	//
+	// Parameters:
+	//
+	//	ctx 	The context of the operation.
+	//	indexes The indexes to create.
+	//
+	// Returns:
+	//
+	//	An error if an index cannot be created.
+	EnsureIndexes(ctx context.Context, indexes []Index) error
+}
+
+// Description:
+//
+//	The transactor interface.
+//	Runs multiple store operations, possibly spanning several collections, atomically.
+//	Implemented by the MongoDB and the in-memory instance.
+type Transactor interface {
+
+	// Description:
+	//
+	//	Runs a function within a transaction.
+	//	Store operations join the transaction by using the transaction context passed to the function.
+	//	The transaction commits if the function succeeds and aborts if the function fails.
+	//	The function may be retried on transient errors, so it must not have other side effects.
+	//
+	// Parameters:
+	//
+	//	ctx The context of the transaction.
+	//	fn 	The function to run.
+	//
+	// Returns:
+	//
+	//	The error returned by the function.
+	//	An error if the transaction cannot be started or committed.
+	WithTransaction(ctx context.Context, fn func(tx context.Context) error) error
+}